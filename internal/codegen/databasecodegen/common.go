@@ -10,8 +10,13 @@ func (g CommonCodeGenerator) SpannerReadTransactionType() string {
 	return "SpannerReadTransaction"
 }
 
+func (g CommonCodeGenerator) EtagFunction() string {
+	return "rowEtag"
+}
+
 func (g CommonCodeGenerator) GenerateCode(f *codegen.File) {
 	g.generateSpannerReadTransactionInterface(f)
+	g.generateEtagFunction(f)
 }
 
 func (g CommonCodeGenerator) generateSpannerReadTransactionInterface(f *codegen.File) {
@@ -38,3 +43,32 @@ func (g CommonCodeGenerator) generateSpannerReadTransactionInterface(f *codegen.
 	f.P("Query(ctx ", contextPkg, ".Context, statement ", spannerPkg, ".Statement) *", spannerPkg, ".RowIterator")
 	f.P("}")
 }
+
+func (g CommonCodeGenerator) generateEtagFunction(f *codegen.File) {
+	sha256Pkg := f.Import("crypto/sha256")
+	hexPkg := f.Import("encoding/hex")
+	jsonPkg := f.Import("encoding/json")
+	fmtPkg := f.Import("fmt")
+	timePkg := f.Import("time")
+	spannerPkg := f.Import("cloud.google.com/go/spanner")
+	f.P()
+	f.P("func ", g.EtagFunction(), "(values ...interface{}) string {")
+	f.P("hash := ", sha256Pkg, ".New()")
+	f.P("for _, value := range values {")
+	f.P("switch v := value.(type) {")
+	f.P("case ", timePkg, ".Time:")
+	f.P("value = v.UTC()")
+	f.P("case ", spannerPkg, ".NullTime:")
+	f.P("v.Time = v.Time.UTC()")
+	f.P("value = v")
+	f.P("}")
+	f.P("data, err := ", jsonPkg, ".Marshal(value)")
+	f.P("if err != nil {")
+	f.P("data = []byte(", fmtPkg, ".Sprint(value))")
+	f.P("}")
+	f.P("_, _ = hash.Write(data)")
+	f.P("_, _ = hash.Write([]byte{0})")
+	f.P("}")
+	f.P("return ", hexPkg, ".EncodeToString(hash.Sum(nil)[:16])")
+	f.P("}")
+}
@@ -1,12 +1,15 @@
 package databasecodegen
 
 import (
+	"cloud.google.com/go/spanner/spansql"
 	"go.einride.tech/spanner-aip/internal/codegen"
 	"go.einride.tech/spanner-aip/spanddl"
 )
 
 type DatabaseCodeGenerator struct {
 	Database *spanddl.Database
+	// EtagColumn is the column to compute row etags from, in tables that have the column.
+	EtagColumn spansql.ID
 }
 
 func (g DatabaseCodeGenerator) GenerateCode(f *codegen.File) {
@@ -16,9 +19,12 @@ func (g DatabaseCodeGenerator) GenerateCode(f *codegen.File) {
 	for _, table := range g.Database.Tables {
 		KeyCodeGenerator{Table: table}.GenerateCode(f)
 	}
+	for _, table := range g.Database.Tables {
+		EtagCodeGenerator{Table: table, Column: g.EtagColumn}.GenerateCode(f)
+	}
 	for _, table := range g.Database.Tables {
 		RowIteratorCodeGenerator{Table: table}.GenerateCode(f)
 	}
-	ReadTransactionCodeGenerator(g).GenerateCode(f)
+	ReadTransactionCodeGenerator{Database: g.Database}.GenerateCode(f)
 	CommonCodeGenerator{}.GenerateCode(f)
 }
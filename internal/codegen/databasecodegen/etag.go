@@ -0,0 +1,133 @@
+package databasecodegen
+
+import (
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/internal/codegen"
+	"go.einride.tech/spanner-aip/spanddl"
+)
+
+type EtagCodeGenerator struct {
+	Table *spanddl.Table
+	// Column is the column to compute etags from, e.g. update_time or a revision column.
+	// Tables without the column get etags computed from all columns.
+	Column spansql.ID
+}
+
+func (g EtagCodeGenerator) EtagMethod() string {
+	return "Etag"
+}
+
+func (g EtagCodeGenerator) CheckEtagMethod() string {
+	return "CheckEtag"
+}
+
+func (g EtagCodeGenerator) GenerateCode(f *codegen.File) {
+	g.generateEtagMethod(f)
+	g.generateCheckEtagMethod(f)
+	g.generateUpdateIfEtagMethod(f)
+	g.generateDeleteIfEtagMethod(f)
+}
+
+func (g EtagCodeGenerator) generateEtagMethod(f *codegen.File) {
+	row := RowCodeGenerator{Table: g.Table}
+	common := CommonCodeGenerator{}
+	f.P()
+	f.P("func (r *", row.Type(), ") ", g.EtagMethod(), "() string {")
+	f.P("return ", common.EtagFunction(), "(")
+	for _, column := range g.etagColumns() {
+		f.P("r.", row.ColumnFieldName(column), ",")
+	}
+	f.P(")")
+	f.P("}")
+}
+
+func (g EtagCodeGenerator) generateCheckEtagMethod(f *codegen.File) {
+	row := RowCodeGenerator{Table: g.Table}
+	key := KeyCodeGenerator{Table: g.Table}
+	contextPkg := f.Import("context")
+	spannerPkg := f.Import("cloud.google.com/go/spanner")
+	statusPkg := f.Import("google.golang.org/grpc/status")
+	codesPkg := f.Import("google.golang.org/grpc/codes")
+	f.P()
+	f.P("func (k ", key.Type(), ") ", g.CheckEtagMethod(), "(")
+	f.P("ctx ", contextPkg, ".Context,")
+	f.P("tx *", spannerPkg, ".ReadWriteTransaction,")
+	f.P("etag string,")
+	f.P(") error {")
+	f.P("spannerRow, err := tx.ReadRow(")
+	f.P("ctx,")
+	f.P(strconv.Quote(string(g.Table.Name)), ",")
+	f.P("k.SpannerKey(),")
+	f.P("[]string{")
+	for _, column := range g.etagColumns() {
+		f.P(strconv.Quote(string(column.Name)), ",")
+	}
+	f.P("},")
+	f.P(")")
+	f.P("if err != nil {")
+	f.P("return err")
+	f.P("}")
+	f.P("var row ", row.Type())
+	f.P("if err := row.", row.UnmarshalSpannerRowMethod(), "(spannerRow); err != nil {")
+	f.P("return err")
+	f.P("}")
+	f.P("if etag != \"\" && etag != row.", g.EtagMethod(), "() {")
+	f.P(
+		"return ", statusPkg, ".Errorf(", codesPkg, ".FailedPrecondition, ",
+		strconv.Quote("etag mismatch for "+string(g.Table.Name)+" row %v"), ", k)",
+	)
+	f.P("}")
+	f.P("return nil")
+	f.P("}")
+}
+
+func (g EtagCodeGenerator) generateUpdateIfEtagMethod(f *codegen.File) {
+	key := KeyCodeGenerator{Table: g.Table}
+	contextPkg := f.Import("context")
+	spannerPkg := f.Import("cloud.google.com/go/spanner")
+	f.P()
+	f.P("func (k ", key.Type(), ") UpdateIfEtag(")
+	f.P("ctx ", contextPkg, ".Context,")
+	f.P("tx *", spannerPkg, ".ReadWriteTransaction,")
+	f.P("etag string,")
+	f.P("mutation *", spannerPkg, ".Mutation,")
+	f.P(") error {")
+	f.P("if err := k.", g.CheckEtagMethod(), "(ctx, tx, etag); err != nil {")
+	f.P("return err")
+	f.P("}")
+	f.P("return tx.BufferWrite([]*", spannerPkg, ".Mutation{mutation})")
+	f.P("}")
+}
+
+func (g EtagCodeGenerator) generateDeleteIfEtagMethod(f *codegen.File) {
+	key := KeyCodeGenerator{Table: g.Table}
+	contextPkg := f.Import("context")
+	spannerPkg := f.Import("cloud.google.com/go/spanner")
+	f.P()
+	f.P("func (k ", key.Type(), ") DeleteIfEtag(")
+	f.P("ctx ", contextPkg, ".Context,")
+	f.P("tx *", spannerPkg, ".ReadWriteTransaction,")
+	f.P("etag string,")
+	f.P(") error {")
+	f.P("if err := k.", g.CheckEtagMethod(), "(ctx, tx, etag); err != nil {")
+	f.P("return err")
+	f.P("}")
+	f.P("return tx.BufferWrite([]*", spannerPkg, ".Mutation{k.Delete()})")
+	f.P("}")
+}
+
+// etagColumns returns the configured etag column if the table has it, and otherwise all columns of the table.
+// Column names are case-insensitive.
+func (g EtagCodeGenerator) etagColumns() []*spanddl.Column {
+	var result []*spanddl.Column
+	for column := range g.Table.QueryableColumns() {
+		if g.Column != "" && strings.EqualFold(string(column.Name), string(g.Column)) {
+			return []*spanddl.Column{column}
+		}
+		result = append(result, column)
+	}
+	return result
+}
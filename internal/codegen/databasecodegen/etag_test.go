@@ -0,0 +1,47 @@
+package databasecodegen
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanddl"
+	"gotest.tools/v3/assert"
+)
+
+func TestEtagCodeGenerator_etagColumns(t *testing.T) {
+	t.Parallel()
+	ddl, err := spansql.ParseDDL("", `
+		CREATE TABLE Shippers (
+			ShipperId STRING(63) NOT NULL,
+			UpdateTime TIMESTAMP NOT NULL,
+		) PRIMARY KEY (ShipperId);
+	`)
+	assert.NilError(t, err)
+	var db spanddl.Database
+	assert.NilError(t, db.ApplyDDL(ddl))
+	table, ok := db.Table("Shippers")
+	assert.Assert(t, ok)
+	columnNames := func(columns []*spanddl.Column) []spansql.ID {
+		result := make([]spansql.ID, 0, len(columns))
+		for _, column := range columns {
+			result = append(result, column.Name)
+		}
+		return result
+	}
+	for _, tt := range []struct {
+		name     string
+		column   spansql.ID
+		expected []spansql.ID
+	}{
+		{name: "no column", expected: []spansql.ID{"ShipperId", "UpdateTime"}},
+		{name: "column", column: "UpdateTime", expected: []spansql.ID{"UpdateTime"}},
+		{name: "column in other case", column: "updatetime", expected: []spansql.ID{"UpdateTime"}},
+		{name: "missing column", column: "Revision", expected: []spansql.ID{"ShipperId", "UpdateTime"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual := EtagCodeGenerator{Table: table, Column: tt.column}.etagColumns()
+			assert.DeepEqual(t, tt.expected, columnNames(actual))
+		})
+	}
+}
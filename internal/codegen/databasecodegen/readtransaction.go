@@ -1,6 +1,7 @@
 package databasecodegen
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 
@@ -50,6 +51,14 @@ func (g ReadTransactionCodeGenerator) ListMethod(table *spanddl.Table) string {
 	return "List" + strcase.UpperCamelCase(string(table.Name)) + "Rows"
 }
 
+func (g ReadTransactionCodeGenerator) SearchMethod(table *spanddl.Table) string {
+	return "Search" + strcase.UpperCamelCase(string(table.Name)) + "Rows"
+}
+
+func (g ReadTransactionCodeGenerator) SearchQueryStruct(table *spanddl.Table) string {
+	return g.SearchMethod(table) + "Query"
+}
+
 func (g ReadTransactionCodeGenerator) ReadInterleavedMethod(table *spanddl.Table) string {
 	return "readInterleaved" + strcase.UpperCamelCase(string(table.Name)) + "Rows"
 }
@@ -77,6 +86,10 @@ func (g ReadTransactionCodeGenerator) GenerateCode(f *codegen.File) {
 		g.generateBatchGetMethod(f, table)
 		g.generateListQueryStruct(f, table)
 		g.generateListMethod(f, table)
+		if len(g.searchColumns(table)) > 0 {
+			g.generateSearchQueryStruct(f, table)
+			g.generateSearchMethod(f, table)
+		}
 		if len(table.InterleavedTables) > 0 {
 			g.generateReadInterleavedRowsQuery(f, table)
 			g.generateReadInterleavedRowsResult(f, table)
@@ -249,6 +262,7 @@ func (g ReadTransactionCodeGenerator) generateListQueryStruct(f *codegen.File, t
 	}
 	g.generateInterleavedTablesStructFields(f, table)
 	f.P("}")
+	g.generateListQueryFromListMethod(f, table)
 	if len(table.InterleavedTables) > 0 {
 		f.P()
 		f.P("func (q *", g.ListQueryStruct(table), ") hasInterleavedTables() bool {")
@@ -257,6 +271,21 @@ func (g ReadTransactionCodeGenerator) generateListQueryStruct(f *codegen.File, t
 	}
 }
 
+// generateListQueryFromListMethod generates a method that sets the condition, ordering and page of a List query from a
+// parsed List request, keeping the other fields of the query.
+func (g ReadTransactionCodeGenerator) generateListQueryFromListMethod(f *codegen.File, table *spanddl.Table) {
+	spanlistPkg := f.Import("go.einride.tech/spanner-aip/spanlist")
+	f.P()
+	f.P("func (q ", g.ListQueryStruct(table), ") FromList(query *", spanlistPkg, ".Query) ", g.ListQueryStruct(table), " {")
+	f.P("q.Where = query.Where")
+	f.P("q.Order = query.Order")
+	f.P("q.Limit = query.Limit")
+	f.P("q.Offset = query.Offset")
+	f.P("q.Params = query.Params")
+	f.P("return q")
+	f.P("}")
+}
+
 func (g ReadTransactionCodeGenerator) generateListMethod(f *codegen.File, table *spanddl.Table) {
 	const (
 		limitParam  = "__limit"
@@ -358,6 +387,251 @@ func (g ReadTransactionCodeGenerator) generateListMethod(f *codegen.File, table
 	f.P("}")
 }
 
+// searchColumn is a TOKENLIST column indexed by a search index.
+type searchColumn struct {
+	column *spanddl.Column
+	// search is the search function for the column's tokenizer.
+	search string
+	// score is the score function for the column's tokenizer, if any.
+	score string
+}
+
+// searchColumns returns the TOKENLIST columns of the table that are indexed by search indexes, and that are tokenized
+// by a text tokenizer. TOKENLIST columns without a search index are not searchable.
+func (g ReadTransactionCodeGenerator) searchColumns(table *spanddl.Table) []searchColumn {
+	var result []searchColumn
+	for _, searchIndex := range g.Database.SearchIndexes {
+		if searchIndex.Table != table.Name {
+			continue
+		}
+	ColumnLoop:
+		for _, keyPart := range searchIndex.Columns {
+			column, ok := table.Column(keyPart.Column)
+			if !ok {
+				continue
+			}
+			for _, existing := range result {
+				if existing.column == column {
+					continue ColumnLoop
+				}
+			}
+			tokenizer, ok := column.Generated.(spansql.Func)
+			if !ok {
+				continue
+			}
+			switch strings.ToUpper(tokenizer.Name) {
+			case "TOKENIZE_FULLTEXT":
+				result = append(result, searchColumn{column: column, search: "SEARCH", score: "SCORE"})
+			case "TOKENIZE_NGRAMS":
+				result = append(result, searchColumn{column: column, search: "SEARCH_NGRAMS", score: "SCORE_NGRAMS"})
+			case "TOKENIZE_SUBSTRING":
+				result = append(result, searchColumn{column: column, search: "SEARCH_SUBSTRING"})
+			}
+		}
+	}
+	return result
+}
+
+// searchIndexes returns the search indexes of the table that index any of the search columns, with the search columns
+// that they index.
+func (g ReadTransactionCodeGenerator) searchIndexes(table *spanddl.Table) []searchIndex {
+	searchColumns := g.searchColumns(table)
+	var result []searchIndex
+	for _, index := range g.Database.SearchIndexes {
+		if index.Table != table.Name {
+			continue
+		}
+		var columns []searchColumn
+		for _, keyPart := range index.Columns {
+			for _, searchColumn := range searchColumns {
+				if searchColumn.column.Name == keyPart.Column {
+					columns = append(columns, searchColumn)
+				}
+			}
+		}
+		if len(columns) > 0 {
+			result = append(result, searchIndex{index: index, columns: columns})
+		}
+	}
+	return result
+}
+
+// searchIndex is a search index and the search columns that it indexes.
+type searchIndex struct {
+	index   *spanddl.SearchIndex
+	columns []searchColumn
+}
+
+// searchPartitionColumns returns the PARTITION BY columns of the search indexes of the table, with the search columns
+// of the indexes that are partitioned by them.
+//
+// Searches on a partitioned search index must have equality conditions on the partition columns.
+func (g ReadTransactionCodeGenerator) searchPartitionColumns(table *spanddl.Table) []searchPartitionColumn {
+	var result []searchPartitionColumn
+	for _, index := range g.searchIndexes(table) {
+	PartitionLoop:
+		for _, partitionBy := range index.index.PartitionBy {
+			column, ok := table.Column(partitionBy)
+			if !ok {
+				continue
+			}
+			for i := range result {
+				if result[i].column == column {
+					result[i].searchColumns = append(result[i].searchColumns, index.columns...)
+					continue PartitionLoop
+				}
+			}
+			result = append(result, searchPartitionColumn{column: column, searchColumns: index.columns})
+		}
+	}
+	return result
+}
+
+// searchPartitionColumn is a PARTITION BY column of search indexes, and the search columns of the indexes.
+type searchPartitionColumn struct {
+	column        *spanddl.Column
+	searchColumns []searchColumn
+}
+
+func (g ReadTransactionCodeGenerator) generateSearchQueryStruct(f *codegen.File, table *spanddl.Table) {
+	spansqlPkg := f.Import("cloud.google.com/go/spanner/spansql")
+	row := RowCodeGenerator{Table: table}
+	f.P()
+	f.P("type ", g.SearchQueryStruct(table), " struct {")
+	for _, searchColumn := range g.searchColumns(table) {
+		f.P(strcase.UpperCamelCase(string(searchColumn.column.Name)), " string")
+	}
+	for _, partitionColumn := range g.searchPartitionColumns(table) {
+		f.P(row.ColumnFieldName(partitionColumn.column), " ", row.columnType(f, partitionColumn.column))
+	}
+	f.P("Where  ", spansqlPkg, ".BoolExpr")
+	f.P("Order  []", spansqlPkg, ".Order")
+	f.P("Limit  int32")
+	f.P("Offset int64")
+	f.P("Params map[string]interface{}")
+	if g.hasSoftDelete(table) {
+		f.P("ShowDeleted bool")
+	}
+	g.generateInterleavedTablesStructFields(f, table)
+	f.P("}")
+}
+
+func (g ReadTransactionCodeGenerator) generateSearchMethod(f *codegen.File, table *spanddl.Table) {
+	rowIterator := RowIteratorCodeGenerator{Table: table}
+	key := KeyCodeGenerator{Table: table}
+	contextPkg := f.Import("context")
+	fmtPkg := f.Import("fmt")
+	spansqlPkg := f.Import("cloud.google.com/go/spanner/spansql")
+	row := RowCodeGenerator{Table: table}
+	searchColumns := g.searchColumns(table)
+	searchIndexes := g.searchIndexes(table)
+	partitionColumns := g.searchPartitionColumns(table)
+	var hasOrder bool
+	for _, searchColumn := range searchColumns {
+		hasOrder = hasOrder || searchColumn.score != ""
+	}
+	for _, index := range searchIndexes {
+		hasOrder = hasOrder || len(index.index.OrderBy) > 0
+	}
+	f.P()
+	f.P("func (t ", g.Type(), ") ", g.SearchMethod(table), "(")
+	f.P("ctx ", contextPkg, ".Context,")
+	f.P("query ", g.SearchQueryStruct(table), ",")
+	f.P(") ", rowIterator.InterfaceType(), " {")
+	f.P("params := make(map[string]interface{}, len(query.Params)+", len(searchColumns)+len(partitionColumns), ")")
+	f.P("for param, value := range query.Params {")
+	f.P("params[param] = value")
+	f.P("}")
+	f.P("if query.Where == nil {")
+	f.P("query.Where = ", spansqlPkg, ".True")
+	f.P("}")
+	if hasOrder {
+		f.P("var order []", spansqlPkg, ".Order")
+	}
+	for _, searchColumn := range searchColumns {
+		field := strcase.UpperCamelCase(string(searchColumn.column.Name))
+		param := strconv.Quote("__search_" + string(searchColumn.column.Name))
+		args := "[]" + spansqlPkg + ".Expr{" +
+			spansqlPkg + ".ID(" + strconv.Quote(string(searchColumn.column.Name)) + "), " +
+			spansqlPkg + ".Param(" + param + ")}"
+		f.P("if query.", field, " != \"\" {")
+		f.P("if _, ok := params[", param, "]; ok {")
+		f.P("panic(", fmtPkg, `.Errorf("invalid param: %s", `, param, "))")
+		f.P("}")
+		f.P("params[", param, "] = query.", field)
+		f.P("query.Where = ", spansqlPkg, ".LogicalOp{")
+		f.P("Op: ", spansqlPkg, ".And,")
+		f.P("LHS: ", spansqlPkg, ".Paren{Expr: query.Where},")
+		f.P("RHS: ", spansqlPkg, ".Func{")
+		f.P("Name: ", strconv.Quote(searchColumn.search), ",")
+		f.P("Args: ", args, ",")
+		f.P("},")
+		f.P("}")
+		if searchColumn.score != "" {
+			f.P("order = append(order, ", spansqlPkg, ".Order{")
+			f.P("Expr: ", spansqlPkg, ".Func{")
+			f.P("Name: ", strconv.Quote(searchColumn.score), ",")
+			f.P("Args: ", args, ",")
+			f.P("},")
+			f.P("Desc: true,")
+			f.P("})")
+		}
+		f.P("}")
+	}
+	for _, partitionColumn := range partitionColumns {
+		param := strconv.Quote("__search_partition_" + string(partitionColumn.column.Name))
+		f.P("if ", searchedPredicate(partitionColumn.searchColumns), " {")
+		f.P("if _, ok := params[", param, "]; ok {")
+		f.P("panic(", fmtPkg, `.Errorf("invalid param: %s", `, param, "))")
+		f.P("}")
+		f.P("params[", param, "] = query.", row.ColumnFieldName(partitionColumn.column))
+		f.P("query.Where = ", spansqlPkg, ".LogicalOp{")
+		f.P("Op: ", spansqlPkg, ".And,")
+		f.P("LHS: ", spansqlPkg, ".Paren{Expr: query.Where},")
+		f.P("RHS: ", spansqlPkg, ".ComparisonOp{")
+		f.P("Op: ", spansqlPkg, ".Eq,")
+		f.P("LHS: ", spansqlPkg, ".ID(", strconv.Quote(string(partitionColumn.column.Name)), "),")
+		f.P("RHS: ", spansqlPkg, ".Param(", param, "),")
+		f.P("},")
+		f.P("}")
+		f.P("}")
+	}
+	for _, index := range searchIndexes {
+		if len(index.index.OrderBy) == 0 {
+			continue
+		}
+		f.P("if ", searchedPredicate(index.columns), " {")
+		f.P("order = append(")
+		f.P("order,")
+		for _, order := range index.index.OrderBy {
+			id, ok := order.Expr.(spansql.ID)
+			if !ok {
+				continue
+			}
+			f.P(spansqlPkg, ".Order{Expr: ", spansqlPkg, ".ID(", strconv.Quote(string(id)), "), Desc: ", order.Desc, "},")
+		}
+		f.P(")")
+		f.P("}")
+	}
+	if hasOrder {
+		f.P("if len(query.Order) == 0 && len(order) > 0 {")
+		f.P("query.Order = append(order, ", key.Type(), "{}.Order()...)")
+		f.P("}")
+	}
+	f.P("return t.", g.ListMethod(table), "(ctx, ", g.ListQueryStruct(table), "{")
+	f.P("Where: query.Where,")
+	f.P("Order: query.Order,")
+	f.P("Limit: query.Limit,")
+	f.P("Offset: query.Offset,")
+	f.P("Params: params,")
+	if g.hasSoftDelete(table) {
+		f.P("ShowDeleted: query.ShowDeleted,")
+	}
+	g.forwardInterleavedTablesStructFields(f, table, "query")
+	f.P("})")
+	f.P("}")
+}
+
 func (g ReadTransactionCodeGenerator) generateReadInterleavedRowsQuery(f *codegen.File, table *spanddl.Table) {
 	spannerPkg := f.Import("cloud.google.com/go/spanner")
 	f.P()
@@ -476,29 +750,15 @@ func (g ReadTransactionCodeGenerator) generateConstructorMethod(f *codegen.File)
 
 func hasInterleavedTablesPredicate(field string, table *spanddl.Table) string {
 	var variables []string
-	var addTable func(table *spanddl.Table)
-	addTable = func(table *spanddl.Table) {
-		variables = append(variables, field+"."+strcase.UpperCamelCase(string(table.Name)))
-		for _, interleavedTable := range table.InterleavedTables {
-			addTable(interleavedTable)
-		}
-	}
-	for _, interleavedTable := range table.InterleavedTables {
-		addTable(interleavedTable)
+	for descendant := range table.Descendants() {
+		variables = append(variables, field+"."+strcase.UpperCamelCase(string(descendant.Name)))
 	}
 	return strings.Join(variables, " || ")
 }
 
 func (g ReadTransactionCodeGenerator) generateInterleavedTablesStructFields(f *codegen.File, table *spanddl.Table) {
-	var pTable func(table *spanddl.Table)
-	pTable = func(table *spanddl.Table) {
-		f.P(strcase.UpperCamelCase(string(table.Name)), " bool")
-		for _, interleavedTable := range table.InterleavedTables {
-			pTable(interleavedTable)
-		}
-	}
-	for _, interleavedTable := range table.InterleavedTables {
-		pTable(interleavedTable)
+	for descendant := range table.Descendants() {
+		f.P(strcase.UpperCamelCase(string(descendant.Name)), " bool")
 	}
 }
 
@@ -507,15 +767,9 @@ func (g ReadTransactionCodeGenerator) forwardInterleavedTablesStructFields(
 	table *spanddl.Table,
 	field string,
 ) {
-	var pTable func(table *spanddl.Table)
-	pTable = func(table *spanddl.Table) {
-		f.P(strcase.UpperCamelCase(string(table.Name)), ": ", field, ".", strcase.UpperCamelCase(string(table.Name)), ",")
-		for _, interleavedTable := range table.InterleavedTables {
-			pTable(interleavedTable)
-		}
-	}
-	for _, interleavedTable := range table.InterleavedTables {
-		pTable(interleavedTable)
+	for descendant := range table.Descendants() {
+		name := strcase.UpperCamelCase(string(descendant.Name))
+		f.P(name, ": ", field, ".", name, ",")
 	}
 }
 
@@ -540,3 +794,15 @@ func rangeInterleavedTables(table *spanddl.Table, f func(parent, child *spanddl.
 		rangeInterleavedTables(interleaved, f)
 	}
 }
+
+// searchedPredicate returns a predicate on a generated search query, that is true when any of the columns is searched.
+func searchedPredicate(columns []searchColumn) string {
+	predicates := make([]string, 0, len(columns))
+	for _, column := range columns {
+		predicate := "query." + strcase.UpperCamelCase(string(column.column.Name)) + ` != ""`
+		if !slices.Contains(predicates, predicate) {
+			predicates = append(predicates, predicate)
+		}
+	}
+	return strings.Join(predicates, " || ")
+}
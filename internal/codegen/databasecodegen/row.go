@@ -68,6 +68,8 @@ func (g RowCodeGenerator) GenerateCode(f *codegen.File) {
 	g.generateMutationFunction(f)
 	g.generateMutationForColumnsFunction(f)
 	g.generateMutationForPresentColumnsFunction(f)
+	g.generateMutationForUpdateMaskFunction(f)
+	g.generateUpdateMaskColumnFunction(f)
 	g.generatePrimaryKeyMethod(f)
 }
 
@@ -198,6 +200,165 @@ func (g RowCodeGenerator) generateMutationForPresentColumnsFunction(f *codegen.F
 	f.P("}")
 }
 
+func (g RowCodeGenerator) generateMutationForUpdateMaskFunction(f *codegen.File) {
+	spannerPkg := f.Import("cloud.google.com/go/spanner")
+	fieldmaskpbPkg := f.Import("google.golang.org/protobuf/types/known/fieldmaskpb")
+	statusPkg := f.Import("google.golang.org/grpc/status")
+	codesPkg := f.Import("google.golang.org/grpc/codes")
+	slicesPkg := f.Import("slices")
+	f.P()
+	f.P("func (r *", g.Type(), ") MutateUpdateMask(")
+	f.P("updateMask *", fieldmaskpbPkg, ".FieldMask,")
+	f.P("fieldColumns map[string]string,")
+	f.P(") (*", spannerPkg, ".Mutation, error) {")
+	f.P("columns := []string{")
+	for _, keyPart := range g.Table.PrimaryKey {
+		f.P(strconv.Quote(string(keyPart.Column)), ",")
+	}
+	f.P("}")
+	f.P("paths := updateMask.GetPaths()")
+	f.P("switch {")
+	var mutableColumns []*spanddl.Column
+	for column := range g.Table.QueryableColumns() {
+		if g.isMutable(column) {
+			mutableColumns = append(mutableColumns, column)
+		}
+	}
+	f.P("case len(paths) == 0:")
+	if len(mutableColumns) > 0 {
+		f.P("_, presentColumns, _ := r.MutatePresentColumns()")
+		f.P("for _, column := range presentColumns {")
+		f.P("switch column {")
+		f.P("case")
+		for i, column := range mutableColumns {
+			if i < len(mutableColumns)-1 {
+				f.P(strconv.Quote(string(column.Name)), ",")
+			} else {
+				f.P(strconv.Quote(string(column.Name)), ":")
+			}
+		}
+		f.P("columns = append(columns, column)")
+		f.P("}")
+		f.P("}")
+	}
+	f.P(`case `, slicesPkg, `.Contains(paths, "*"):`)
+	f.P("if len(paths) > 1 {")
+	f.P(`return nil, `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: * must be the only path")`)
+	f.P("}")
+	if len(mutableColumns) > 0 {
+		f.P("columns = append(")
+		f.P("columns,")
+		for _, column := range mutableColumns {
+			f.P(strconv.Quote(string(column.Name)), ",")
+		}
+		f.P(")")
+	}
+	f.P("default:")
+	f.P("for _, path := range paths {")
+	f.P("column, err := r.updateMaskColumn(path, fieldColumns)")
+	f.P("if err != nil {")
+	f.P("return nil, err")
+	f.P("}")
+	f.P("if !", slicesPkg, ".Contains(columns, column) {")
+	f.P("columns = append(columns, column)")
+	f.P("}")
+	f.P("}")
+	f.P("}")
+	var commitTimestampColumns []*spanddl.Column
+	for column := range g.Table.QueryableColumns() {
+		if !g.isUpdateTime(column) {
+			continue
+		}
+		if g.allowsCommitTimestamp(column) {
+			commitTimestampColumns = append(commitTimestampColumns, column)
+			continue
+		}
+		// Without commit timestamps, the update time of the row is written and must be set by the caller.
+		f.P("columns = append(columns, ", strconv.Quote(string(column.Name)), ")")
+	}
+	if len(commitTimestampColumns) == 0 {
+		f.P("return ", spannerPkg, ".Update(r.MutateColumns(columns)), nil")
+		f.P("}")
+		return
+	}
+	f.P("table, columns, values := r.MutateColumns(columns)")
+	for _, column := range commitTimestampColumns {
+		f.P("columns = append(columns, ", strconv.Quote(string(column.Name)), ")")
+		f.P("values = append(values, ", spannerPkg, ".CommitTimestamp)")
+	}
+	f.P("return ", spannerPkg, ".Update(table, columns, values), nil")
+	f.P("}")
+}
+
+func (g RowCodeGenerator) generateUpdateMaskColumnFunction(f *codegen.File) {
+	statusPkg := f.Import("google.golang.org/grpc/status")
+	codesPkg := f.Import("google.golang.org/grpc/codes")
+	f.P()
+	f.P("func (r *", g.Type(), ") updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {")
+	f.P("column, ok := fieldColumns[path]")
+	f.P("if fieldColumns == nil {")
+	f.P("switch path {")
+	for column := range g.Table.QueryableColumns() {
+		f.P("case ", strconv.Quote(strcase.SnakeCase(string(column.Name))), ":")
+		f.P("column, ok = ", strconv.Quote(string(column.Name)), ", true")
+	}
+	f.P("}")
+	f.P("}")
+	f.P("if !ok {")
+	f.P(`return "", `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: unsupported field %s", path)`)
+	f.P("}")
+	f.P("switch column {")
+	for column := range g.Table.QueryableColumns() {
+		f.P("case ", strconv.Quote(string(column.Name)), ":")
+		switch {
+		case g.isPrimaryKey(column):
+			f.P(`return "", `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: immutable field %s", path)`)
+		case column.Generated != nil:
+			f.P(`return "", `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: generated field %s", path)`)
+		case g.isOutputOnly(column):
+			f.P(`return "", `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: output only field %s", path)`)
+		default:
+			f.P("return column, nil")
+		}
+	}
+	f.P("}")
+	f.P(`return "", `, statusPkg, `.Errorf(`, codesPkg, `.InvalidArgument, "update_mask: unsupported field %s", path)`)
+	f.P("}")
+}
+
+// isMutable returns true if the column can be updated through an update mask.
+func (g RowCodeGenerator) isMutable(column *spanddl.Column) bool {
+	return !g.isPrimaryKey(column) && !g.isOutputOnly(column) && column.Generated == nil
+}
+
+func (g RowCodeGenerator) isPrimaryKey(column *spanddl.Column) bool {
+	for _, keyPart := range g.Table.PrimaryKey {
+		if keyPart.Column == column.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutputOnly returns true if the column stores one of the standard output only fields of AIP-148.
+func (g RowCodeGenerator) isOutputOnly(column *spanddl.Column) bool {
+	switch strcase.SnakeCase(string(column.Name)) {
+	case "create_time", "update_time", "delete_time":
+		return true
+	}
+	return false
+}
+
+// isUpdateTime returns true if the column stores the update time, which is updated along with any other column.
+func (g RowCodeGenerator) isUpdateTime(column *spanddl.Column) bool {
+	return strcase.SnakeCase(string(column.Name)) == "update_time"
+}
+
+// allowsCommitTimestamp returns true if the column has the allow_commit_timestamp option set.
+func (g RowCodeGenerator) allowsCommitTimestamp(column *spanddl.Column) bool {
+	return column.Options.AllowCommitTimestamp != nil && *column.Options.AllowCommitTimestamp
+}
+
 func (g RowCodeGenerator) isPresentPredicate(column *spanddl.Column) string {
 	switch {
 	case column.Type.Array:
@@ -6,11 +6,20 @@ package testdata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type SingersRow struct {
@@ -132,6 +141,80 @@ func (r *SingersRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *SingersRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"SingerId",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"FirstName",
+				"LastName",
+				"SingerInfo":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"FirstName",
+			"LastName",
+			"SingerInfo",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *SingersRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "singer_id":
+			column, ok = "SingerId", true
+		case "first_name":
+			column, ok = "FirstName", true
+		case "last_name":
+			column, ok = "LastName", true
+		case "singer_info":
+			column, ok = "SingerInfo", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "SingerId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "FirstName":
+		return column, nil
+	case "LastName":
+		return column, nil
+	case "SingerInfo":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *SingersRow) Key() SingersKey {
 	return SingersKey{
 		SingerId: r.SingerId,
@@ -172,6 +255,67 @@ func (k SingersKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *SingersRow) Etag() string {
+	return rowEtag(
+		r.SingerId,
+		r.FirstName,
+		r.LastName,
+		r.SingerInfo,
+	)
+}
+
+func (k SingersKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"Singers",
+		k.SpannerKey(),
+		[]string{
+			"SingerId",
+			"FirstName",
+			"LastName",
+			"SingerInfo",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row SingersRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for Singers row %v", k)
+	}
+	return nil
+}
+
+func (k SingersKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k SingersKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type SingersRowIterator interface {
 	Next() (*SingersRow, error)
 	Do(f func(row *SingersRow) error) error
@@ -326,6 +470,15 @@ type ListSingersRowsQuery struct {
 	Params map[string]interface{}
 }
 
+func (q ListSingersRowsQuery) FromList(query *spanlist.Query) ListSingersRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListSingersRows(
 	ctx context.Context,
 	query ListSingersRowsQuery,
@@ -373,3 +526,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -6,13 +6,22 @@ package testdata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
+	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type SingersRow struct {
@@ -139,6 +148,80 @@ func (r *SingersRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *SingersRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"SingerId",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"FirstName",
+				"LastName",
+				"SingerInfo":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"FirstName",
+			"LastName",
+			"SingerInfo",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *SingersRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "singer_id":
+			column, ok = "SingerId", true
+		case "first_name":
+			column, ok = "FirstName", true
+		case "last_name":
+			column, ok = "LastName", true
+		case "singer_info":
+			column, ok = "SingerInfo", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "SingerId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "FirstName":
+		return column, nil
+	case "LastName":
+		return column, nil
+	case "SingerInfo":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *SingersRow) Key() SingersKey {
 	return SingersKey{
 		SingerId: r.SingerId,
@@ -247,6 +330,73 @@ func (r *AlbumsRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *AlbumsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"SingerId",
+		"AlbumId",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"AlbumTitle":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"AlbumTitle",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *AlbumsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "singer_id":
+			column, ok = "SingerId", true
+		case "album_id":
+			column, ok = "AlbumId", true
+		case "album_title":
+			column, ok = "AlbumTitle", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "SingerId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "AlbumId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "AlbumTitle":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *AlbumsRow) Key() AlbumsKey {
 	return AlbumsKey{
 		SingerId: r.SingerId,
@@ -363,6 +513,78 @@ func (r *SongsRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *SongsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"SingerId",
+		"AlbumId",
+		"TrackId",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"SongName":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"SongName",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *SongsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "singer_id":
+			column, ok = "SingerId", true
+		case "album_id":
+			column, ok = "AlbumId", true
+		case "track_id":
+			column, ok = "TrackId", true
+		case "song_name":
+			column, ok = "SongName", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "SingerId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "AlbumId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "TrackId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "SongName":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *SongsRow) Key() SongsKey {
 	return SongsKey{
 		SingerId: r.SingerId,
@@ -512,6 +734,187 @@ func (k SongsKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *SingersRow) Etag() string {
+	return rowEtag(
+		r.SingerId,
+		r.FirstName,
+		r.LastName,
+		r.SingerInfo,
+	)
+}
+
+func (k SingersKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"Singers",
+		k.SpannerKey(),
+		[]string{
+			"SingerId",
+			"FirstName",
+			"LastName",
+			"SingerInfo",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row SingersRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for Singers row %v", k)
+	}
+	return nil
+}
+
+func (k SingersKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k SingersKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *AlbumsRow) Etag() string {
+	return rowEtag(
+		r.SingerId,
+		r.AlbumId,
+		r.AlbumTitle,
+	)
+}
+
+func (k AlbumsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"Albums",
+		k.SpannerKey(),
+		[]string{
+			"SingerId",
+			"AlbumId",
+			"AlbumTitle",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row AlbumsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for Albums row %v", k)
+	}
+	return nil
+}
+
+func (k AlbumsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k AlbumsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *SongsRow) Etag() string {
+	return rowEtag(
+		r.SingerId,
+		r.AlbumId,
+		r.TrackId,
+		r.SongName,
+	)
+}
+
+func (k SongsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"Songs",
+		k.SpannerKey(),
+		[]string{
+			"SingerId",
+			"AlbumId",
+			"TrackId",
+			"SongName",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row SongsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for Songs row %v", k)
+	}
+	return nil
+}
+
+func (k SongsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k SongsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type SingersRowIterator interface {
 	Next() (*SingersRow, error)
 	Do(f func(row *SingersRow) error) error
@@ -862,6 +1265,15 @@ type ListSingersRowsQuery struct {
 	Songs  bool
 }
 
+func (q ListSingersRowsQuery) FromList(query *spanlist.Query) ListSingersRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (q *ListSingersRowsQuery) hasInterleavedTables() bool {
 	return q.Albums || q.Songs
 }
@@ -1103,6 +1515,15 @@ type ListAlbumsRowsQuery struct {
 	Songs  bool
 }
 
+func (q ListAlbumsRowsQuery) FromList(query *spanlist.Query) ListAlbumsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (q *ListAlbumsRowsQuery) hasInterleavedTables() bool {
 	return q.Songs
 }
@@ -1281,6 +1702,15 @@ type ListSongsRowsQuery struct {
 	Params map[string]interface{}
 }
 
+func (q ListSongsRowsQuery) FromList(query *spanlist.Query) ListSongsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListSongsRows(
 	ctx context.Context,
 	query ListSongsRowsQuery,
@@ -1328,3 +1758,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -6,12 +6,20 @@ package testdata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type UserAccessLogRow struct {
@@ -97,6 +105,57 @@ func (r *UserAccessLogRow) MutatePresentColumns() (string, []string, []interface
 	return r.MutateColumns(columns)
 }
 
+func (r *UserAccessLogRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"UserId",
+		"LastAccess",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *UserAccessLogRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "user_id":
+			column, ok = "UserId", true
+		case "last_access":
+			column, ok = "LastAccess", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "UserId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "LastAccess":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *UserAccessLogRow) Key() UserAccessLogKey {
 	return UserAccessLogKey{
 		UserId:     r.UserId,
@@ -151,6 +210,63 @@ func (k UserAccessLogKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *UserAccessLogRow) Etag() string {
+	return rowEtag(
+		r.UserId,
+		r.LastAccess,
+	)
+}
+
+func (k UserAccessLogKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"UserAccessLog",
+		k.SpannerKey(),
+		[]string{
+			"UserId",
+			"LastAccess",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row UserAccessLogRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for UserAccessLog row %v", k)
+	}
+	return nil
+}
+
+func (k UserAccessLogKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k UserAccessLogKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type UserAccessLogRowIterator interface {
 	Next() (*UserAccessLogRow, error)
 	Do(f func(row *UserAccessLogRow) error) error
@@ -305,6 +421,15 @@ type ListUserAccessLogRowsQuery struct {
 	Params map[string]interface{}
 }
 
+func (q ListUserAccessLogRowsQuery) FromList(query *spanlist.Query) ListUserAccessLogRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListUserAccessLogRows(
 	ctx context.Context,
 	query ListUserAccessLogRowsQuery,
@@ -352,3 +477,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
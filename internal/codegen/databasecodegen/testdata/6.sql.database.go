@@ -6,14 +6,22 @@ package testdata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type ShippersRow struct {
@@ -133,6 +141,67 @@ func (r *ShippersRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *ShippersRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *ShippersRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *ShippersRow) Key() ShippersKey {
 	return ShippersKey{
 		ShipperId: r.ShipperId,
@@ -266,6 +335,72 @@ func (r *ShipmentsRow) MutatePresentColumns() (string, []string, []interface{})
 	return r.MutateColumns(columns)
 }
 
+func (r *ShipmentsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"shipment_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *ShipmentsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "shipment_id":
+			column, ok = "shipment_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "shipment_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *ShipmentsRow) Key() ShipmentsKey {
 	return ShipmentsKey{
 		ShipperId:  r.ShipperId,
@@ -354,6 +489,130 @@ func (k ShipmentsKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *ShippersRow) Etag() string {
+	return rowEtag(
+		r.ShipperId,
+		r.CreateTime,
+		r.UpdateTime,
+		r.DeleteTime,
+	)
+}
+
+func (k ShippersKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"shippers",
+		k.SpannerKey(),
+		[]string{
+			"shipper_id",
+			"create_time",
+			"update_time",
+			"delete_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row ShippersRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for shippers row %v", k)
+	}
+	return nil
+}
+
+func (k ShippersKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k ShippersKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *ShipmentsRow) Etag() string {
+	return rowEtag(
+		r.ShipperId,
+		r.ShipmentId,
+		r.CreateTime,
+		r.UpdateTime,
+		r.DeleteTime,
+	)
+}
+
+func (k ShipmentsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"shipments",
+		k.SpannerKey(),
+		[]string{
+			"shipper_id",
+			"shipment_id",
+			"create_time",
+			"update_time",
+			"delete_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row ShipmentsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for shipments row %v", k)
+	}
+	return nil
+}
+
+func (k ShipmentsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k ShipmentsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type ShippersRowIterator interface {
 	Next() (*ShippersRow, error)
 	Do(f func(row *ShippersRow) error) error
@@ -624,6 +883,15 @@ type ListShippersRowsQuery struct {
 	Shipments   bool
 }
 
+func (q ListShippersRowsQuery) FromList(query *spanlist.Query) ListShippersRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (q *ListShippersRowsQuery) hasInterleavedTables() bool {
 	return q.Shipments
 }
@@ -812,6 +1080,15 @@ type ListShipmentsRowsQuery struct {
 	ShowDeleted bool
 }
 
+func (q ListShipmentsRowsQuery) FromList(query *spanlist.Query) ListShipmentsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListShipmentsRows(
 	ctx context.Context,
 	query ListShipmentsRowsQuery,
@@ -869,3 +1146,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
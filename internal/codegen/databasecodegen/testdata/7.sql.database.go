@@ -6,12 +6,20 @@ package testdata
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type ShippersRow struct {
@@ -143,6 +151,72 @@ func (r *ShippersRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *ShippersRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"revision_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *ShippersRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "revision_id":
+			column, ok = "revision_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "revision_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *ShippersRow) Key() ShippersKey {
 	return ShippersKey{
 		ShipperId:  r.ShipperId,
@@ -203,6 +277,69 @@ func (k ShippersKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *ShippersRow) Etag() string {
+	return rowEtag(
+		r.ShipperId,
+		r.RevisionId,
+		r.CreateTime,
+		r.UpdateTime,
+		r.DeleteTime,
+	)
+}
+
+func (k ShippersKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"shippers",
+		k.SpannerKey(),
+		[]string{
+			"shipper_id",
+			"revision_id",
+			"create_time",
+			"update_time",
+			"delete_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row ShippersRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for shippers row %v", k)
+	}
+	return nil
+}
+
+func (k ShippersKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k ShippersKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type ShippersRowIterator interface {
 	Next() (*ShippersRow, error)
 	Do(f func(row *ShippersRow) error) error
@@ -358,6 +495,15 @@ type ListShippersRowsQuery struct {
 	ShowDeleted bool
 }
 
+func (q ListShippersRowsQuery) FromList(query *spanlist.Query) ListShippersRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListShippersRows(
 	ctx context.Context,
 	query ListShippersRowsQuery,
@@ -408,6 +554,62 @@ func (t ReadTransaction) ListShippersRows(
 	return iter
 }
 
+type SearchShippersRowsQuery struct {
+	ShipperIdTokens string
+	Where           spansql.BoolExpr
+	Order           []spansql.Order
+	Limit           int32
+	Offset          int64
+	Params          map[string]interface{}
+	ShowDeleted     bool
+}
+
+func (t ReadTransaction) SearchShippersRows(
+	ctx context.Context,
+	query SearchShippersRowsQuery,
+) ShippersRowIterator {
+	params := make(map[string]interface{}, len(query.Params)+1)
+	for param, value := range query.Params {
+		params[param] = value
+	}
+	if query.Where == nil {
+		query.Where = spansql.True
+	}
+	var order []spansql.Order
+	if query.ShipperIdTokens != "" {
+		if _, ok := params["__search_shipper_id_tokens"]; ok {
+			panic(fmt.Errorf("invalid param: %s", "__search_shipper_id_tokens"))
+		}
+		params["__search_shipper_id_tokens"] = query.ShipperIdTokens
+		query.Where = spansql.LogicalOp{
+			Op:  spansql.And,
+			LHS: spansql.Paren{Expr: query.Where},
+			RHS: spansql.Func{
+				Name: "SEARCH_NGRAMS",
+				Args: []spansql.Expr{spansql.ID("shipper_id_tokens"), spansql.Param("__search_shipper_id_tokens")},
+			},
+		}
+		order = append(order, spansql.Order{
+			Expr: spansql.Func{
+				Name: "SCORE_NGRAMS",
+				Args: []spansql.Expr{spansql.ID("shipper_id_tokens"), spansql.Param("__search_shipper_id_tokens")},
+			},
+			Desc: true,
+		})
+	}
+	if len(query.Order) == 0 && len(order) > 0 {
+		query.Order = append(order, ShippersKey{}.Order()...)
+	}
+	return t.ListShippersRows(ctx, ListShippersRowsQuery{
+		Where:       query.Where,
+		Order:       query.Order,
+		Limit:       query.Limit,
+		Offset:      query.Offset,
+		Params:      params,
+		ShowDeleted: query.ShowDeleted,
+	})
+}
+
 type SpannerReadTransaction interface {
 	Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
 	ReadUsingIndex(ctx context.Context, table, index string, keys spanner.KeySet, columns []string) *spanner.RowIterator
@@ -415,3 +617,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -0,0 +1,18 @@
+CREATE TABLE sites (
+    shipper_id STRING(63) NOT NULL,
+    site_id STRING(63) NOT NULL,
+    display_name STRING(63),
+    display_name_tokens TOKENLIST AS (TOKENIZE_FULLTEXT(display_name)) HIDDEN,
+    address STRING(MAX),
+    address_tokens TOKENLIST AS (TOKENIZE_SUBSTRING(address)) HIDDEN,
+    notes STRING(MAX),
+    notes_tokens TOKENLIST AS (TOKENIZE_FULLTEXT(notes)) HIDDEN,
+    update_time TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp=true),
+) PRIMARY KEY(shipper_id, site_id);
+
+CREATE SEARCH INDEX sites_by_display_name ON sites(display_name_tokens)
+PARTITION BY shipper_id
+ORDER BY update_time DESC;
+
+CREATE SEARCH INDEX sites_by_address ON sites(address_tokens)
+PARTITION BY shipper_id;
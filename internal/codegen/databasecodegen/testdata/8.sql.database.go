@@ -0,0 +1,696 @@
+// Code generated by TestDatabaseCodeGenerator_GenerateCode/database/testdata/8.sql. DO NOT EDIT.
+//go:build testdata.8.sql.database
+// +build testdata.8.sql.database
+
+package testdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type SitesRow struct {
+	ShipperId   string             `spanner:"shipper_id"`
+	SiteId      string             `spanner:"site_id"`
+	DisplayName spanner.NullString `spanner:"display_name"`
+	Address     spanner.NullString `spanner:"address"`
+	Notes       spanner.NullString `spanner:"notes"`
+	UpdateTime  time.Time          `spanner:"update_time"`
+}
+
+func (*SitesRow) ColumnNames() []string {
+	return []string{
+		"shipper_id",
+		"site_id",
+		"display_name",
+		"address",
+		"notes",
+		"update_time",
+	}
+}
+
+func (*SitesRow) ColumnIDs() []spansql.ID {
+	return []spansql.ID{
+		"shipper_id",
+		"site_id",
+		"display_name",
+		"address",
+		"notes",
+		"update_time",
+	}
+}
+
+func (*SitesRow) ColumnExprs() []spansql.Expr {
+	return []spansql.Expr{
+		spansql.ID("shipper_id"),
+		spansql.ID("site_id"),
+		spansql.ID("display_name"),
+		spansql.ID("address"),
+		spansql.ID("notes"),
+		spansql.ID("update_time"),
+	}
+}
+
+func (r *SitesRow) Validate() error {
+	if len(r.ShipperId) > 63 {
+		return fmt.Errorf("column shipper_id length > 63")
+	}
+	if len(r.SiteId) > 63 {
+		return fmt.Errorf("column site_id length > 63")
+	}
+	if !r.DisplayName.IsNull() && len(r.DisplayName.StringVal) > 63 {
+		return fmt.Errorf("column display_name length > 63")
+	}
+	return nil
+}
+
+func (r *SitesRow) UnmarshalSpannerRow(row *spanner.Row) error {
+	for i := 0; i < row.Size(); i++ {
+		switch row.ColumnName(i) {
+		case "shipper_id":
+			if err := row.Column(i, &r.ShipperId); err != nil {
+				return fmt.Errorf("unmarshal sites row: shipper_id column: %w", err)
+			}
+		case "site_id":
+			if err := row.Column(i, &r.SiteId); err != nil {
+				return fmt.Errorf("unmarshal sites row: site_id column: %w", err)
+			}
+		case "display_name":
+			if err := row.Column(i, &r.DisplayName); err != nil {
+				return fmt.Errorf("unmarshal sites row: display_name column: %w", err)
+			}
+		case "address":
+			if err := row.Column(i, &r.Address); err != nil {
+				return fmt.Errorf("unmarshal sites row: address column: %w", err)
+			}
+		case "notes":
+			if err := row.Column(i, &r.Notes); err != nil {
+				return fmt.Errorf("unmarshal sites row: notes column: %w", err)
+			}
+		case "update_time":
+			if err := row.Column(i, &r.UpdateTime); err != nil {
+				return fmt.Errorf("unmarshal sites row: update_time column: %w", err)
+			}
+		default:
+			return fmt.Errorf("unmarshal sites row: unhandled column: %s", row.ColumnName(i))
+		}
+	}
+	return nil
+}
+
+func (r *SitesRow) Mutate() (string, []string, []interface{}) {
+	return "sites", r.ColumnNames(), []interface{}{
+		r.ShipperId,
+		r.SiteId,
+		r.DisplayName,
+		r.Address,
+		r.Notes,
+		r.UpdateTime,
+	}
+}
+
+func (r *SitesRow) MutateColumns(columns []string) (string, []string, []interface{}) {
+	if len(columns) == 0 {
+		columns = r.ColumnNames()
+	}
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "shipper_id":
+			values = append(values, r.ShipperId)
+		case "site_id":
+			values = append(values, r.SiteId)
+		case "display_name":
+			values = append(values, r.DisplayName)
+		case "address":
+			values = append(values, r.Address)
+		case "notes":
+			values = append(values, r.Notes)
+		case "update_time":
+			values = append(values, r.UpdateTime)
+		default:
+			panic(fmt.Errorf("table sites does not have column %s", column))
+		}
+	}
+	return "sites", columns, values
+}
+
+func (r *SitesRow) MutatePresentColumns() (string, []string, []interface{}) {
+	columns := make([]string, 0, len(r.ColumnNames()))
+	columns = append(
+		columns,
+		"shipper_id",
+		"site_id",
+		"update_time",
+	)
+	if !r.DisplayName.IsNull() {
+		columns = append(columns, "display_name")
+	}
+	if !r.Address.IsNull() {
+		columns = append(columns, "address")
+	}
+	if !r.Notes.IsNull() {
+		columns = append(columns, "notes")
+	}
+	return r.MutateColumns(columns)
+}
+
+func (r *SitesRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"site_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"display_name",
+				"address",
+				"notes":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"display_name",
+			"address",
+			"notes",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *SitesRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "site_id":
+			column, ok = "site_id", true
+		case "display_name":
+			column, ok = "display_name", true
+		case "address":
+			column, ok = "address", true
+		case "notes":
+			column, ok = "notes", true
+		case "update_time":
+			column, ok = "update_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "site_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "display_name":
+		return column, nil
+	case "address":
+		return column, nil
+	case "notes":
+		return column, nil
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
+func (r *SitesRow) Key() SitesKey {
+	return SitesKey{
+		ShipperId: r.ShipperId,
+		SiteId:    r.SiteId,
+	}
+}
+
+type SitesKey struct {
+	ShipperId string
+	SiteId    string
+}
+
+func (k SitesKey) SpannerKey() spanner.Key {
+	return spanner.Key{
+		k.ShipperId,
+		k.SiteId,
+	}
+}
+
+func (k SitesKey) SpannerKeySet() spanner.KeySet {
+	return k.SpannerKey()
+}
+
+func (k SitesKey) Delete() *spanner.Mutation {
+	return spanner.Delete("sites", k.SpannerKey())
+}
+
+func (SitesKey) Order() []spansql.Order {
+	return []spansql.Order{
+		{Expr: spansql.ID("shipper_id"), Desc: false},
+		{Expr: spansql.ID("site_id"), Desc: false},
+	}
+}
+
+func (k SitesKey) BoolExpr() spansql.BoolExpr {
+	cmp0 := spansql.BoolExpr(spansql.ComparisonOp{
+		Op:  spansql.Eq,
+		LHS: spansql.ID("shipper_id"),
+		RHS: spansql.StringLiteral(k.ShipperId),
+	})
+	cmp1 := spansql.BoolExpr(spansql.ComparisonOp{
+		Op:  spansql.Eq,
+		LHS: spansql.ID("site_id"),
+		RHS: spansql.StringLiteral(k.SiteId),
+	})
+	b := cmp0
+	b = spansql.LogicalOp{
+		Op:  spansql.And,
+		LHS: b,
+		RHS: cmp1,
+	}
+	return spansql.Paren{Expr: b}
+}
+
+func (r *SitesRow) Etag() string {
+	return rowEtag(
+		r.ShipperId,
+		r.SiteId,
+		r.DisplayName,
+		r.Address,
+		r.Notes,
+		r.UpdateTime,
+	)
+}
+
+func (k SitesKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"sites",
+		k.SpannerKey(),
+		[]string{
+			"shipper_id",
+			"site_id",
+			"display_name",
+			"address",
+			"notes",
+			"update_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row SitesRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for sites row %v", k)
+	}
+	return nil
+}
+
+func (k SitesKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k SitesKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+type SitesRowIterator interface {
+	Next() (*SitesRow, error)
+	Do(f func(row *SitesRow) error) error
+	Stop()
+	Count() int64
+}
+
+type streamingSitesRowIterator struct {
+	*spanner.RowIterator
+}
+
+func (i *streamingSitesRowIterator) Next() (*SitesRow, error) {
+	spannerRow, err := i.RowIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	var row SitesRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (i *streamingSitesRowIterator) Do(f func(row *SitesRow) error) error {
+	return i.RowIterator.Do(func(spannerRow *spanner.Row) error {
+		var row SitesRow
+		if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+			return err
+		}
+		return f(&row)
+	})
+}
+
+func (i *streamingSitesRowIterator) Count() int64 {
+	return i.RowCount
+}
+
+type bufferedSitesRowIterator struct {
+	rows []*SitesRow
+	err  error
+}
+
+func (i *bufferedSitesRowIterator) Next() (*SitesRow, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	if len(i.rows) == 0 {
+		return nil, iterator.Done
+	}
+	next := i.rows[0]
+	i.rows = i.rows[1:]
+	return next, nil
+}
+
+func (i *bufferedSitesRowIterator) Count() int64 {
+	return int64(len(i.rows))
+}
+
+func (i *bufferedSitesRowIterator) Do(f func(row *SitesRow) error) error {
+	for {
+		row, err := i.Next()
+		switch err {
+		case iterator.Done:
+			return nil
+		case nil:
+			if err = f(row); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+func (i *bufferedSitesRowIterator) Stop() {}
+
+type ReadTransaction struct {
+	Tx SpannerReadTransaction
+}
+
+func Query(tx SpannerReadTransaction) ReadTransaction {
+	return ReadTransaction{Tx: tx}
+}
+
+func (t ReadTransaction) ReadSitesRows(
+	ctx context.Context,
+	keySet spanner.KeySet,
+) SitesRowIterator {
+	return &streamingSitesRowIterator{
+		RowIterator: t.Tx.Read(
+			ctx,
+			"sites",
+			keySet,
+			((*SitesRow)(nil)).ColumnNames(),
+		),
+	}
+}
+
+type GetSitesRowQuery struct {
+	Key SitesKey
+}
+
+func (t ReadTransaction) GetSitesRow(
+	ctx context.Context,
+	query GetSitesRowQuery,
+) (*SitesRow, error) {
+	spannerRow, err := t.Tx.ReadRow(
+		ctx,
+		"sites",
+		query.Key.SpannerKey(),
+		((*SitesRow)(nil)).ColumnNames(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var row SitesRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+type BatchGetSitesRowsQuery struct {
+	Keys []SitesKey
+}
+
+func (t ReadTransaction) BatchGetSitesRows(
+	ctx context.Context,
+	query BatchGetSitesRowsQuery,
+) (map[SitesKey]*SitesRow, error) {
+	spannerKeys := make([]spanner.KeySet, 0, len(query.Keys))
+	spannerPrefixKeys := make([]spanner.KeySet, 0, len(query.Keys))
+	for _, key := range query.Keys {
+		spannerKeys = append(spannerKeys, key.SpannerKey())
+		spannerPrefixKeys = append(spannerPrefixKeys, key.SpannerKey().AsPrefix())
+	}
+	foundRows := make(map[SitesKey]*SitesRow, len(query.Keys))
+	if err := t.ReadSitesRows(ctx, spanner.KeySets(spannerKeys...)).Do(func(row *SitesRow) error {
+		foundRows[row.Key()] = row
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return foundRows, nil
+}
+
+type ListSitesRowsQuery struct {
+	Where  spansql.BoolExpr
+	Order  []spansql.Order
+	Limit  int32
+	Offset int64
+	Params map[string]interface{}
+}
+
+func (q ListSitesRowsQuery) FromList(query *spanlist.Query) ListSitesRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
+func (t ReadTransaction) ListSitesRows(
+	ctx context.Context,
+	query ListSitesRowsQuery,
+) SitesRowIterator {
+	if len(query.Order) == 0 {
+		query.Order = SitesKey{}.Order()
+	}
+	params := make(map[string]interface{}, len(query.Params)+2)
+	params["__limit"] = int64(query.Limit)
+	params["__offset"] = int64(query.Offset)
+	for param, value := range query.Params {
+		if _, ok := params[param]; ok {
+			panic(fmt.Errorf("invalid param: %s", param))
+		}
+		params[param] = value
+	}
+	if query.Where == nil {
+		query.Where = spansql.True
+	}
+	stmt := spanner.Statement{
+		SQL: spansql.Query{
+			Select: spansql.Select{
+				List: ((*SitesRow)(nil)).ColumnExprs(),
+				From: []spansql.SelectFrom{
+					spansql.SelectFromTable{Table: "sites"},
+				},
+				Where: query.Where,
+			},
+			Order:  query.Order,
+			Limit:  spansql.Param("__limit"),
+			Offset: spansql.Param("__offset"),
+		}.SQL(),
+		Params: params,
+	}
+	iter := &streamingSitesRowIterator{
+		RowIterator: t.Tx.Query(ctx, stmt),
+	}
+	return iter
+}
+
+type SearchSitesRowsQuery struct {
+	DisplayNameTokens string
+	AddressTokens     string
+	ShipperId         string
+	Where             spansql.BoolExpr
+	Order             []spansql.Order
+	Limit             int32
+	Offset            int64
+	Params            map[string]interface{}
+}
+
+func (t ReadTransaction) SearchSitesRows(
+	ctx context.Context,
+	query SearchSitesRowsQuery,
+) SitesRowIterator {
+	params := make(map[string]interface{}, len(query.Params)+3)
+	for param, value := range query.Params {
+		params[param] = value
+	}
+	if query.Where == nil {
+		query.Where = spansql.True
+	}
+	var order []spansql.Order
+	if query.DisplayNameTokens != "" {
+		if _, ok := params["__search_display_name_tokens"]; ok {
+			panic(fmt.Errorf("invalid param: %s", "__search_display_name_tokens"))
+		}
+		params["__search_display_name_tokens"] = query.DisplayNameTokens
+		query.Where = spansql.LogicalOp{
+			Op:  spansql.And,
+			LHS: spansql.Paren{Expr: query.Where},
+			RHS: spansql.Func{
+				Name: "SEARCH",
+				Args: []spansql.Expr{spansql.ID("display_name_tokens"), spansql.Param("__search_display_name_tokens")},
+			},
+		}
+		order = append(order, spansql.Order{
+			Expr: spansql.Func{
+				Name: "SCORE",
+				Args: []spansql.Expr{spansql.ID("display_name_tokens"), spansql.Param("__search_display_name_tokens")},
+			},
+			Desc: true,
+		})
+	}
+	if query.AddressTokens != "" {
+		if _, ok := params["__search_address_tokens"]; ok {
+			panic(fmt.Errorf("invalid param: %s", "__search_address_tokens"))
+		}
+		params["__search_address_tokens"] = query.AddressTokens
+		query.Where = spansql.LogicalOp{
+			Op:  spansql.And,
+			LHS: spansql.Paren{Expr: query.Where},
+			RHS: spansql.Func{
+				Name: "SEARCH_SUBSTRING",
+				Args: []spansql.Expr{spansql.ID("address_tokens"), spansql.Param("__search_address_tokens")},
+			},
+		}
+	}
+	if query.DisplayNameTokens != "" || query.AddressTokens != "" {
+		if _, ok := params["__search_partition_shipper_id"]; ok {
+			panic(fmt.Errorf("invalid param: %s", "__search_partition_shipper_id"))
+		}
+		params["__search_partition_shipper_id"] = query.ShipperId
+		query.Where = spansql.LogicalOp{
+			Op:  spansql.And,
+			LHS: spansql.Paren{Expr: query.Where},
+			RHS: spansql.ComparisonOp{
+				Op:  spansql.Eq,
+				LHS: spansql.ID("shipper_id"),
+				RHS: spansql.Param("__search_partition_shipper_id"),
+			},
+		}
+	}
+	if query.DisplayNameTokens != "" {
+		order = append(
+			order,
+			spansql.Order{Expr: spansql.ID("update_time"), Desc: true},
+		)
+	}
+	if len(query.Order) == 0 && len(order) > 0 {
+		query.Order = append(order, SitesKey{}.Order()...)
+	}
+	return t.ListSitesRows(ctx, ListSitesRowsQuery{
+		Where:  query.Where,
+		Order:  query.Order,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+		Params: params,
+	})
+}
+
+type SpannerReadTransaction interface {
+	Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+	ReadUsingIndex(ctx context.Context, table, index string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+	ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error)
+	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
+	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
+}
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
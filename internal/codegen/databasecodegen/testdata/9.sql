@@ -0,0 +1,6 @@
+CREATE TABLE Documents (
+  DocumentId  STRING(63) NOT NULL,
+  Title       STRING(MAX),
+  TitleLength INT64 AS (CHAR_LENGTH(Title)) STORED,
+  UpdateTime  TIMESTAMP NOT NULL,
+) PRIMARY KEY (DocumentId);
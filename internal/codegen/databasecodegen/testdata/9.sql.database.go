@@ -0,0 +1,540 @@
+// Code generated by TestDatabaseCodeGenerator_GenerateCode/database/testdata/9.sql. DO NOT EDIT.
+//go:build testdata.9.sql.database
+// +build testdata.9.sql.database
+
+package testdata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+type DocumentsRow struct {
+	DocumentId  string             `spanner:"DocumentId"`
+	Title       spanner.NullString `spanner:"Title"`
+	TitleLength spanner.NullInt64  `spanner:"TitleLength"`
+	UpdateTime  time.Time          `spanner:"UpdateTime"`
+}
+
+func (*DocumentsRow) ColumnNames() []string {
+	return []string{
+		"DocumentId",
+		"Title",
+		"TitleLength",
+		"UpdateTime",
+	}
+}
+
+func (*DocumentsRow) ColumnIDs() []spansql.ID {
+	return []spansql.ID{
+		"DocumentId",
+		"Title",
+		"TitleLength",
+		"UpdateTime",
+	}
+}
+
+func (*DocumentsRow) ColumnExprs() []spansql.Expr {
+	return []spansql.Expr{
+		spansql.ID("DocumentId"),
+		spansql.ID("Title"),
+		spansql.ID("TitleLength"),
+		spansql.ID("UpdateTime"),
+	}
+}
+
+func (r *DocumentsRow) Validate() error {
+	if len(r.DocumentId) > 63 {
+		return fmt.Errorf("column DocumentId length > 63")
+	}
+	return nil
+}
+
+func (r *DocumentsRow) UnmarshalSpannerRow(row *spanner.Row) error {
+	for i := 0; i < row.Size(); i++ {
+		switch row.ColumnName(i) {
+		case "DocumentId":
+			if err := row.Column(i, &r.DocumentId); err != nil {
+				return fmt.Errorf("unmarshal Documents row: DocumentId column: %w", err)
+			}
+		case "Title":
+			if err := row.Column(i, &r.Title); err != nil {
+				return fmt.Errorf("unmarshal Documents row: Title column: %w", err)
+			}
+		case "TitleLength":
+			if err := row.Column(i, &r.TitleLength); err != nil {
+				return fmt.Errorf("unmarshal Documents row: TitleLength column: %w", err)
+			}
+		case "UpdateTime":
+			if err := row.Column(i, &r.UpdateTime); err != nil {
+				return fmt.Errorf("unmarshal Documents row: UpdateTime column: %w", err)
+			}
+		default:
+			return fmt.Errorf("unmarshal Documents row: unhandled column: %s", row.ColumnName(i))
+		}
+	}
+	return nil
+}
+
+func (r *DocumentsRow) Mutate() (string, []string, []interface{}) {
+	return "Documents", r.ColumnNames(), []interface{}{
+		r.DocumentId,
+		r.Title,
+		r.TitleLength,
+		r.UpdateTime,
+	}
+}
+
+func (r *DocumentsRow) MutateColumns(columns []string) (string, []string, []interface{}) {
+	if len(columns) == 0 {
+		columns = r.ColumnNames()
+	}
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		switch column {
+		case "DocumentId":
+			values = append(values, r.DocumentId)
+		case "Title":
+			values = append(values, r.Title)
+		case "TitleLength":
+			values = append(values, r.TitleLength)
+		case "UpdateTime":
+			values = append(values, r.UpdateTime)
+		default:
+			panic(fmt.Errorf("table Documents does not have column %s", column))
+		}
+	}
+	return "Documents", columns, values
+}
+
+func (r *DocumentsRow) MutatePresentColumns() (string, []string, []interface{}) {
+	columns := make([]string, 0, len(r.ColumnNames()))
+	columns = append(
+		columns,
+		"DocumentId",
+		"UpdateTime",
+	)
+	if !r.Title.IsNull() {
+		columns = append(columns, "Title")
+	}
+	if !r.TitleLength.IsNull() {
+		columns = append(columns, "TitleLength")
+	}
+	return r.MutateColumns(columns)
+}
+
+func (r *DocumentsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"DocumentId",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"Title":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"Title",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	columns = append(columns, "UpdateTime")
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *DocumentsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "document_id":
+			column, ok = "DocumentId", true
+		case "title":
+			column, ok = "Title", true
+		case "title_length":
+			column, ok = "TitleLength", true
+		case "update_time":
+			column, ok = "UpdateTime", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "DocumentId":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "Title":
+		return column, nil
+	case "TitleLength":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: generated field %s", path)
+	case "UpdateTime":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
+func (r *DocumentsRow) Key() DocumentsKey {
+	return DocumentsKey{
+		DocumentId: r.DocumentId,
+	}
+}
+
+type DocumentsKey struct {
+	DocumentId string
+}
+
+func (k DocumentsKey) SpannerKey() spanner.Key {
+	return spanner.Key{
+		k.DocumentId,
+	}
+}
+
+func (k DocumentsKey) SpannerKeySet() spanner.KeySet {
+	return k.SpannerKey()
+}
+
+func (k DocumentsKey) Delete() *spanner.Mutation {
+	return spanner.Delete("Documents", k.SpannerKey())
+}
+
+func (DocumentsKey) Order() []spansql.Order {
+	return []spansql.Order{
+		{Expr: spansql.ID("DocumentId"), Desc: false},
+	}
+}
+
+func (k DocumentsKey) BoolExpr() spansql.BoolExpr {
+	cmp0 := spansql.BoolExpr(spansql.ComparisonOp{
+		Op:  spansql.Eq,
+		LHS: spansql.ID("DocumentId"),
+		RHS: spansql.StringLiteral(k.DocumentId),
+	})
+	b := cmp0
+	return spansql.Paren{Expr: b}
+}
+
+func (r *DocumentsRow) Etag() string {
+	return rowEtag(
+		r.DocumentId,
+		r.Title,
+		r.TitleLength,
+		r.UpdateTime,
+	)
+}
+
+func (k DocumentsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"Documents",
+		k.SpannerKey(),
+		[]string{
+			"DocumentId",
+			"Title",
+			"TitleLength",
+			"UpdateTime",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row DocumentsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for Documents row %v", k)
+	}
+	return nil
+}
+
+func (k DocumentsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k DocumentsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+type DocumentsRowIterator interface {
+	Next() (*DocumentsRow, error)
+	Do(f func(row *DocumentsRow) error) error
+	Stop()
+	Count() int64
+}
+
+type streamingDocumentsRowIterator struct {
+	*spanner.RowIterator
+}
+
+func (i *streamingDocumentsRowIterator) Next() (*DocumentsRow, error) {
+	spannerRow, err := i.RowIterator.Next()
+	if err != nil {
+		return nil, err
+	}
+	var row DocumentsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func (i *streamingDocumentsRowIterator) Do(f func(row *DocumentsRow) error) error {
+	return i.RowIterator.Do(func(spannerRow *spanner.Row) error {
+		var row DocumentsRow
+		if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+			return err
+		}
+		return f(&row)
+	})
+}
+
+func (i *streamingDocumentsRowIterator) Count() int64 {
+	return i.RowCount
+}
+
+type bufferedDocumentsRowIterator struct {
+	rows []*DocumentsRow
+	err  error
+}
+
+func (i *bufferedDocumentsRowIterator) Next() (*DocumentsRow, error) {
+	if i.err != nil {
+		return nil, i.err
+	}
+	if len(i.rows) == 0 {
+		return nil, iterator.Done
+	}
+	next := i.rows[0]
+	i.rows = i.rows[1:]
+	return next, nil
+}
+
+func (i *bufferedDocumentsRowIterator) Count() int64 {
+	return int64(len(i.rows))
+}
+
+func (i *bufferedDocumentsRowIterator) Do(f func(row *DocumentsRow) error) error {
+	for {
+		row, err := i.Next()
+		switch err {
+		case iterator.Done:
+			return nil
+		case nil:
+			if err = f(row); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+func (i *bufferedDocumentsRowIterator) Stop() {}
+
+type ReadTransaction struct {
+	Tx SpannerReadTransaction
+}
+
+func Query(tx SpannerReadTransaction) ReadTransaction {
+	return ReadTransaction{Tx: tx}
+}
+
+func (t ReadTransaction) ReadDocumentsRows(
+	ctx context.Context,
+	keySet spanner.KeySet,
+) DocumentsRowIterator {
+	return &streamingDocumentsRowIterator{
+		RowIterator: t.Tx.Read(
+			ctx,
+			"Documents",
+			keySet,
+			((*DocumentsRow)(nil)).ColumnNames(),
+		),
+	}
+}
+
+type GetDocumentsRowQuery struct {
+	Key DocumentsKey
+}
+
+func (t ReadTransaction) GetDocumentsRow(
+	ctx context.Context,
+	query GetDocumentsRowQuery,
+) (*DocumentsRow, error) {
+	spannerRow, err := t.Tx.ReadRow(
+		ctx,
+		"Documents",
+		query.Key.SpannerKey(),
+		((*DocumentsRow)(nil)).ColumnNames(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var row DocumentsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+type BatchGetDocumentsRowsQuery struct {
+	Keys []DocumentsKey
+}
+
+func (t ReadTransaction) BatchGetDocumentsRows(
+	ctx context.Context,
+	query BatchGetDocumentsRowsQuery,
+) (map[DocumentsKey]*DocumentsRow, error) {
+	spannerKeys := make([]spanner.KeySet, 0, len(query.Keys))
+	spannerPrefixKeys := make([]spanner.KeySet, 0, len(query.Keys))
+	for _, key := range query.Keys {
+		spannerKeys = append(spannerKeys, key.SpannerKey())
+		spannerPrefixKeys = append(spannerPrefixKeys, key.SpannerKey().AsPrefix())
+	}
+	foundRows := make(map[DocumentsKey]*DocumentsRow, len(query.Keys))
+	if err := t.ReadDocumentsRows(ctx, spanner.KeySets(spannerKeys...)).Do(func(row *DocumentsRow) error {
+		foundRows[row.Key()] = row
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return foundRows, nil
+}
+
+type ListDocumentsRowsQuery struct {
+	Where  spansql.BoolExpr
+	Order  []spansql.Order
+	Limit  int32
+	Offset int64
+	Params map[string]interface{}
+}
+
+func (q ListDocumentsRowsQuery) FromList(query *spanlist.Query) ListDocumentsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
+func (t ReadTransaction) ListDocumentsRows(
+	ctx context.Context,
+	query ListDocumentsRowsQuery,
+) DocumentsRowIterator {
+	if len(query.Order) == 0 {
+		query.Order = DocumentsKey{}.Order()
+	}
+	params := make(map[string]interface{}, len(query.Params)+2)
+	params["__limit"] = int64(query.Limit)
+	params["__offset"] = int64(query.Offset)
+	for param, value := range query.Params {
+		if _, ok := params[param]; ok {
+			panic(fmt.Errorf("invalid param: %s", param))
+		}
+		params[param] = value
+	}
+	if query.Where == nil {
+		query.Where = spansql.True
+	}
+	stmt := spanner.Statement{
+		SQL: spansql.Query{
+			Select: spansql.Select{
+				List: ((*DocumentsRow)(nil)).ColumnExprs(),
+				From: []spansql.SelectFrom{
+					spansql.SelectFromTable{Table: "Documents"},
+				},
+				Where: query.Where,
+			},
+			Order:  query.Order,
+			Limit:  spansql.Param("__limit"),
+			Offset: spansql.Param("__offset"),
+		}.SQL(),
+		Params: params,
+	}
+	iter := &streamingDocumentsRowIterator{
+		RowIterator: t.Tx.Query(ctx, stmt),
+	}
+	return iter
+}
+
+type SpannerReadTransaction interface {
+	Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+	ReadUsingIndex(ctx context.Context, table, index string, keys spanner.KeySet, columns []string) *spanner.RowIterator
+	ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error)
+	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
+	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
+}
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
@@ -44,6 +44,14 @@ func (g TableDescriptorCodeGenerator) ColumnExprsMethod() string {
 	return "ColumnExprs"
 }
 
+func (g TableDescriptorCodeGenerator) ColumnTypesMethod() string {
+	return "ColumnTypes"
+}
+
+func (g TableDescriptorCodeGenerator) PrimaryKeyMethod() string {
+	return "PrimaryKey"
+}
+
 func (g TableDescriptorCodeGenerator) GenerateCode(f *codegen.File) {
 	g.generateInterface(f)
 	g.generateStruct(f)
@@ -59,6 +67,8 @@ func (g TableDescriptorCodeGenerator) generateInterface(f *codegen.File) {
 	f.P(g.ColumnNamesMethod(), "() []string")
 	f.P(g.ColumnIDsMethod(), "() []", spansqlPkg, ".ID")
 	f.P(g.ColumnExprsMethod(), "() []", spansqlPkg, ".Expr")
+	f.P(g.ColumnTypesMethod(), "() []", spansqlPkg, ".Type")
+	f.P(g.PrimaryKeyMethod(), "() []", spansqlPkg, ".KeyPart")
 	for _, column := range g.Table.Columns {
 		f.P(g.ColumnDescriptorMethod(column), "() ", genericColumnDescriptor.InterfaceType())
 	}
@@ -107,6 +117,22 @@ func (g TableDescriptorCodeGenerator) generateStruct(f *codegen.File) {
 	}
 	f.P("}")
 	f.P("}")
+	f.P()
+	f.P("func (d *", g.StructType(), ") ", g.ColumnTypesMethod(), "() []", spansqlPkg, ".Type {")
+	f.P("return []", spansqlPkg, ".Type{")
+	for column := range g.Table.QueryableColumns() {
+		f.P("d.", g.columnDescriptorField(column), ".columnType,")
+	}
+	f.P("}")
+	f.P("}")
+	f.P()
+	f.P("func (d *", g.StructType(), ") ", g.PrimaryKeyMethod(), "() []", spansqlPkg, ".KeyPart {")
+	f.P("return []", spansqlPkg, ".KeyPart{")
+	for _, keyPart := range g.Table.PrimaryKey {
+		f.P("{Column: ", strconv.Quote(string(keyPart.Column)), ", Desc: ", keyPart.Desc, "},")
+	}
+	f.P("}")
+	f.P("}")
 	for _, column := range g.Table.Columns {
 		f.P()
 		f.P("func (d *", g.StructType(), ") ", g.ColumnDescriptorMethod(column), "() ColumnDescriptor", " {")
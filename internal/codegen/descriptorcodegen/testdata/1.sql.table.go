@@ -14,6 +14,8 @@ type SingersTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	FirstName() ColumnDescriptor
 	LastName() ColumnDescriptor
@@ -63,6 +65,21 @@ func (d *singersTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *singersTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.firstName.columnType,
+		d.lastName.columnType,
+		d.singerInfo.columnType,
+	}
+}
+
+func (d *singersTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+	}
+}
+
 func (d *singersTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -87,6 +87,8 @@ type SingersTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	FirstName() ColumnDescriptor
 	LastName() ColumnDescriptor
@@ -136,6 +138,21 @@ func (d *singersTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *singersTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.firstName.columnType,
+		d.lastName.columnType,
+		d.singerInfo.columnType,
+	}
+}
+
+func (d *singersTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+	}
+}
+
 func (d *singersTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -158,6 +175,8 @@ type AlbumsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	AlbumId() ColumnDescriptor
 	AlbumTitle() ColumnDescriptor
@@ -202,6 +221,21 @@ func (d *albumsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *albumsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.albumId.columnType,
+		d.albumTitle.columnType,
+	}
+}
+
+func (d *albumsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+		{Column: "AlbumId", Desc: false},
+	}
+}
+
 func (d *albumsTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -1,12 +1,22 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/resourcename"
+	"go.einride.tech/spanner-aip/internal/migration"
 	"go.einride.tech/spanner-aip/spanddl"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
 // DatabaseConfig contains code generation config for a database.
@@ -15,32 +25,197 @@ type DatabaseConfig struct {
 	Name string `yaml:"name"`
 	// SchemaGlobs are read in ass
 	SchemaGlobs []string `yaml:"schema"`
+	// Version is the migration version to load the schema at. Defaults to the latest version.
+	//
+	// Only applicable when the schema files are golang-migrate style migrations, such as 000001_singers.up.sql.
+	// Migrations are applied in version order.
+	Version uint64 `yaml:"version"`
+	// AllowVersionGaps allows missing migration versions, e.g. for timestamp-based versions.
+	AllowVersionGaps bool `yaml:"allowVersionGaps"`
+	// CheckDownMigrations checks that each down migration reverts the schema changes of its up migration.
+	CheckDownMigrations bool `yaml:"checkDownMigrations"`
+	// Database is the resource name of a live database to load the schema from, instead of from schema files.
+	// Example: projects/my-project/instances/my-instance/databases/my-database.
+	Database string `yaml:"database"`
+	// EmulatorHost is the host of a Spanner emulator serving the live database.
+	// When empty, the SPANNER_EMULATOR_HOST environment variable and default credentials are used.
+	EmulatorHost string `yaml:"emulatorHost"`
+	// EtagColumn is the column that generated row etags are computed from, e.g. update_time or a revision column.
+	// Rows of tables without the column get etags computed from all columns.
+	EtagColumn spansql.ID `yaml:"etagColumn"`
 	// Package is the config for database's generated Go package.
 	Package GoPackageConfig `yaml:"package"`
 }
 
+// CheckEtagColumn checks that the configured etag column is a column of at least one table of the database.
+// Column names are case-insensitive.
+func (c *DatabaseConfig) CheckEtagColumn(db *spanddl.Database) error {
+	if c.EtagColumn == "" {
+		return nil
+	}
+	for _, table := range db.Tables {
+		for column := range table.QueryableColumns() {
+			if strings.EqualFold(string(column.Name), string(c.EtagColumn)) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("database %s: etag column %s not found in any table", c.Name, c.EtagColumn)
+}
+
 // LoadDatabase loads the configured database.
-func (c *DatabaseConfig) LoadDatabase() (*spanddl.Database, error) {
+func (c *DatabaseConfig) LoadDatabase(ctx context.Context) (_ *spanddl.Database, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("load database %s: %w", c.Name, err)
+		}
+	}()
+	switch {
+	case c.Database != "" && len(c.SchemaGlobs) > 0:
+		return nil, fmt.Errorf("only one of schema and database can be configured")
+	case c.Database != "":
+		return c.loadLiveDatabase(ctx)
+	default:
+		return c.loadSchemaFiles()
+	}
+}
+
+func (c *DatabaseConfig) loadSchemaFiles() (*spanddl.Database, error) {
+	schemaFiles, err := c.globSchemaFiles()
+	if err != nil {
+		return nil, err
+	}
+	if c.isMigrations(schemaFiles) {
+		return c.loadMigrations(schemaFiles)
+	}
+	if c.Version != 0 {
+		return nil, fmt.Errorf("version %d configured, but schema files are not versioned migrations", c.Version)
+	}
 	var db spanddl.Database
+	for _, schemaFile := range schemaFiles {
+		schema, err := os.ReadFile(schemaFile)
+		if err != nil {
+			return nil, err
+		}
+		ddl, err := spansql.ParseDDL(schemaFile, string(schema))
+		if err != nil {
+			return nil, err
+		}
+		if err := db.ApplyDDL(ddl); err != nil {
+			return nil, err
+		}
+	}
+	return &db, nil
+}
+
+func (c *DatabaseConfig) globSchemaFiles() ([]string, error) {
+	var result []string
 	for _, schemaGlob := range c.SchemaGlobs {
 		schemaFiles, err := filepath.Glob(schemaGlob)
 		if err != nil {
-			return nil, fmt.Errorf("load database %s: %w", c.Name, err)
+			return nil, err
 		}
 		for _, schemaFile := range schemaFiles {
-			schema, err := os.ReadFile(schemaFile)
-			if err != nil {
-				return nil, fmt.Errorf("load database %s: %w", c.Name, err)
-			}
-			ddl, err := spansql.ParseDDL(schemaFile, string(schema))
-			if err != nil {
-				return nil, fmt.Errorf("load database %s: %w", c.Name, err)
-			}
-			if err := db.ApplyDDL(ddl); err != nil {
-				return nil, fmt.Errorf("load database %s: %w", c.Name, err)
+			if !slices.Contains(result, schemaFile) {
+				result = append(result, schemaFile)
 			}
 		}
 	}
+	return result, nil
+}
+
+// isMigrations reports whether any of the schema files are versioned migrations.
+func (c *DatabaseConfig) isMigrations(schemaFiles []string) bool {
+	for _, schemaFile := range schemaFiles {
+		if _, _, _, ok := migration.ParseFilename(schemaFile); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Migrations returns the configured versioned migrations, in version order.
+func (c *DatabaseConfig) Migrations() ([]*migration.Migration, error) {
+	schemaFiles, err := c.globSchemaFiles()
+	if err != nil {
+		return nil, err
+	}
+	return c.migrationsFromFiles(schemaFiles)
+}
+
+func (c *DatabaseConfig) migrationsFromFiles(schemaFiles []string) ([]*migration.Migration, error) {
+	migrations, err := migration.FromFiles(schemaFiles)
+	if err != nil {
+		return nil, err
+	}
+	if !c.AllowVersionGaps {
+		if err := migration.CheckSequential(migrations); err != nil {
+			return nil, err
+		}
+	}
+	return migrations, nil
+}
+
+func (c *DatabaseConfig) loadMigrations(schemaFiles []string) (*spanddl.Database, error) {
+	migrations, err := c.migrationsFromFiles(schemaFiles)
+	if err != nil {
+		return nil, err
+	}
+	if c.CheckDownMigrations {
+		if err := migration.CheckRoundTrip(migrations); err != nil {
+			return nil, err
+		}
+	}
+	if c.Version != 0 {
+		if migrations, err = migration.UpTo(migrations, c.Version); err != nil {
+			return nil, err
+		}
+	}
+	var db spanddl.Database
+	if err := migration.ApplyUp(&db, migrations); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+func (c *DatabaseConfig) loadLiveDatabase(ctx context.Context) (*spanddl.Database, error) {
+	if !resourcename.Match("projects/{project}/instances/{instance}/databases/{database}", c.Database) {
+		return nil, fmt.Errorf("invalid database resource name %q", c.Database)
+	}
+	var opts []option.ClientOption
+	if c.EmulatorHost != "" {
+		conn, err := grpc.NewClient(c.EmulatorHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		opts = append(opts, option.WithGRPCConn(conn))
+	}
+	client, err := database.NewDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	response, err := client.GetDatabaseDdl(ctx, &databasepb.GetDatabaseDdlRequest{Database: c.Database})
+	if err != nil {
+		return nil, fmt.Errorf("get DDL of %s: %w", c.Database, err)
+	}
+	ddl := &spansql.DDL{Filename: c.Database}
+	for i, statement := range response.GetStatements() {
+		stmt, err := spansql.ParseDDLStmt(statement)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i, err)
+		}
+		ddl.List = append(ddl.List, stmt)
+	}
+	var db spanddl.Database
+	if err := db.ApplyDDL(ddl); err != nil {
+		return nil, err
+	}
 	return &db, nil
 }
 
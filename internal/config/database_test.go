@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+type fakeDatabaseAdminServer struct {
+	databasepb.UnimplementedDatabaseAdminServer
+	statements map[string][]string
+}
+
+func (s *fakeDatabaseAdminServer) GetDatabaseDdl(
+	_ context.Context,
+	request *databasepb.GetDatabaseDdlRequest,
+) (*databasepb.GetDatabaseDdlResponse, error) {
+	statements, ok := s.statements[request.GetDatabase()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "database %s not found", request.GetDatabase())
+	}
+	return &databasepb.GetDatabaseDdlResponse{Statements: statements}, nil
+}
+
+func newFakeDatabaseAdminServer(t *testing.T, statements map[string][]string) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	assert.NilError(t, err)
+	server := grpc.NewServer()
+	databasepb.RegisterDatabaseAdminServer(server, &fakeDatabaseAdminServer{statements: statements})
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestDatabaseConfig_LoadDatabase(t *testing.T) {
+	t.Parallel()
+	const databaseName = "projects/test/instances/test/databases/music"
+	emulatorHost := newFakeDatabaseAdminServer(t, map[string][]string{
+		databaseName: {
+			"CREATE TABLE Singers (\n  SingerId INT64 NOT NULL,\n) PRIMARY KEY(SingerId)",
+			"CREATE TABLE Albums (\n  SingerId INT64 NOT NULL,\n  AlbumId INT64 NOT NULL,\n) " +
+				"PRIMARY KEY(SingerId, AlbumId),\n  INTERLEAVE IN PARENT Singers ON DELETE CASCADE",
+		},
+	})
+
+	t.Run("schema files", func(t *testing.T) {
+		t.Parallel()
+		config := DatabaseConfig{
+			Name:        "freight",
+			SchemaGlobs: []string{"../../testdata/migrations/freight/*.up.sql"},
+		}
+		db, err := config.LoadDatabase(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, 4, len(db.Tables))
+	})
+
+	t.Run("live database", func(t *testing.T) {
+		t.Parallel()
+		config := DatabaseConfig{
+			Name:         "music",
+			Database:     databaseName,
+			EmulatorHost: emulatorHost,
+		}
+		db, err := config.LoadDatabase(context.Background())
+		assert.NilError(t, err)
+		assert.Equal(t, 2, len(db.Tables))
+		singers, ok := db.Table("Singers")
+		assert.Assert(t, ok)
+		assert.Equal(t, 1, len(singers.InterleavedTables))
+	})
+
+	t.Run("live database not found", func(t *testing.T) {
+		t.Parallel()
+		config := DatabaseConfig{
+			Name:         "music",
+			Database:     "projects/test/instances/test/databases/missing",
+			EmulatorHost: emulatorHost,
+		}
+		_, err := config.LoadDatabase(context.Background())
+		assert.ErrorContains(t, err, "load database music: get DDL of projects/test/instances/test/databases/missing")
+	})
+
+	t.Run("invalid database name", func(t *testing.T) {
+		t.Parallel()
+		config := DatabaseConfig{Name: "music", Database: "music"}
+		_, err := config.LoadDatabase(context.Background())
+		assert.ErrorContains(t, err, `invalid database resource name "music"`)
+	})
+
+	t.Run("both schema and database", func(t *testing.T) {
+		t.Parallel()
+		config := DatabaseConfig{
+			Name:        "music",
+			SchemaGlobs: []string{"*.sql"},
+			Database:    databaseName,
+		}
+		_, err := config.LoadDatabase(context.Background())
+		assert.ErrorContains(t, err, "only one of schema and database can be configured")
+	})
+}
+
+func TestDatabaseConfig_LoadDatabase_version(t *testing.T) {
+	t.Parallel()
+	config := DatabaseConfig{
+		Name:        "music",
+		SchemaGlobs: []string{"../../testdata/migrations/music/*.up.sql"},
+		Version:     2,
+	}
+	db, err := config.LoadDatabase(context.Background())
+	assert.NilError(t, err)
+	_, ok := db.Table("Playlists")
+	assert.Assert(t, !ok)
+	config.Version = 3
+	db, err = config.LoadDatabase(context.Background())
+	assert.NilError(t, err)
+	_, ok = db.Table("Playlists")
+	assert.Assert(t, ok)
+	config.Version = 4
+	_, err = config.LoadDatabase(context.Background())
+	assert.ErrorContains(t, err, "load database music: unknown migration version 4")
+}
+
+func TestDatabaseConfig_CheckEtagColumn(t *testing.T) {
+	t.Parallel()
+	config := DatabaseConfig{
+		Name:        "freight",
+		SchemaGlobs: []string{"../../testdata/migrations/freight/*.up.sql"},
+	}
+	db, err := config.LoadDatabase(context.Background())
+	assert.NilError(t, err)
+	assert.NilError(t, config.CheckEtagColumn(db))
+	config.EtagColumn = "UPDATE_TIME"
+	assert.NilError(t, config.CheckEtagColumn(db))
+	config.EtagColumn = "revision"
+	assert.ErrorContains(t, config.CheckEtagColumn(db), "database freight: etag column revision not found in any table")
+}
@@ -3,14 +3,22 @@ package freightdb
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"time"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanlist"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
 type ShippersRow struct {
@@ -130,6 +138,67 @@ func (r *ShippersRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *ShippersRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *ShippersRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *ShippersRow) Key() ShippersKey {
 	return ShippersKey{
 		ShipperId: r.ShipperId,
@@ -322,6 +391,106 @@ func (r *SitesRow) MutatePresentColumns() (string, []string, []interface{}) {
 	return r.MutateColumns(columns)
 }
 
+func (r *SitesRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"site_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"display_name",
+				"latitude",
+				"longitude",
+				"config":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"display_name",
+			"latitude",
+			"longitude",
+			"config",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *SitesRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "site_id":
+			column, ok = "site_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		case "display_name":
+			column, ok = "display_name", true
+		case "latitude":
+			column, ok = "latitude", true
+		case "longitude":
+			column, ok = "longitude", true
+		case "config":
+			column, ok = "config", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "site_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "display_name":
+		return column, nil
+	case "latitude":
+		return column, nil
+	case "longitude":
+		return column, nil
+	case "config":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *SitesRow) Key() SitesKey {
 	return SitesKey{
 		ShipperId: r.ShipperId,
@@ -551,6 +720,118 @@ func (r *ShipmentsRow) MutatePresentColumns() (string, []string, []interface{})
 	return r.MutateColumns(columns)
 }
 
+func (r *ShipmentsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"shipment_id",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"origin_site_id",
+				"destination_site_id",
+				"pickup_earliest_time",
+				"pickup_latest_time",
+				"delivery_earliest_time",
+				"delivery_latest_time":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"origin_site_id",
+			"destination_site_id",
+			"pickup_earliest_time",
+			"pickup_latest_time",
+			"delivery_earliest_time",
+			"delivery_latest_time",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	table, columns, values := r.MutateColumns(columns)
+	columns = append(columns, "update_time")
+	values = append(values, spanner.CommitTimestamp)
+	return spanner.Update(table, columns, values), nil
+}
+
+func (r *ShipmentsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "shipment_id":
+			column, ok = "shipment_id", true
+		case "create_time":
+			column, ok = "create_time", true
+		case "update_time":
+			column, ok = "update_time", true
+		case "delete_time":
+			column, ok = "delete_time", true
+		case "origin_site_id":
+			column, ok = "origin_site_id", true
+		case "destination_site_id":
+			column, ok = "destination_site_id", true
+		case "pickup_earliest_time":
+			column, ok = "pickup_earliest_time", true
+		case "pickup_latest_time":
+			column, ok = "pickup_latest_time", true
+		case "delivery_earliest_time":
+			column, ok = "delivery_earliest_time", true
+		case "delivery_latest_time":
+			column, ok = "delivery_latest_time", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "shipment_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "create_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "update_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "delete_time":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: output only field %s", path)
+	case "origin_site_id":
+		return column, nil
+	case "destination_site_id":
+		return column, nil
+	case "pickup_earliest_time":
+		return column, nil
+	case "pickup_latest_time":
+		return column, nil
+	case "delivery_earliest_time":
+		return column, nil
+	case "delivery_latest_time":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *ShipmentsRow) Key() ShipmentsKey {
 	return ShipmentsKey{
 		ShipperId:  r.ShipperId,
@@ -718,6 +999,96 @@ func (r *LineItemsRow) MutatePresentColumns() (string, []string, []interface{})
 	return r.MutateColumns(columns)
 }
 
+func (r *LineItemsRow) MutateUpdateMask(
+	updateMask *fieldmaskpb.FieldMask,
+	fieldColumns map[string]string,
+) (*spanner.Mutation, error) {
+	columns := []string{
+		"shipper_id",
+		"shipment_id",
+		"line_number",
+	}
+	paths := updateMask.GetPaths()
+	switch {
+	case len(paths) == 0:
+		_, presentColumns, _ := r.MutatePresentColumns()
+		for _, column := range presentColumns {
+			switch column {
+			case
+				"title",
+				"quantity",
+				"weight_kg",
+				"volume_m3":
+				columns = append(columns, column)
+			}
+		}
+	case slices.Contains(paths, "*"):
+		if len(paths) > 1 {
+			return nil, status.Errorf(codes.InvalidArgument, "update_mask: * must be the only path")
+		}
+		columns = append(
+			columns,
+			"title",
+			"quantity",
+			"weight_kg",
+			"volume_m3",
+		)
+	default:
+		for _, path := range paths {
+			column, err := r.updateMaskColumn(path, fieldColumns)
+			if err != nil {
+				return nil, err
+			}
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return spanner.Update(r.MutateColumns(columns)), nil
+}
+
+func (r *LineItemsRow) updateMaskColumn(path string, fieldColumns map[string]string) (string, error) {
+	column, ok := fieldColumns[path]
+	if fieldColumns == nil {
+		switch path {
+		case "shipper_id":
+			column, ok = "shipper_id", true
+		case "shipment_id":
+			column, ok = "shipment_id", true
+		case "line_number":
+			column, ok = "line_number", true
+		case "title":
+			column, ok = "title", true
+		case "quantity":
+			column, ok = "quantity", true
+		case "weight_kg":
+			column, ok = "weight_kg", true
+		case "volume_m3":
+			column, ok = "volume_m3", true
+		}
+	}
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+	}
+	switch column {
+	case "shipper_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "shipment_id":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "line_number":
+		return "", status.Errorf(codes.InvalidArgument, "update_mask: immutable field %s", path)
+	case "title":
+		return column, nil
+	case "quantity":
+		return column, nil
+	case "weight_kg":
+		return column, nil
+	case "volume_m3":
+		return column, nil
+	}
+	return "", status.Errorf(codes.InvalidArgument, "update_mask: unsupported field %s", path)
+}
+
 func (r *LineItemsRow) Key() LineItemsKey {
 	return LineItemsKey{
 		ShipperId:  r.ShipperId,
@@ -914,6 +1285,238 @@ func (k LineItemsKey) BoolExpr() spansql.BoolExpr {
 	return spansql.Paren{Expr: b}
 }
 
+func (r *ShippersRow) Etag() string {
+	return rowEtag(
+		r.UpdateTime,
+	)
+}
+
+func (k ShippersKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"shippers",
+		k.SpannerKey(),
+		[]string{
+			"update_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row ShippersRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for shippers row %v", k)
+	}
+	return nil
+}
+
+func (k ShippersKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k ShippersKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *SitesRow) Etag() string {
+	return rowEtag(
+		r.UpdateTime,
+	)
+}
+
+func (k SitesKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"sites",
+		k.SpannerKey(),
+		[]string{
+			"update_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row SitesRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for sites row %v", k)
+	}
+	return nil
+}
+
+func (k SitesKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k SitesKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *ShipmentsRow) Etag() string {
+	return rowEtag(
+		r.UpdateTime,
+	)
+}
+
+func (k ShipmentsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"shipments",
+		k.SpannerKey(),
+		[]string{
+			"update_time",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row ShipmentsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for shipments row %v", k)
+	}
+	return nil
+}
+
+func (k ShipmentsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k ShipmentsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
+func (r *LineItemsRow) Etag() string {
+	return rowEtag(
+		r.ShipperId,
+		r.ShipmentId,
+		r.LineNumber,
+		r.Title,
+		r.Quantity,
+		r.WeightKg,
+		r.VolumeM3,
+	)
+}
+
+func (k LineItemsKey) CheckEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	spannerRow, err := tx.ReadRow(
+		ctx,
+		"line_items",
+		k.SpannerKey(),
+		[]string{
+			"shipper_id",
+			"shipment_id",
+			"line_number",
+			"title",
+			"quantity",
+			"weight_kg",
+			"volume_m3",
+		},
+	)
+	if err != nil {
+		return err
+	}
+	var row LineItemsRow
+	if err := row.UnmarshalSpannerRow(spannerRow); err != nil {
+		return err
+	}
+	if etag != "" && etag != row.Etag() {
+		return status.Errorf(codes.FailedPrecondition, "etag mismatch for line_items row %v", k)
+	}
+	return nil
+}
+
+func (k LineItemsKey) UpdateIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+	mutation *spanner.Mutation,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{mutation})
+}
+
+func (k LineItemsKey) DeleteIfEtag(
+	ctx context.Context,
+	tx *spanner.ReadWriteTransaction,
+	etag string,
+) error {
+	if err := k.CheckEtag(ctx, tx, etag); err != nil {
+		return err
+	}
+	return tx.BufferWrite([]*spanner.Mutation{k.Delete()})
+}
+
 type ShippersRowIterator interface {
 	Next() (*ShippersRow, error)
 	Do(f func(row *ShippersRow) error) error
@@ -1341,6 +1944,15 @@ type ListShippersRowsQuery struct {
 	LineItems   bool
 }
 
+func (q ListShippersRowsQuery) FromList(query *spanlist.Query) ListShippersRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (q *ListShippersRowsQuery) hasInterleavedTables() bool {
 	return q.Shipments || q.LineItems
 }
@@ -1554,6 +2166,15 @@ type ListSitesRowsQuery struct {
 	ShowDeleted bool
 }
 
+func (q ListSitesRowsQuery) FromList(query *spanlist.Query) ListSitesRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListSitesRows(
 	ctx context.Context,
 	query ListSitesRowsQuery,
@@ -1714,6 +2335,15 @@ type ListShipmentsRowsQuery struct {
 	LineItems   bool
 }
 
+func (q ListShipmentsRowsQuery) FromList(query *spanlist.Query) ListShipmentsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (q *ListShipmentsRowsQuery) hasInterleavedTables() bool {
 	return q.LineItems
 }
@@ -1902,6 +2532,15 @@ type ListLineItemsRowsQuery struct {
 	Params map[string]interface{}
 }
 
+func (q ListLineItemsRowsQuery) FromList(query *spanlist.Query) ListLineItemsRowsQuery {
+	q.Where = query.Where
+	q.Order = query.Order
+	q.Limit = query.Limit
+	q.Offset = query.Offset
+	q.Params = query.Params
+	return q
+}
+
 func (t ReadTransaction) ListLineItemsRows(
 	ctx context.Context,
 	query ListLineItemsRowsQuery,
@@ -1949,3 +2588,23 @@ type SpannerReadTransaction interface {
 	ReadRowUsingIndex(ctx context.Context, table string, index string, key spanner.Key, columns []string) (*spanner.Row, error)
 	Query(ctx context.Context, statement spanner.Statement) *spanner.RowIterator
 }
+
+func rowEtag(values ...interface{}) string {
+	hash := sha256.New()
+	for _, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			value = v.UTC()
+		case spanner.NullTime:
+			v.Time = v.Time.UTC()
+			value = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			data = []byte(fmt.Sprint(value))
+		}
+		_, _ = hash.Write(data)
+		_, _ = hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
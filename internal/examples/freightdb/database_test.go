@@ -7,11 +7,13 @@ import (
 	"time"
 
 	"cloud.google.com/go/spanner"
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"go.einride.tech/spanner-aip/internal/examples/freightdb"
 	"go.einride.tech/spanner-aip/spantest"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"gotest.tools/v3/assert"
 )
 
@@ -436,6 +438,174 @@ func TestReadTransaction(t *testing.T) {
 	})
 }
 
+func TestShipmentsRow_MutateUpdateMask(t *testing.T) {
+	t.Parallel()
+	shipment := &freightdb.ShipmentsRow{
+		ShipperId:    "shipper",
+		ShipmentId:   "shipment",
+		CreateTime:   time.Unix(1, 0).UTC(),
+		OriginSiteId: spanner.NullString{StringVal: "origin", Valid: true},
+	}
+	// The update time is written as the commit timestamp, regardless of the update time of the row.
+	committed := *shipment
+	committed.UpdateTime = spanner.CommitTimestamp
+	for _, tt := range []struct {
+		name            string
+		updateMask      *fieldmaskpb.FieldMask
+		fieldColumns    map[string]string
+		expectedColumns []string
+		errorContains   string
+	}{
+		{
+			name:            "no update mask",
+			expectedColumns: []string{"shipper_id", "shipment_id", "origin_site_id", "update_time"},
+		},
+
+		{
+			name:       "full replacement",
+			updateMask: &fieldmaskpb.FieldMask{Paths: []string{"*"}},
+			expectedColumns: []string{
+				"shipper_id",
+				"shipment_id",
+				"origin_site_id",
+				"destination_site_id",
+				"pickup_earliest_time",
+				"pickup_latest_time",
+				"delivery_earliest_time",
+				"delivery_latest_time",
+				"update_time",
+			},
+		},
+
+		{
+			name:            "paths",
+			updateMask:      &fieldmaskpb.FieldMask{Paths: []string{"destination_site_id", "origin_site_id"}},
+			expectedColumns: []string{"shipper_id", "shipment_id", "destination_site_id", "origin_site_id", "update_time"},
+		},
+
+		{
+			name:       "mapped paths",
+			updateMask: &fieldmaskpb.FieldMask{Paths: []string{"origin_site", "pickup_time.start_time"}},
+			fieldColumns: map[string]string{
+				"origin_site":            "origin_site_id",
+				"pickup_time.start_time": "pickup_earliest_time",
+			},
+			expectedColumns: []string{"shipper_id", "shipment_id", "origin_site_id", "pickup_earliest_time", "update_time"},
+		},
+
+		{
+			name:          "unmapped path",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"origin_site_id"}},
+			fieldColumns:  map[string]string{"origin_site": "origin_site_id"},
+			errorContains: "update_mask: unsupported field origin_site_id",
+		},
+
+		{
+			name:          "unknown path",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"line_items"}},
+			errorContains: "update_mask: unsupported field line_items",
+		},
+
+		{
+			name:          "primary key",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"shipment_id"}},
+			errorContains: "update_mask: immutable field shipment_id",
+		},
+
+		{
+			name:          "update time",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"update_time"}},
+			errorContains: "update_mask: output only field update_time",
+		},
+
+		{
+			name:          "create time",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"create_time"}},
+			errorContains: "update_mask: output only field create_time",
+		},
+
+		{
+			name:          "wildcard with other paths",
+			updateMask:    &fieldmaskpb.FieldMask{Paths: []string{"*", "origin_site_id"}},
+			errorContains: "update_mask: * must be the only path",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := shipment.MutateUpdateMask(tt.updateMask, tt.fieldColumns)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			assert.NilError(t, err)
+			expected := spanner.Update(committed.MutateColumns(tt.expectedColumns))
+			assert.DeepEqual(t, expected, actual, cmp.AllowUnexported(spanner.Mutation{}))
+		})
+	}
+}
+
+func TestShipmentsRow_Etag(t *testing.T) {
+	t.Parallel()
+	updateTime := time.Unix(1, 0).UTC()
+	shipment := &freightdb.ShipmentsRow{ShipperId: "shipper", ShipmentId: "shipment", UpdateTime: updateTime}
+	etag := shipment.Etag()
+	assert.Assert(t, etag != "")
+	sameUpdateTime := *shipment
+	sameUpdateTime.OriginSiteId = spanner.NullString{StringVal: "origin", Valid: true}
+	sameUpdateTime.UpdateTime = updateTime.In(time.FixedZone("CET", 3600))
+	assert.Equal(t, etag, sameUpdateTime.Etag())
+	updated := *shipment
+	updated.UpdateTime = updateTime.Add(time.Second)
+	assert.Assert(t, etag != updated.Etag())
+}
+
+func TestShipmentsKey_UpdateIfEtag(t *testing.T) {
+	t.Parallel()
+	fx := spantest.NewEmulatorFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client := fx.NewDatabaseFromDDLFiles(t, ddlFileGlob)
+	populateDB(ctx, t, client)
+	key := freightdb.ShipmentsKey{ShipperId: "allexists", ShipmentId: "allexists"}
+	shipment, err := freightdb.Query(client.Single()).GetShipmentsRow(ctx, freightdb.GetShipmentsRowQuery{Key: key})
+	assert.NilError(t, err)
+	shipment.OriginSiteId = spanner.NullString{StringVal: "origin", Valid: true}
+	shipment.UpdateTime = spanner.CommitTimestamp
+	mutation, err := shipment.MutateUpdateMask(&fieldmaskpb.FieldMask{Paths: []string{"origin_site_id"}}, nil)
+	assert.NilError(t, err)
+	etag := shipment.Etag()
+
+	t.Run("mismatch", func(t *testing.T) {
+		_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			return key.UpdateIfEtag(ctx, tx, "mismatch", mutation)
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), err)
+	})
+
+	t.Run("match", func(t *testing.T) {
+		current, err := freightdb.Query(client.Single()).GetShipmentsRow(ctx, freightdb.GetShipmentsRowQuery{Key: key})
+		assert.NilError(t, err)
+		assert.Assert(t, etag != current.Etag())
+		_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			return key.UpdateIfEtag(ctx, tx, current.Etag(), mutation)
+		})
+		assert.NilError(t, err)
+		_, err = client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			return key.DeleteIfEtag(ctx, tx, current.Etag())
+		})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err), err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		notFound := freightdb.ShipmentsKey{ShipperId: "allexists", ShipmentId: "notfound"}
+		_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			return notFound.DeleteIfEtag(ctx, tx, "")
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err), err)
+	})
+}
+
 func populateDB(ctx context.Context, t *testing.T, client *spanner.Client) time.Time {
 	t.Helper()
 
@@ -246,6 +246,8 @@ type ShippersTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	ShipperId() ColumnDescriptor
 	CreateTime() ColumnDescriptor
 	UpdateTime() ColumnDescriptor
@@ -295,6 +297,21 @@ func (d *shippersTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *shippersTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.shipperId.columnType,
+		d.createTime.columnType,
+		d.updateTime.columnType,
+		d.deleteTime.columnType,
+	}
+}
+
+func (d *shippersTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "shipper_id", Desc: false},
+	}
+}
+
 func (d *shippersTableDescriptor) ShipperId() ColumnDescriptor {
 	return &d.shipperId
 }
@@ -317,6 +334,8 @@ type SitesTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	ShipperId() ColumnDescriptor
 	SiteId() ColumnDescriptor
 	CreateTime() ColumnDescriptor
@@ -391,6 +410,27 @@ func (d *sitesTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *sitesTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.shipperId.columnType,
+		d.siteId.columnType,
+		d.createTime.columnType,
+		d.updateTime.columnType,
+		d.deleteTime.columnType,
+		d.displayName.columnType,
+		d.latitude.columnType,
+		d.longitude.columnType,
+		d.config.columnType,
+	}
+}
+
+func (d *sitesTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "shipper_id", Desc: false},
+		{Column: "site_id", Desc: false},
+	}
+}
+
 func (d *sitesTableDescriptor) ShipperId() ColumnDescriptor {
 	return &d.shipperId
 }
@@ -433,6 +473,8 @@ type ShipmentsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	ShipperId() ColumnDescriptor
 	ShipmentId() ColumnDescriptor
 	CreateTime() ColumnDescriptor
@@ -517,6 +559,29 @@ func (d *shipmentsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *shipmentsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.shipperId.columnType,
+		d.shipmentId.columnType,
+		d.createTime.columnType,
+		d.updateTime.columnType,
+		d.deleteTime.columnType,
+		d.originSiteId.columnType,
+		d.destinationSiteId.columnType,
+		d.pickupEarliestTime.columnType,
+		d.pickupLatestTime.columnType,
+		d.deliveryEarliestTime.columnType,
+		d.deliveryLatestTime.columnType,
+	}
+}
+
+func (d *shipmentsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "shipper_id", Desc: false},
+		{Column: "shipment_id", Desc: false},
+	}
+}
+
 func (d *shipmentsTableDescriptor) ShipperId() ColumnDescriptor {
 	return &d.shipperId
 }
@@ -567,6 +632,8 @@ type LineItemsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	ShipperId() ColumnDescriptor
 	ShipmentId() ColumnDescriptor
 	LineNumber() ColumnDescriptor
@@ -631,6 +698,26 @@ func (d *lineItemsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *lineItemsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.shipperId.columnType,
+		d.shipmentId.columnType,
+		d.lineNumber.columnType,
+		d.title.columnType,
+		d.quantity.columnType,
+		d.weightKg.columnType,
+		d.volumeM3.columnType,
+	}
+}
+
+func (d *lineItemsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "shipper_id", Desc: false},
+		{Column: "shipment_id", Desc: false},
+		{Column: "line_number", Desc: false},
+	}
+}
+
 func (d *lineItemsTableDescriptor) ShipperId() ColumnDescriptor {
 	return &d.shipperId
 }
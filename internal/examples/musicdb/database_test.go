@@ -2,11 +2,18 @@ package musicdb_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
 	"cloud.google.com/go/spanner"
 	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/ordering"
 	"go.einride.tech/spanner-aip/internal/examples/musicdb"
+	"go.einride.tech/spanner-aip/internal/migration"
+	"go.einride.tech/spanner-aip/spanddl"
+	"go.einride.tech/spanner-aip/spanfiltering"
+	"go.einride.tech/spanner-aip/spanordering"
 	"go.einride.tech/spanner-aip/spantest"
 	"gotest.tools/v3/assert"
 )
@@ -110,6 +117,117 @@ func TestReadTransaction(t *testing.T) {
 		assert.DeepEqual(t, expected, actual)
 	})
 
+	t.Run("list with interleaved table filter", func(t *testing.T) {
+		t.Parallel()
+		client := fx.NewDatabaseFromDDLFiles(t, "../../../testdata/migrations/music/*.up.sql")
+		filenames, err := filepath.Glob("../../../testdata/migrations/music/*.up.sql")
+		assert.NilError(t, err)
+		migrations, err := migration.FromFiles(filenames)
+		assert.NilError(t, err)
+		var db spanddl.Database
+		assert.NilError(t, migration.ApplyUp(&db, migrations))
+		_, err = client.Apply(ctx, []*spanner.Mutation{
+			spanner.Insert((&musicdb.SingersRow{SingerId: 1}).Mutate()),
+			spanner.Insert((&musicdb.SingersRow{SingerId: 2}).Mutate()),
+			spanner.Insert((&musicdb.AlbumsRow{
+				SingerId:   1,
+				AlbumId:    1,
+				AlbumTitle: spanner.NullString{StringVal: "Songs for Swingin' Lovers!", Valid: true},
+			}).Mutate()),
+			spanner.Insert((&musicdb.AlbumsRow{
+				SingerId:   2,
+				AlbumId:    1,
+				AlbumTitle: spanner.NullString{StringVal: "Ella Swings Lightly", Valid: true},
+			}).Mutate()),
+		})
+		assert.NilError(t, err)
+		albumType := filtering.TypeMap(filtering.TypeString, filtering.TypeString)
+		declarations, err := filtering.NewDeclarations(
+			filtering.DeclareStandardFunctions(),
+			filtering.DeclareIdent("albums", filtering.TypeList(albumType)),
+			filtering.DeclareIdent("albums.AlbumTitle", filtering.TypeString),
+		)
+		assert.NilError(t, err)
+		parsedFilter, err := filtering.ParseFilter(filterRequest(`albums.AlbumTitle = "*Swingin*"`), declarations)
+		assert.NilError(t, err)
+		where, params, err := spanfiltering.TranspileFilter(
+			parsedFilter,
+			spanfiltering.WithInterleavedTable("albums", &db, "Singers", "Albums"),
+		)
+		assert.NilError(t, err)
+		var actual []int64
+		tx := client.Single()
+		defer tx.Close()
+		assert.NilError(t, musicdb.Query(tx).ListSingersRows(ctx, musicdb.ListSingersRowsQuery{
+			Where:  where,
+			Params: params,
+			Limit:  10,
+		}).Do(func(row *musicdb.SingersRow) error {
+			actual = append(actual, row.SingerId)
+			return nil
+		}))
+		assert.DeepEqual(t, []int64{1}, actual)
+	})
+
+	t.Run("list with collation and nulls order", func(t *testing.T) {
+		t.Parallel()
+		client := fx.NewDatabaseFromDDLFiles(t, "../../../testdata/migrations/music/*.up.sql")
+		_, err := client.Apply(ctx, []*spanner.Mutation{
+			spanner.Insert((&musicdb.SingersRow{SingerId: 1}).Mutate()),
+			spanner.Insert((&musicdb.SingersRow{
+				SingerId:  2,
+				FirstName: spanner.NullString{StringVal: "adele", Valid: true},
+			}).Mutate()),
+			spanner.Insert((&musicdb.SingersRow{
+				SingerId:  3,
+				FirstName: spanner.NullString{StringVal: "Ella", Valid: true},
+			}).Mutate()),
+			spanner.Insert((&musicdb.SingersRow{
+				SingerId:  4,
+				FirstName: spanner.NullString{StringVal: "Bing", Valid: true},
+			}).Mutate()),
+		})
+		assert.NilError(t, err)
+		for _, tt := range []struct {
+			name     string
+			field    ordering.Field
+			nulls    spanordering.NullsOrder
+			expected []int64
+		}{
+			{
+				name:     "ascending with nulls last",
+				field:    ordering.Field{Path: "FirstName"},
+				nulls:    spanordering.NullsLast,
+				expected: []int64{2, 4, 3, 1},
+			},
+			{
+				name:     "descending with nulls first",
+				field:    ordering.Field{Path: "FirstName", Desc: true},
+				nulls:    spanordering.NullsFirst,
+				expected: []int64{1, 3, 4, 2},
+			},
+		} {
+			t.Run(tt.name, func(t *testing.T) {
+				order := spanordering.TranspileOrderBy(
+					ordering.OrderBy{Fields: []ordering.Field{tt.field}},
+					spanordering.WithCollation("FirstName", "und:ci"),
+					spanordering.WithNullsOrder("FirstName", tt.nulls),
+				)
+				var actual []int64
+				tx := client.Single()
+				defer tx.Close()
+				assert.NilError(t, musicdb.Query(tx).ListSingersRows(ctx, musicdb.ListSingersRowsQuery{
+					Order: order,
+					Limit: 10,
+				}).Do(func(row *musicdb.SingersRow) error {
+					actual = append(actual, row.SingerId)
+					return nil
+				}))
+				assert.DeepEqual(t, tt.expected, actual)
+			})
+		}
+	})
+
 	t.Run("interleaved", func(t *testing.T) {
 		t.Run("insert and get", func(t *testing.T) {
 			t.Parallel()
@@ -228,3 +346,9 @@ func TestReadTransaction(t *testing.T) {
 		})
 	})
 }
+
+type filterRequest string
+
+func (r filterRequest) GetFilter() string {
+	return string(r)
+}
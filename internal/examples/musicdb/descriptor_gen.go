@@ -159,6 +159,8 @@ type LabelsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	LabelId() ColumnDescriptor
 	LabelName() ColumnDescriptor
 }
@@ -198,6 +200,19 @@ func (d *labelsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *labelsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.labelId.columnType,
+		d.labelName.columnType,
+	}
+}
+
+func (d *labelsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "LabelId", Desc: false},
+	}
+}
+
 func (d *labelsTableDescriptor) LabelId() ColumnDescriptor {
 	return &d.labelId
 }
@@ -212,6 +227,8 @@ type SingersTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	LabelId() ColumnDescriptor
 	FirstName() ColumnDescriptor
@@ -266,6 +283,22 @@ func (d *singersTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *singersTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.labelId.columnType,
+		d.firstName.columnType,
+		d.lastName.columnType,
+		d.singerInfo.columnType,
+	}
+}
+
+func (d *singersTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+	}
+}
+
 func (d *singersTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -292,6 +325,8 @@ type AlbumsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	AlbumId() ColumnDescriptor
 	AlbumTitle() ColumnDescriptor
@@ -336,6 +371,21 @@ func (d *albumsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *albumsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.albumId.columnType,
+		d.albumTitle.columnType,
+	}
+}
+
+func (d *albumsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+		{Column: "AlbumId", Desc: false},
+	}
+}
+
 func (d *albumsTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -354,6 +404,8 @@ type SongsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	SingerId() ColumnDescriptor
 	AlbumId() ColumnDescriptor
 	TrackId() ColumnDescriptor
@@ -403,6 +455,23 @@ func (d *songsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *songsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.singerId.columnType,
+		d.albumId.columnType,
+		d.trackId.columnType,
+		d.songName.columnType,
+	}
+}
+
+func (d *songsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "SingerId", Desc: false},
+		{Column: "AlbumId", Desc: false},
+		{Column: "TrackId", Desc: false},
+	}
+}
+
 func (d *songsTableDescriptor) SingerId() ColumnDescriptor {
 	return &d.singerId
 }
@@ -425,6 +494,8 @@ type PlaylistsTableDescriptor interface {
 	ColumnNames() []string
 	ColumnIDs() []spansql.ID
 	ColumnExprs() []spansql.Expr
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
 	Id() ColumnDescriptor
 }
 
@@ -459,6 +530,18 @@ func (d *playlistsTableDescriptor) ColumnExprs() []spansql.Expr {
 	}
 }
 
+func (d *playlistsTableDescriptor) ColumnTypes() []spansql.Type {
+	return []spansql.Type{
+		d.id.columnType,
+	}
+}
+
+func (d *playlistsTableDescriptor) PrimaryKey() []spansql.KeyPart {
+	return []spansql.KeyPart{
+		{Column: "Id", Desc: false},
+	}
+}
+
 func (d *playlistsTableDescriptor) Id() ColumnDescriptor {
 	return &d.id
 }
@@ -0,0 +1,121 @@
+package musicdb_test
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"go.einride.tech/aip/filtering"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"go.einride.tech/spanner-aip/internal/examples/musicdb"
+	"go.einride.tech/spanner-aip/spanfiltering"
+	"go.einride.tech/spanner-aip/spantest"
+	"gotest.tools/v3/assert"
+)
+
+// TestListSingersRows_predicate lists singers with filters transpiled to SQL, and checks that the listed singers are
+// the ones matched by the in-memory predicates of the same filters.
+func TestListSingersRows_predicate(t *testing.T) {
+	t.Parallel()
+	fx := spantest.NewEmulatorFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	client := fx.NewDatabaseFromDDLFiles(t, "../../../testdata/migrations/music/*.up.sql")
+	labels := []*musicdb.LabelsRow{
+		{LabelId: int64(syntaxv1.Enum_ENUM_ONE)},
+		{LabelId: int64(syntaxv1.Enum_ENUM_TWO)},
+	}
+	singers := []*musicdb.SingersRow{
+		{SingerId: 1, FirstName: spanner.NullString{StringVal: "Frank", Valid: true}},
+		{
+			SingerId:  2,
+			LabelId:   spanner.NullInt64{Int64: int64(syntaxv1.Enum_ENUM_ONE), Valid: true},
+			FirstName: spanner.NullString{StringVal: "Frank", Valid: true},
+			LastName:  spanner.NullString{StringVal: "Sinatra", Valid: true},
+		},
+		{
+			SingerId:  3,
+			LabelId:   spanner.NullInt64{Int64: int64(syntaxv1.Enum_ENUM_TWO), Valid: true},
+			FirstName: spanner.NullString{StringVal: "Frank", Valid: true},
+			LastName:  spanner.NullString{StringVal: "Zappa", Valid: true},
+		},
+		{
+			SingerId: 4,
+			LabelId:  spanner.NullInt64{Int64: int64(syntaxv1.Enum_ENUM_ONE), Valid: true},
+			LastName: spanner.NullString{StringVal: "50%_off", Valid: true},
+		},
+		{SingerId: 5, FirstName: spanner.NullString{StringVal: "", Valid: true}},
+	}
+	mutations := make([]*spanner.Mutation, 0, len(labels)+len(singers))
+	for _, label := range labels {
+		mutations = append(mutations, spanner.Insert(label.Mutate()))
+	}
+	for _, singer := range singers {
+		mutations = append(mutations, spanner.Insert(singer.Mutate()))
+	}
+	_, err := client.Apply(ctx, mutations)
+	assert.NilError(t, err)
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("SingerId", filtering.TypeInt),
+		filtering.DeclareEnumIdent("LabelId", syntaxv1.Enum(0).Type()),
+		filtering.DeclareIdent("FirstName", filtering.TypeString),
+		filtering.DeclareIdent("LastName", filtering.TypeString),
+	)
+	assert.NilError(t, err)
+	for _, filter := range []string{
+		// NULLs.
+		`FirstName = "Frank"`,
+		`LastName != "Sinatra"`,
+		`NOT LastName = "Sinatra"`,
+		`LastName = "Sinatra" OR SingerId > 3`,
+		`NOT (LastName = "Sinatra" OR FirstName = "Frank")`,
+		`LastName = "Zappa" AND FirstName != "Frank"`,
+		`FirstName = ""`,
+		`LastName:*`,
+		`NOT LastName:*`,
+		// Wildcards.
+		`LastName = "50%_*"`,
+		`LastName = "50*"`,
+		`LastName = "*pa"`,
+		`LastName = "*nat*"`,
+		`FirstName = "*"`,
+		`LastName != "Zap*"`,
+		`NOT FirstName = "Fr*"`,
+		// Enums.
+		`LabelId = ENUM_ONE`,
+		`LabelId != ENUM_ONE`,
+		`LabelId = ENUM_ONE OR LabelId = ENUM_TWO`,
+		`NOT LabelId = ENUM_TWO`,
+		`SingerId = 1 OR SingerId = 3`,
+	} {
+		t.Run(filter, func(t *testing.T) {
+			t.Parallel()
+			parsedFilter, err := filtering.ParseFilter(filterRequest(filter), declarations)
+			assert.NilError(t, err)
+			where, params, err := spanfiltering.TranspileFilter(parsedFilter)
+			assert.NilError(t, err)
+			predicate, err := spanfiltering.CompilePredicate(parsedFilter)
+			assert.NilError(t, err)
+			match := spanfiltering.RowPredicate[*musicdb.SingersRow](predicate)
+			var expected []int64
+			for _, singer := range singers {
+				if match(singer) {
+					expected = append(expected, singer.SingerId)
+				}
+			}
+			var actual []int64
+			tx := client.Single()
+			defer tx.Close()
+			assert.NilError(t, musicdb.Query(tx).ListSingersRows(ctx, musicdb.ListSingersRowsQuery{
+				Where:  where,
+				Params: params,
+				Limit:  int32(len(singers)),
+			}).Do(func(row *musicdb.SingersRow) error {
+				actual = append(actual, row.SingerId)
+				return nil
+			}))
+			assert.DeepEqual(t, expected, actual)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+// Package migration provides primitives for versioned golang-migrate style schema migration files.
+package migration
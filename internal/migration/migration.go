@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanddl"
+)
+
+// Direction is the direction of a migration file.
+type Direction string
+
+const (
+	// Up migrations migrate the schema to a newer version.
+	Up Direction = "up"
+	// Down migrations revert the corresponding up migration.
+	Down Direction = "down"
+)
+
+var filenameRegexp = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+
+// ParseFilename parses a golang-migrate style migration filename, such as 000001_create_singers.up.sql.
+func ParseFilename(filename string) (version uint64, name string, direction Direction, ok bool) {
+	match := filenameRegexp.FindStringSubmatch(filepath.Base(filename))
+	if match == nil {
+		return 0, "", "", false
+	}
+	version, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, "", "", false
+	}
+	return version, match[2], Direction(match[3]), true
+}
+
+// Migration is a versioned schema migration.
+type Migration struct {
+	// Version of the migration.
+	Version uint64
+	// Name of the migration.
+	Name string
+	// UpFile is the path to the up migration file.
+	UpFile string
+	// DownFile is the path to the down migration file. Empty if the migration has no down migration.
+	DownFile string
+}
+
+// ParseUp parses the DDL of the up migration.
+func (m *Migration) ParseUp() (*spansql.DDL, error) {
+	return parseFile(m.UpFile)
+}
+
+// ParseDown parses the DDL of the down migration.
+func (m *Migration) ParseDown() (*spansql.DDL, error) {
+	if m.DownFile == "" {
+		return nil, fmt.Errorf("migration %d has no down migration", m.Version)
+	}
+	return parseFile(m.DownFile)
+}
+
+func parseFile(filename string) (*spansql.DDL, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return spansql.ParseDDL(filename, string(content))
+}
+
+// FromFiles groups the provided migration files by version, in ascending version order.
+//
+// Down migration files are looked up next to up migration files, so a list of up migration files is enough.
+// Files that are not named as migrations, duplicate versions and down migrations without a corresponding up migration
+// result in an error.
+func FromFiles(filenames []string) ([]*Migration, error) {
+	byVersion := make(map[uint64]*Migration, len(filenames))
+	for _, filename := range filenames {
+		version, name, direction, ok := ParseFilename(filename)
+		if !ok {
+			return nil, fmt.Errorf("%s: not a migration file", filename)
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("%s: duplicate migration version %d", filename, version)
+		}
+		switch direction {
+		case Up:
+			if migration.UpFile != "" && migration.UpFile != filename {
+				return nil, fmt.Errorf("%s: duplicate migration version %d", filename, version)
+			}
+			migration.UpFile = filename
+			if migration.DownFile == "" {
+				downFile := strings.TrimSuffix(filename, ".up.sql") + ".down.sql"
+				if _, err := os.Stat(downFile); err == nil {
+					migration.DownFile = downFile
+				} else if !errors.Is(err, os.ErrNotExist) {
+					return nil, err
+				}
+			}
+		case Down:
+			if migration.DownFile != "" && migration.DownFile != filename {
+				return nil, fmt.Errorf("%s: duplicate migration version %d", filename, version)
+			}
+			migration.DownFile = filename
+		}
+	}
+	result := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.UpFile == "" {
+			return nil, fmt.Errorf("%s: no up migration for version %d", migration.DownFile, migration.Version)
+		}
+		result = append(result, migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+// CheckSequential checks that the migration versions are sequential, without any missing versions.
+func CheckSequential(migrations []*Migration) error {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version != migrations[i-1].Version+1 {
+			return fmt.Errorf(
+				"missing migration version %d between %d and %d",
+				migrations[i-1].Version+1,
+				migrations[i-1].Version,
+				migrations[i].Version,
+			)
+		}
+	}
+	return nil
+}
+
+// UpTo returns the migrations up to and including the provided version.
+func UpTo(migrations []*Migration, version uint64) ([]*Migration, error) {
+	for i, migration := range migrations {
+		if migration.Version == version {
+			return migrations[:i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown migration version %d", version)
+}
+
+// ApplyUp applies the up migrations to the database, in order.
+func ApplyUp(db *spanddl.Database, migrations []*Migration) error {
+	for _, migration := range migrations {
+		ddl, err := migration.ParseUp()
+		if err != nil {
+			return err
+		}
+		if err := db.ApplyDDL(ddl); err != nil {
+			return fmt.Errorf("migration %d up: %w", migration.Version, err)
+		}
+	}
+	return nil
+}
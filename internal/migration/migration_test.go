@@ -0,0 +1,176 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.einride.tech/spanner-aip/spanddl"
+	"gotest.tools/v3/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NilError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	return dir
+}
+
+func TestParseFilename(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		filename  string
+		version   uint64
+		name      string
+		direction Direction
+		ok        bool
+	}{
+		{filename: "000001_singers.up.sql", version: 1, name: "singers", direction: Up, ok: true},
+		{filename: "dir/10_albums_and_songs.down.sql", version: 10, name: "albums_and_songs", direction: Down, ok: true},
+		{filename: "schema.sql"},
+		{filename: "1_singers.sql"},
+		{filename: "singers.up.sql"},
+	} {
+		t.Run(tt.filename, func(t *testing.T) {
+			t.Parallel()
+			version, name, direction, ok := ParseFilename(tt.filename)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.version, version)
+			assert.Equal(t, tt.name, name)
+			assert.Equal(t, tt.direction, direction)
+		})
+	}
+}
+
+func TestFromFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("numeric order", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"9_singers.up.sql":   "",
+			"9_singers.down.sql": "",
+			"10_albums.up.sql":   "",
+		})
+		files, err := filepath.Glob(filepath.Join(dir, "*.up.sql"))
+		assert.NilError(t, err)
+		migrations, err := FromFiles(files)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []*Migration{
+			{
+				Version:  9,
+				Name:     "singers",
+				UpFile:   filepath.Join(dir, "9_singers.up.sql"),
+				DownFile: filepath.Join(dir, "9_singers.down.sql"),
+			},
+			{
+				Version: 10,
+				Name:    "albums",
+				UpFile:  filepath.Join(dir, "10_albums.up.sql"),
+			},
+		}, migrations)
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		t.Parallel()
+		_, err := FromFiles([]string{"1_singers.up.sql", "01_albums.up.sql"})
+		assert.ErrorContains(t, err, "01_albums.up.sql: duplicate migration version 1")
+	})
+
+	t.Run("down without up", func(t *testing.T) {
+		t.Parallel()
+		_, err := FromFiles([]string{"1_singers.up.sql", "2_albums.down.sql"})
+		assert.ErrorContains(t, err, "2_albums.down.sql: no up migration for version 2")
+	})
+
+	t.Run("not a migration", func(t *testing.T) {
+		t.Parallel()
+		_, err := FromFiles([]string{"1_singers.up.sql", "schema.sql"})
+		assert.ErrorContains(t, err, "schema.sql: not a migration file")
+	})
+}
+
+func TestCheckSequential(t *testing.T) {
+	t.Parallel()
+	assert.NilError(t, CheckSequential([]*Migration{{Version: 1}, {Version: 2}, {Version: 3}}))
+	assert.ErrorContains(
+		t,
+		CheckSequential([]*Migration{{Version: 1}, {Version: 2}, {Version: 5}}),
+		"missing migration version 3 between 2 and 5",
+	)
+}
+
+func TestUpTo(t *testing.T) {
+	t.Parallel()
+	migrations := []*Migration{{Version: 1}, {Version: 2}, {Version: 3}}
+	result, err := UpTo(migrations, 2)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, migrations[:2], result)
+	_, err = UpTo(migrations, 4)
+	assert.ErrorContains(t, err, "unknown migration version 4")
+}
+
+func TestCheckRoundTrip(t *testing.T) {
+	t.Parallel()
+	const singersUp = `CREATE TABLE Singers (SingerId INT64 NOT NULL) PRIMARY KEY (SingerId);`
+	const albumsUp = `
+	  CREATE TABLE Albums (
+	    SingerId INT64 NOT NULL,
+	    AlbumId  INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+	  ALTER TABLE Singers ADD COLUMN Name STRING(MAX);
+	  CREATE INDEX SingersByName ON Singers(Name);`
+
+	t.Run("ok", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"1_singers.up.sql":   singersUp,
+			"1_singers.down.sql": `DROP TABLE Singers;`,
+			"2_albums.up.sql":    albumsUp,
+			"2_albums.down.sql": `
+			  DROP INDEX SingersByName;
+			  ALTER TABLE Singers DROP COLUMN Name;
+			  DROP TABLE Albums;`,
+		})
+		migrations, err := FromFiles([]string{
+			filepath.Join(dir, "1_singers.up.sql"),
+			filepath.Join(dir, "2_albums.up.sql"),
+		})
+		assert.NilError(t, err)
+		assert.NilError(t, CheckRoundTrip(migrations))
+		var db spanddl.Database
+		assert.NilError(t, ApplyUp(&db, migrations))
+		assert.Equal(t, 2, len(db.Tables))
+	})
+
+	t.Run("incomplete down migration", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"1_singers.up.sql":   singersUp,
+			"1_singers.down.sql": `DROP TABLE Singers;`,
+			"2_albums.up.sql":    albumsUp,
+			"2_albums.down.sql": `
+			  DROP INDEX SingersByName;
+			  DROP TABLE Albums;`,
+		})
+		migrations, err := FromFiles([]string{
+			filepath.Join(dir, "1_singers.up.sql"),
+			filepath.Join(dir, "2_albums.up.sql"),
+		})
+		assert.NilError(t, err)
+		assert.ErrorContains(t, CheckRoundTrip(migrations), "migration 2 down does not revert up")
+	})
+
+	t.Run("missing down migration", func(t *testing.T) {
+		t.Parallel()
+		dir := writeFiles(t, map[string]string{
+			"1_singers.up.sql": singersUp,
+		})
+		migrations, err := FromFiles([]string{filepath.Join(dir, "1_singers.up.sql")})
+		assert.NilError(t, err)
+		assert.ErrorContains(t, CheckRoundTrip(migrations), "migration 1 has no down migration")
+	})
+}
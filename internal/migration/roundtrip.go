@@ -0,0 +1,57 @@
+package migration
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.einride.tech/spanner-aip/spanddl"
+)
+
+// CheckRoundTrip checks that every down migration reverts its up migration.
+//
+// All up migrations are applied in order, after which the down migrations are applied in reverse order. After each
+// down migration, the database must be equal to the database before the corresponding up migration. The order of
+// schema objects is not significant. Migrations without a down migration result in an error.
+func CheckRoundTrip(migrations []*Migration) error {
+	var db spanddl.Database
+	snapshots := make([]*spanddl.Database, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.DownFile == "" {
+			return fmt.Errorf("migration %d has no down migration", migration.Version)
+		}
+		snapshots = append(snapshots, db.Clone())
+		ddl, err := migration.ParseUp()
+		if err != nil {
+			return err
+		}
+		if err := db.ApplyDDL(ddl); err != nil {
+			return fmt.Errorf("migration %d up: %w", migration.Version, err)
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		ddl, err := migration.ParseDown()
+		if err != nil {
+			return err
+		}
+		if err := db.ApplyDDL(ddl); err != nil {
+			return fmt.Errorf("migration %d down: %w", migration.Version, err)
+		}
+		if diff := cmp.Diff(snapshots[i], &db, schemaComparison()...); diff != "" {
+			return fmt.Errorf("migration %d down does not revert up (-before +after):\n%s", migration.Version, diff)
+		}
+	}
+	return nil
+}
+
+func schemaComparison() []cmp.Option {
+	return []cmp.Option{
+		cmpopts.EquateEmpty(),
+		cmpopts.SortSlices(func(a, b *spanddl.Table) bool { return a.Name < b.Name }),
+		cmpopts.SortSlices(func(a, b *spanddl.Index) bool { return a.Name < b.Name }),
+		cmpopts.SortSlices(func(a, b *spanddl.SearchIndex) bool { return a.Name < b.Name }),
+		cmp.Comparer(func(a, b spansql.Order) bool { return a.SQL() == b.SQL() }),
+	}
+}
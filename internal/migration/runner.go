@@ -0,0 +1,493 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanddl"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// DefaultVersionTable is the default name of the table recording the migration version.
+	// The table is compatible with the version table of the golang-migrate Spanner driver.
+	DefaultVersionTable spansql.ID = "SchemaMigrations"
+	// DefaultLockTable is the default name of the table used to lock out concurrent runners.
+	DefaultLockTable spansql.ID = "SchemaMigrationsLock"
+	// DefaultLockTimeout is the default duration after which a lock held by another runner is considered abandoned.
+	// Runners refresh their locks while migrating, so only the locks of runners that stopped refreshing expire.
+	DefaultLockTimeout = time.Hour
+)
+
+// ErrDirty is returned when a previous migration failed and left the database in a dirty state.
+var ErrDirty = errors.New("database is dirty")
+
+// Runner applies migrations to a Spanner database and records the applied version in a version table.
+type Runner struct {
+	// Client for the target database.
+	Client *spanner.Client
+	// AdminClient for updating the DDL of the target database.
+	AdminClient *database.DatabaseAdminClient
+	// Migrations to run, in version order.
+	Migrations []*Migration
+	// VersionTable is the table recording the migration version. Defaults to DefaultVersionTable.
+	VersionTable spansql.ID
+	// LockTable is the table used to lock out concurrent runners. Defaults to DefaultLockTable.
+	LockTable spansql.ID
+	// LockTimeout is the duration after which a lock held by another runner is considered abandoned.
+	// The lock is refreshed every third of the timeout, and at most every second, while migrating.
+	// Defaults to DefaultLockTimeout.
+	LockTimeout time.Duration
+	// Owner identifies the runner in the lock table. Defaults to the hostname and process ID.
+	Owner string
+}
+
+// Status is the migration status of a database.
+type Status struct {
+	// Version is the current migration version. Zero when no migration has been applied.
+	Version uint64
+	// Dirty is true when a migration failed while being applied to the database.
+	Dirty bool
+}
+
+// Status returns the migration status of the database.
+func (r *Runner) Status(ctx context.Context) (_ *Status, err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("migration status: %w", err)
+		}
+	}()
+	exists, err := r.tableExists(ctx, r.versionTable())
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return &Status{}, nil
+	}
+	return r.readStatus(ctx, r.Client.Single())
+}
+
+// Up applies up to n pending migrations. All pending migrations are applied when n is not positive.
+func (r *Runner) Up(ctx context.Context, n int) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("migrate up: %w", err)
+		}
+	}()
+	return r.run(ctx, func(status *Status) ([]step, error) {
+		i, err := r.indexOfVersion(status.Version)
+		if err != nil {
+			return nil, err
+		}
+		var steps []step
+		for _, migration := range r.Migrations[i+1:] {
+			if n > 0 && len(steps) == n {
+				break
+			}
+			steps = append(steps, step{migration: migration, direction: Up, version: migration.Version})
+		}
+		return steps, nil
+	})
+}
+
+// Down reverts up to n applied migrations. All applied migrations are reverted when n is not positive.
+func (r *Runner) Down(ctx context.Context, n int) (err error) {
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("migrate down: %w", err)
+		}
+	}()
+	return r.run(ctx, func(status *Status) ([]step, error) {
+		i, err := r.indexOfVersion(status.Version)
+		if err != nil {
+			return nil, err
+		}
+		var steps []step
+		for ; i >= 0; i-- {
+			if n > 0 && len(steps) == n {
+				break
+			}
+			var version uint64
+			if i > 0 {
+				version = r.Migrations[i-1].Version
+			}
+			steps = append(steps, step{migration: r.Migrations[i], direction: Down, version: version})
+		}
+		return steps, nil
+	})
+}
+
+// step is a single migration to run, and the version of the database after the migration.
+type step struct {
+	migration *Migration
+	direction Direction
+	version   uint64
+}
+
+func (s step) parse() (*spansql.DDL, error) {
+	if s.direction == Down {
+		return s.migration.ParseDown()
+	}
+	return s.migration.ParseUp()
+}
+
+func (r *Runner) run(ctx context.Context, plan func(*Status) ([]step, error)) (err error) {
+	if err := r.ensureTables(ctx); err != nil {
+		return err
+	}
+	ctx, unlock, err := r.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// A lost lock cancels the migration, so the unlock error explains the cancellation.
+		if errUnlock := unlock(); errUnlock != nil {
+			err = errors.Join(err, errUnlock)
+		}
+	}()
+	status, err := r.readStatus(ctx, r.Client.Single())
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf(
+			"%w at version %d: fix the schema manually and clear the dirty flag in %s",
+			ErrDirty,
+			status.Version,
+			r.versionTable(),
+		)
+	}
+	steps, err := plan(status)
+	if err != nil {
+		return err
+	}
+	ddls, err := r.validate(status, steps)
+	if err != nil {
+		return err
+	}
+	for i, step := range steps {
+		if err := r.writeStatus(ctx, &Status{Version: step.migration.Version, Dirty: true}); err != nil {
+			return err
+		}
+		if err := r.updateDDL(ctx, ddls[i]); err != nil {
+			return fmt.Errorf("migration %d %s: %w", step.migration.Version, step.direction, err)
+		}
+		if err := r.writeStatus(ctx, &Status{Version: step.version}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate applies the planned migration steps to an in-memory model of the database at its current version.
+func (r *Runner) validate(status *Status, steps []step) ([]*spansql.DDL, error) {
+	i, err := r.indexOfVersion(status.Version)
+	if err != nil {
+		return nil, err
+	}
+	var db spanddl.Database
+	if err := ApplyUp(&db, r.Migrations[:i+1]); err != nil {
+		return nil, err
+	}
+	ddls := make([]*spansql.DDL, 0, len(steps))
+	for _, step := range steps {
+		ddl, err := step.parse()
+		if err != nil {
+			return nil, err
+		}
+		if err := db.ApplyDDL(ddl); err != nil {
+			return nil, fmt.Errorf("migration %d %s: %w", step.migration.Version, step.direction, err)
+		}
+		ddls = append(ddls, ddl)
+	}
+	return ddls, nil
+}
+
+// indexOfVersion returns the index of the migration with the provided version, or -1 for version zero.
+func (r *Runner) indexOfVersion(version uint64) (int, error) {
+	if version == 0 {
+		return -1, nil
+	}
+	for i, migration := range r.Migrations {
+		if migration.Version == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("database is at unknown migration version %d", version)
+}
+
+// ensureTables creates the version and lock tables, unless they exist.
+func (r *Runner) ensureTables(ctx context.Context) error {
+	var statements []string
+	versionTableExists, err := r.tableExists(ctx, r.versionTable())
+	if err != nil {
+		return err
+	}
+	if !versionTableExists {
+		statements = append(statements, (&spansql.CreateTable{
+			Name: r.versionTable(),
+			Columns: []spansql.ColumnDef{
+				{Name: "Version", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
+				{Name: "Dirty", Type: spansql.Type{Base: spansql.Bool}, NotNull: true},
+			},
+			PrimaryKey: []spansql.KeyPart{{Column: "Version"}},
+		}).SQL())
+	}
+	lockTableExists, err := r.tableExists(ctx, r.lockTable())
+	if err != nil {
+		return err
+	}
+	if !lockTableExists {
+		allowCommitTimestamp := true
+		statements = append(statements, (&spansql.CreateTable{
+			Name: r.lockTable(),
+			Columns: []spansql.ColumnDef{
+				{Name: "LockId", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
+				{Name: "Owner", Type: spansql.Type{Base: spansql.String, Len: spansql.MaxLen}, NotNull: true},
+				{
+					Name:    "LockTime",
+					Type:    spansql.Type{Base: spansql.Timestamp},
+					NotNull: true,
+					Options: spansql.ColumnOptions{AllowCommitTimestamp: &allowCommitTimestamp},
+				},
+			},
+			PrimaryKey: []spansql.KeyPart{{Column: "LockId"}},
+		}).SQL())
+	}
+	if len(statements) == 0 {
+		return nil
+	}
+	if err := r.updateStatements(ctx, statements); err != nil {
+		// A concurrent runner may have created the tables first, in which case the DDL update fails with a duplicate
+		// name error. The lock, which requires the tables, is what serializes the runners.
+		for _, table := range []spansql.ID{r.versionTable(), r.lockTable()} {
+			exists, errExists := r.tableExists(ctx, table)
+			if errExists != nil || !exists {
+				return fmt.Errorf("create migration tables: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Runner) tableExists(ctx context.Context, table spansql.ID) (bool, error) {
+	stmt := spanner.Statement{
+		SQL: `SELECT 1 FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = '' AND TABLE_NAME = @table`,
+		Params: map[string]interface{}{
+			"table": string(table),
+		},
+	}
+	var exists bool
+	if err := r.Client.Single().Query(ctx, stmt).Do(func(*spanner.Row) error {
+		exists = true
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// lock acquires the lock table row, failing if another runner holds an unexpired lock.
+//
+// The lock is refreshed in the background until it is released, so that it doesn't expire during long-running
+// migrations. The returned context is canceled when the lock can't be refreshed, e.g. because another runner took it
+// over, and the returned function then fails with the refresh error.
+func (r *Runner) lock(ctx context.Context) (context.Context, func() error, error) {
+	owner := r.owner()
+	columns := []string{"LockId", "Owner", "LockTime"}
+	if _, err := r.Client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+		row, err := tx.ReadRow(ctx, string(r.lockTable()), spanner.Key{int64(1)}, columns)
+		switch {
+		case spanner.ErrCode(err) == codes.NotFound:
+		case err != nil:
+			return err
+		default:
+			var lockID int64
+			var lockOwner string
+			var lockTime time.Time
+			if err := row.Columns(&lockID, &lockOwner, &lockTime); err != nil {
+				return err
+			}
+			if time.Since(lockTime) < r.lockTimeout() {
+				return fmt.Errorf("database is locked by %s since %s", lockOwner, lockTime.Format(time.RFC3339))
+			}
+		}
+		return tx.BufferWrite([]*spanner.Mutation{
+			spanner.InsertOrUpdate(string(r.lockTable()), columns, []interface{}{
+				int64(1), owner, spanner.CommitTimestamp,
+			}),
+		})
+	}); err != nil {
+		return nil, nil, fmt.Errorf("lock: %w", err)
+	}
+	lockCtx, cancel := context.WithCancel(ctx)
+	refreshed := make(chan error, 1)
+	go func() {
+		refreshed <- r.refreshLock(lockCtx, owner)
+		cancel()
+	}()
+	return lockCtx, func() error {
+		cancel()
+		if err := <-refreshed; err != nil {
+			return fmt.Errorf("lock: %w", err)
+		}
+		// Use a fresh context so that the lock is released even if the migration was canceled.
+		_, err := r.Client.ReadWriteTransaction(
+			context.WithoutCancel(ctx),
+			func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+				if err := r.checkLockOwner(ctx, tx, owner); err != nil {
+					return err
+				}
+				return tx.BufferWrite([]*spanner.Mutation{
+					spanner.Delete(string(r.lockTable()), spanner.Key{int64(1)}),
+				})
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("unlock: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// refreshLock updates the lock time of the lock held by the owner periodically, until the context is canceled.
+// It returns nil when the context is canceled, and the error of the first failed refresh otherwise.
+func (r *Runner) refreshLock(ctx context.Context, owner string) error {
+	ticker := time.NewTicker(r.lockRefreshInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if _, err := r.Client.ReadWriteTransaction(ctx, func(ctx context.Context, tx *spanner.ReadWriteTransaction) error {
+			if err := r.checkLockOwner(ctx, tx, owner); err != nil {
+				return err
+			}
+			return tx.BufferWrite([]*spanner.Mutation{
+				spanner.Update(string(r.lockTable()), []string{"LockId", "LockTime"}, []interface{}{
+					int64(1), spanner.CommitTimestamp,
+				}),
+			})
+		}); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("refresh: %w", err)
+		}
+	}
+}
+
+// checkLockOwner checks that the lock is held by the owner.
+func (r *Runner) checkLockOwner(ctx context.Context, tx *spanner.ReadWriteTransaction, owner string) error {
+	row, err := tx.ReadRow(ctx, string(r.lockTable()), spanner.Key{int64(1)}, []string{"Owner"})
+	if err != nil {
+		return err
+	}
+	var lockOwner string
+	if err := row.Columns(&lockOwner); err != nil {
+		return err
+	}
+	if lockOwner != owner {
+		return fmt.Errorf("lock was taken over by %s", lockOwner)
+	}
+	return nil
+}
+
+func (r *Runner) readStatus(ctx context.Context, tx *spanner.ReadOnlyTransaction) (*Status, error) {
+	defer tx.Close()
+	var status Status
+	if err := tx.Read(
+		ctx, string(r.versionTable()), spanner.AllKeys(), []string{"Version", "Dirty"},
+	).Do(func(row *spanner.Row) error {
+		var version int64
+		if err := row.Columns(&version, &status.Dirty); err != nil {
+			return err
+		}
+		status.Version = uint64(version)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (r *Runner) writeStatus(ctx context.Context, status *Status) error {
+	mutations := []*spanner.Mutation{
+		spanner.Delete(string(r.versionTable()), spanner.AllKeys()),
+	}
+	if status.Version != 0 {
+		mutations = append(mutations, spanner.Insert(
+			string(r.versionTable()),
+			[]string{"Version", "Dirty"},
+			[]interface{}{int64(status.Version), status.Dirty},
+		))
+	}
+	if _, err := r.Client.Apply(ctx, mutations); err != nil {
+		return fmt.Errorf("write version %d: %w", status.Version, err)
+	}
+	return nil
+}
+
+func (r *Runner) updateDDL(ctx context.Context, ddl *spansql.DDL) error {
+	statements := make([]string, 0, len(ddl.List))
+	for _, stmt := range ddl.List {
+		statements = append(statements, stmt.SQL())
+	}
+	return r.updateStatements(ctx, statements)
+}
+
+func (r *Runner) updateStatements(ctx context.Context, statements []string) error {
+	op, err := r.AdminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   r.Client.DatabaseName(),
+		Statements: statements,
+	})
+	if err != nil {
+		return err
+	}
+	return op.Wait(ctx)
+}
+
+func (r *Runner) versionTable() spansql.ID {
+	if r.VersionTable == "" {
+		return DefaultVersionTable
+	}
+	return r.VersionTable
+}
+
+func (r *Runner) lockTable() spansql.ID {
+	if r.LockTable == "" {
+		return DefaultLockTable
+	}
+	return r.LockTable
+}
+
+func (r *Runner) lockTimeout() time.Duration {
+	if r.LockTimeout == 0 {
+		return DefaultLockTimeout
+	}
+	return r.LockTimeout
+}
+
+func (r *Runner) lockRefreshInterval() time.Duration {
+	return max(r.lockTimeout()/3, time.Second)
+}
+
+func (r *Runner) owner() string {
+	if r.Owner != "" {
+		return r.Owner
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
@@ -0,0 +1,165 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"go.einride.tech/spanner-aip/spantest"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gotest.tools/v3/assert"
+)
+
+func TestRunner(t *testing.T) {
+	t.Parallel()
+	fx := spantest.NewEmulatorFixture(t).(*spantest.EmulatorFixture)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dir := writeFiles(t, map[string]string{
+		"1_singers.up.sql":   `CREATE TABLE Singers (SingerId INT64 NOT NULL) PRIMARY KEY (SingerId);`,
+		"1_singers.down.sql": `DROP TABLE Singers;`,
+		"2_albums.up.sql": `
+		  CREATE TABLE Albums (
+		    SingerId INT64 NOT NULL,
+		    AlbumId  INT64 NOT NULL,
+		  ) PRIMARY KEY (SingerId, AlbumId),
+		    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;`,
+		"2_albums.down.sql": `DROP TABLE Albums;`,
+		"3_invalid.up.sql":  `ALTER TABLE Songs ADD COLUMN Name STRING(MAX);`,
+	})
+	migrations, err := FromFiles([]string{
+		filepath.Join(dir, "1_singers.up.sql"),
+		filepath.Join(dir, "2_albums.up.sql"),
+		filepath.Join(dir, "3_invalid.up.sql"),
+	})
+	assert.NilError(t, err)
+	newRunner := func(t *testing.T) *Runner {
+		t.Helper()
+		client := fx.NewDatabaseFromStatements(t, []string{})
+		conn, err := grpc.NewClient(fx.EmulatorHost(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		assert.NilError(t, err)
+		t.Cleanup(func() {
+			assert.NilError(t, conn.Close())
+		})
+		adminClient, err := database.NewDatabaseAdminClient(ctx, option.WithGRPCConn(conn))
+		assert.NilError(t, err)
+		return &Runner{
+			Client:      client,
+			AdminClient: adminClient,
+			Migrations:  migrations[:2],
+			Owner:       t.Name(),
+		}
+	}
+
+	t.Run("up and down", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		status, err := runner.Status(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &Status{}, status)
+		assert.NilError(t, runner.Up(ctx, 1))
+		status, err = runner.Status(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &Status{Version: 1}, status)
+		assert.NilError(t, runner.Up(ctx, 0))
+		status, err = runner.Status(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &Status{Version: 2}, status)
+		_, err = runner.Client.Apply(ctx, []*spanner.Mutation{
+			spanner.Insert("Singers", []string{"SingerId"}, []interface{}{int64(1)}),
+			spanner.Insert("Albums", []string{"SingerId", "AlbumId"}, []interface{}{int64(1), int64(1)}),
+		})
+		assert.NilError(t, err)
+		assert.NilError(t, runner.Down(ctx, 0))
+		status, err = runner.Status(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &Status{}, status)
+	})
+
+	t.Run("invalid migration", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		runner.Migrations = migrations
+		assert.ErrorContains(t, runner.Up(ctx, 0), "migration 3 up")
+		status, err := runner.Status(ctx)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, &Status{}, status)
+	})
+
+	t.Run("dirty", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		assert.NilError(t, runner.Up(ctx, 1))
+		assert.NilError(t, runner.writeStatus(ctx, &Status{Version: 1, Dirty: true}))
+		assert.ErrorIs(t, runner.Up(ctx, 0), ErrDirty)
+	})
+
+	t.Run("locked", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		assert.NilError(t, runner.Up(ctx, 1))
+		_, err := runner.Client.Apply(ctx, []*spanner.Mutation{
+			spanner.Insert(
+				string(DefaultLockTable),
+				[]string{"LockId", "Owner", "LockTime"},
+				[]interface{}{int64(1), "other", spanner.CommitTimestamp},
+			),
+		})
+		assert.NilError(t, err)
+		assert.ErrorContains(t, runner.Up(ctx, 0), "database is locked by other")
+		runner.LockTimeout = time.Nanosecond
+		assert.NilError(t, runner.Up(ctx, 0))
+	})
+	t.Run("lock refresh", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		runner.LockTimeout = 3 * time.Second
+		assert.NilError(t, runner.ensureTables(ctx))
+		lockCtx, unlock, err := runner.lock(ctx)
+		assert.NilError(t, err)
+		// The lock is refreshed every second, so it doesn't expire while it's held.
+		time.Sleep(2 * runner.LockTimeout)
+		other := *runner
+		other.Owner = "other"
+		_, _, err = other.lock(ctx)
+		assert.ErrorContains(t, err, "database is locked by "+runner.Owner)
+		assert.NilError(t, lockCtx.Err())
+		assert.NilError(t, unlock())
+	})
+
+	t.Run("lock taken over", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		runner.LockTimeout = 3 * time.Second
+		assert.NilError(t, runner.ensureTables(ctx))
+		lockCtx, unlock, err := runner.lock(ctx)
+		assert.NilError(t, err)
+		_, err = runner.Client.Apply(ctx, []*spanner.Mutation{
+			spanner.Update(string(DefaultLockTable), []string{"LockId", "Owner"}, []interface{}{int64(1), "other"}),
+		})
+		assert.NilError(t, err)
+		<-lockCtx.Done()
+		assert.ErrorContains(t, unlock(), "lock was taken over by other")
+	})
+
+	t.Run("concurrent table creation", func(t *testing.T) {
+		t.Parallel()
+		runner := newRunner(t)
+		other := *runner
+		other.Owner = "other"
+		errs := make(chan error, 2)
+		for _, r := range []*Runner{runner, &other} {
+			go func() {
+				errs <- r.ensureTables(ctx)
+			}()
+		}
+		assert.NilError(t, <-errs)
+		assert.NilError(t, <-errs)
+		assert.NilError(t, runner.Up(ctx, 0))
+	})
+}
@@ -0,0 +1,3 @@
+// Package postgresql provides primitives for printing spansql expressions as PostgreSQL-dialect SQL, for databases
+// using the Spanner PostgreSQL interface.
+package postgresql
@@ -0,0 +1,518 @@
+package postgresql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+)
+
+// Printer prints spansql expressions as PostgreSQL-dialect SQL.
+//
+// Named parameters are printed as positional placeholders $1, $2, ..., in order of first use. Parameters that are not
+// bound by the printer, for example in orderings, must already be named p1, p2, ..., the names Spanner uses for
+// positional placeholders.
+type Printer struct {
+	params    map[string]interface{}
+	positions map[string]int
+	args      map[string]interface{}
+}
+
+// NewPrinter creates a new printer for expressions with the provided named parameters.
+func NewPrinter(params map[string]interface{}) *Printer {
+	return &Printer{params: params}
+}
+
+// Params returns the positional parameters of the printed expressions, keyed p1, p2, ...
+// The parameter map is nil if no printed expression contains any parameters.
+func (p *Printer) Params() map[string]interface{} {
+	return p.args
+}
+
+// Orders prints an ORDER BY list, without the ORDER BY keywords.
+func (p *Printer) Orders(orders []spansql.Order) (string, error) {
+	result := make([]string, 0, len(orders))
+	for _, order := range orders {
+		expr, err := p.Expr(order.Expr)
+		if err != nil {
+			return "", err
+		}
+		if order.Desc {
+			expr += " DESC"
+		}
+		result = append(result, expr)
+	}
+	return strings.Join(result, ", "), nil
+}
+
+// Expr prints an expression.
+func (p *Printer) Expr(e spansql.Expr) (string, error) {
+	switch e := e.(type) {
+	case spansql.Paren:
+		inner, err := p.Expr(e.Expr)
+		if err != nil {
+			return "", err
+		}
+		return "(" + inner + ")", nil
+	case spansql.ID:
+		return Ident(string(e)), nil
+	case spansql.PathExp:
+		parts := make([]string, 0, len(e))
+		for _, part := range e {
+			parts = append(parts, Ident(string(part)))
+		}
+		return strings.Join(parts, "."), nil
+	case spansql.Param:
+		return p.param(string(e))
+	case spansql.BoolLiteral:
+		if e {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case spansql.NullLiteral:
+		return "NULL", nil
+	case spansql.IntegerLiteral:
+		return strconv.FormatInt(int64(e), 10), nil
+	case spansql.FloatLiteral:
+		return strconv.FormatFloat(float64(e), 'g', -1, 64), nil
+	case spansql.StringLiteral:
+		return stringLiteral(string(e)), nil
+	case spansql.LogicalOp:
+		return p.logicalOp(e)
+	case spansql.ComparisonOp:
+		return p.comparisonOp(e)
+	case spansql.IsOp:
+		return p.isOp(e)
+	case spansql.InOp:
+		return p.inOp(e)
+	case spansql.Func:
+		return p.function(e)
+	case spansql.ExistsOp:
+		return p.exists(e)
+	default:
+		return "", fmt.Errorf("unsupported expression in PostgreSQL dialect: %s", e.SQL())
+	}
+}
+
+func (p *Printer) logicalOp(e spansql.LogicalOp) (string, error) {
+	rhs, err := p.Expr(e.RHS)
+	if err != nil {
+		return "", err
+	}
+	switch e.Op {
+	case spansql.Not:
+		return "NOT " + rhs, nil
+	case spansql.And, spansql.Or:
+		lhs, err := p.Expr(e.LHS)
+		if err != nil {
+			return "", err
+		}
+		op := "AND"
+		if e.Op == spansql.Or {
+			op = "OR"
+		}
+		return lhs + " " + op + " " + rhs, nil
+	default:
+		return "", fmt.Errorf("unsupported logical operator in PostgreSQL dialect: %s", e.SQL())
+	}
+}
+
+func (p *Printer) comparisonOp(e spansql.ComparisonOp) (string, error) {
+	if ilike, ok, err := p.ilike(e); ok || err != nil {
+		return ilike, err
+	}
+	var op string
+	switch e.Op {
+	case spansql.Lt:
+		op = "<"
+	case spansql.Le:
+		op = "<="
+	case spansql.Gt:
+		op = ">"
+	case spansql.Ge:
+		op = ">="
+	case spansql.Eq:
+		op = "="
+	case spansql.Ne:
+		op = "<>"
+	case spansql.Like:
+		op = "LIKE"
+	case spansql.NotLike:
+		op = "NOT LIKE"
+	default:
+		return "", fmt.Errorf("unsupported comparison in PostgreSQL dialect: %s", e.SQL())
+	}
+	lhs, err := p.Expr(e.LHS)
+	if err != nil {
+		return "", err
+	}
+	rhs, err := p.Expr(e.RHS)
+	if err != nil {
+		return "", err
+	}
+	return lhs + " " + op + " " + rhs, nil
+}
+
+// ilike prints case-insensitive equality with a parameter, LOWER(x) = LOWER(@p), as x ILIKE $n.
+func (p *Printer) ilike(e spansql.ComparisonOp) (string, bool, error) {
+	lhs, ok := lowerArg(e.LHS)
+	if !ok || e.Op != spansql.Eq {
+		return "", false, nil
+	}
+	rhs, ok := lowerArg(e.RHS)
+	if !ok {
+		return "", false, nil
+	}
+	param, ok := rhs.(spansql.Param)
+	if !ok {
+		return "", false, nil
+	}
+	value, ok := p.params[string(param)].(string)
+	if !ok {
+		return "", false, nil
+	}
+	lhsSQL, err := p.Expr(lhs)
+	if err != nil {
+		return "", false, err
+	}
+	return lhsSQL + " ILIKE " + p.arg(string(param)+"#ilike", likeEscaper.Replace(value)), true, nil
+}
+
+func lowerArg(e spansql.Expr) (spansql.Expr, bool) {
+	if paren, ok := e.(spansql.Paren); ok {
+		e = paren.Expr
+	}
+	if f, ok := e.(spansql.Func); ok && f.Name == "LOWER" && len(f.Args) == 1 {
+		return f.Args[0], true
+	}
+	return nil, false
+}
+
+func (p *Printer) isOp(e spansql.IsOp) (string, error) {
+	lhs, err := p.Expr(e.LHS)
+	if err != nil {
+		return "", err
+	}
+	rhs, err := p.Expr(e.RHS)
+	if err != nil {
+		return "", err
+	}
+	if e.Neg {
+		return lhs + " IS NOT " + rhs, nil
+	}
+	return lhs + " IS " + rhs, nil
+}
+
+func (p *Printer) inOp(e spansql.InOp) (string, error) {
+	lhs, err := p.Expr(e.LHS)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0, len(e.RHS))
+	for _, rhs := range e.RHS {
+		value, err := p.Expr(rhs)
+		if err != nil {
+			return "", err
+		}
+		values = append(values, value)
+	}
+	if e.Unnest {
+		if len(values) != 1 {
+			return "", fmt.Errorf("unsupported IN UNNEST expression in PostgreSQL dialect: %s", e.SQL())
+		}
+		if e.Neg {
+			return "NOT " + lhs + " = ANY(" + values[0] + ")", nil
+		}
+		return lhs + " = ANY(" + values[0] + ")", nil
+	}
+	if e.Neg {
+		return lhs + " NOT IN (" + strings.Join(values, ", ") + ")", nil
+	}
+	return lhs + " IN (" + strings.Join(values, ", ") + ")", nil
+}
+
+// functions maps GoogleSQL functions to their PostgreSQL-dialect equivalents with the same arguments.
+var functions = map[string]string{
+	"STARTS_WITH":      "starts_with",
+	"STRPOS":           "strpos",
+	"LOWER":            "lower",
+	"COALESCE":         "coalesce",
+	"SEARCH":           "spanner.search",
+	"SEARCH_NGRAMS":    "spanner.search_ngrams",
+	"SEARCH_SUBSTRING": "spanner.search_substring",
+	"SCORE":            "spanner.score",
+	"SCORE_NGRAMS":     "spanner.score_ngrams",
+}
+
+func (p *Printer) function(e spansql.Func) (string, error) {
+	switch e.Name {
+	case "ENDS_WITH":
+		return p.endsWith(e)
+	case "REGEXP_CONTAINS":
+		args, err := p.args2(e)
+		if err != nil {
+			return "", err
+		}
+		return args[0] + " ~ " + args[1], nil
+	case "TIMESTAMP_ADD", "TIMESTAMP_SUB":
+		return p.timestampArithmetic(e)
+	case "JSON_VALUE":
+		return p.jsonValue(e)
+	case "CAST":
+		return p.cast(e)
+	case "COLLATE":
+		return "", fmt.Errorf("unsupported collation in PostgreSQL dialect: %s", e.SQL())
+	case "ARRAY_LENGTH":
+		if len(e.Args) != 1 {
+			return "", fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+		}
+		array, err := p.Expr(e.Args[0])
+		if err != nil {
+			return "", err
+		}
+		return "array_length(" + array + ", 1)", nil
+	}
+	name, ok := functions[e.Name]
+	if !ok {
+		return "", fmt.Errorf("unsupported function in PostgreSQL dialect: %s", e.Name)
+	}
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		if definition, ok := arg.(spansql.DefinitionExpr); ok {
+			value, err := p.Expr(definition.Value)
+			if err != nil {
+				return "", err
+			}
+			args = append(args, definition.Key+" => "+value)
+			continue
+		}
+		value, err := p.Expr(arg)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, value)
+	}
+	return name + "(" + strings.Join(args, ", ") + ")", nil
+}
+
+// exists prints EXISTS subqueries of the form `EXISTS (SELECT 1 FROM source AS alias WHERE condition)`, where the
+// source is a table or UNNEST of an array.
+func (p *Printer) exists(e spansql.ExistsOp) (string, error) {
+	query := e.Subquery
+	if len(query.Select.From) != 1 || len(query.Select.List) != 1 || query.Limit != nil || len(query.Order) != 0 {
+		return "", fmt.Errorf("unsupported subquery in PostgreSQL dialect: %s", e.SQL())
+	}
+	var from string
+	switch source := query.Select.From[0].(type) {
+	case spansql.SelectFromTable:
+		from = Ident(string(source.Table))
+		if source.Alias != "" {
+			from += " AS " + Ident(string(source.Alias))
+		}
+	case spansql.SelectFromUnnest:
+		array, err := p.Expr(source.Expr)
+		if err != nil {
+			return "", err
+		}
+		from = "unnest(" + array + ")"
+		if source.Alias != "" {
+			from += " AS " + Ident(string(source.Alias))
+		}
+	default:
+		return "", fmt.Errorf("unsupported subquery in PostgreSQL dialect: %s", e.SQL())
+	}
+	list, err := p.Expr(query.Select.List[0])
+	if err != nil {
+		return "", err
+	}
+	result := "EXISTS (SELECT " + list + " FROM " + from
+	if query.Select.Where != nil {
+		where, err := p.Expr(query.Select.Where)
+		if err != nil {
+			return "", err
+		}
+		result += " WHERE " + where
+	}
+	return result + ")", nil
+}
+
+func (p *Printer) args2(e spansql.Func) ([]string, error) {
+	if len(e.Args) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+	}
+	result := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		value, err := p.Expr(arg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// endsWith prints ENDS_WITH(x, @p) as x LIKE $n, with a LIKE pattern matching the parameter as a suffix.
+func (p *Printer) endsWith(e spansql.Func) (string, error) {
+	if len(e.Args) != 2 {
+		return "", fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+	}
+	param, ok := e.Args[1].(spansql.Param)
+	if !ok {
+		return "", fmt.Errorf("unsupported argument to %s in PostgreSQL dialect: %s", e.Name, e.Args[1].SQL())
+	}
+	value, ok := p.params[string(param)].(string)
+	if !ok {
+		return "", fmt.Errorf("unsupported argument to %s in PostgreSQL dialect: %s", e.Name, e.Args[1].SQL())
+	}
+	lhs, err := p.Expr(e.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return lhs + " LIKE " + p.arg(string(param)+"#suffix", "%"+likeEscaper.Replace(value)), nil
+}
+
+func (p *Printer) timestampArithmetic(e spansql.Func) (string, error) {
+	if len(e.Args) != 2 {
+		return "", fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+	}
+	interval, ok := e.Args[1].(spansql.IntervalExpr)
+	if !ok {
+		return "", fmt.Errorf("unsupported argument to %s in PostgreSQL dialect: %s", e.Name, e.Args[1].SQL())
+	}
+	timestamp, err := p.Expr(e.Args[0])
+	if err != nil {
+		return "", err
+	}
+	amount, err := p.Expr(interval.Expr)
+	if err != nil {
+		return "", err
+	}
+	op := "+"
+	if e.Name == "TIMESTAMP_SUB" {
+		op = "-"
+	}
+	unit := strings.ToLower(interval.DatePart)
+	return timestamp + " " + op + " " + amount + " * INTERVAL " + stringLiteral("1 "+unit), nil
+}
+
+// jsonValue prints JSON_VALUE(x, '$.a.b') as jsonb access, x->'a'->>'b'.
+func (p *Printer) jsonValue(e spansql.Func) (string, error) {
+	if len(e.Args) != 2 {
+		return "", fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+	}
+	jsonPath, ok := e.Args[1].(spansql.StringLiteral)
+	if !ok || !strings.HasPrefix(string(jsonPath), "$.") {
+		return "", fmt.Errorf("unsupported JSON path in PostgreSQL dialect: %s", e.Args[1].SQL())
+	}
+	document, err := p.Expr(e.Args[0])
+	if err != nil {
+		return "", err
+	}
+	path := strings.Split(strings.TrimPrefix(string(jsonPath), "$."), ".")
+	var result strings.Builder
+	result.WriteString(document)
+	for i, field := range path {
+		if i == len(path)-1 {
+			result.WriteString("->>")
+		} else {
+			result.WriteString("->")
+		}
+		result.WriteString(stringLiteral(field))
+	}
+	return result.String(), nil
+}
+
+// types maps spansql types to their PostgreSQL-dialect equivalents.
+var types = map[spansql.TypeBase]string{
+	spansql.Bool:      "boolean",
+	spansql.Int64:     "bigint",
+	spansql.Float64:   "double precision",
+	spansql.Numeric:   "numeric",
+	spansql.String:    "text",
+	spansql.Bytes:     "bytea",
+	spansql.Date:      "date",
+	spansql.Timestamp: "timestamptz",
+	spansql.JSON:      "jsonb",
+}
+
+func (p *Printer) cast(e spansql.Func) (string, error) {
+	if len(e.Args) != 1 {
+		return "", fmt.Errorf("unexpected number of arguments to %s: %d", e.Name, len(e.Args))
+	}
+	typedExpr, ok := e.Args[0].(spansql.TypedExpr)
+	if !ok || typedExpr.Type.Array {
+		return "", fmt.Errorf("unsupported CAST in PostgreSQL dialect: %s", e.SQL())
+	}
+	typeName, ok := types[typedExpr.Type.Base]
+	if !ok {
+		return "", fmt.Errorf("unsupported CAST in PostgreSQL dialect: %s", e.SQL())
+	}
+	value, err := p.Expr(typedExpr.Expr)
+	if err != nil {
+		return "", err
+	}
+	return "CAST(" + value + " AS " + typeName + ")", nil
+}
+
+var positionalParam = regexp.MustCompile(`^p[1-9][0-9]*$`)
+
+func (p *Printer) param(name string) (string, error) {
+	value, ok := p.params[name]
+	if !ok {
+		if positionalParam.MatchString(name) {
+			return "$" + strings.TrimPrefix(name, "p"), nil
+		}
+		return "", fmt.Errorf("unbound param in PostgreSQL dialect: %s", name)
+	}
+	return p.arg(name, value), nil
+}
+
+// arg returns the positional placeholder of a named parameter, allocating a position on first use.
+func (p *Printer) arg(name string, value interface{}) string {
+	if p.positions == nil {
+		p.positions = make(map[string]int)
+		p.args = make(map[string]interface{})
+	}
+	position, ok := p.positions[name]
+	if !ok {
+		position = len(p.positions) + 1
+		p.positions[name] = position
+		p.args["p"+strconv.Itoa(position)] = value
+	}
+	return "$" + strconv.Itoa(position)
+}
+
+// likeEscaper escapes the metacharacters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reservedKeywords are the PostgreSQL reserved keywords, which must be quoted when used as identifiers.
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true, "as": true, "asc": true,
+	"asymmetric": true, "both": true, "case": true, "cast": true, "check": true, "collate": true, "column": true,
+	"constraint": true, "create": true, "current_catalog": true, "current_date": true, "current_role": true,
+	"current_time": true, "current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true, "false": true,
+	"fetch": true, "for": true, "foreign": true, "from": true, "grant": true, "group": true, "having": true,
+	"in": true, "initially": true, "intersect": true, "into": true, "lateral": true, "leading": true, "limit": true,
+	"localtime": true, "localtimestamp": true, "not": true, "null": true, "offset": true, "on": true, "only": true,
+	"or": true, "order": true, "placing": true, "primary": true, "references": true, "returning": true,
+	"select": true, "session_user": true, "some": true, "symmetric": true, "system_user": true, "table": true,
+	"then": true, "to": true, "trailing": true, "true": true, "union": true, "unique": true, "user": true,
+	"using": true, "variadic": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// Ident prints an identifier, quoted unless it is a plain lowercase identifier.
+func Ident(name string) string {
+	if plainIdent.MatchString(name) && !reservedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func stringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -1,28 +1,56 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 
+	"cloud.google.com/go/spanner"
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
 	"go.einride.tech/spanner-aip/internal/codegen"
 	"go.einride.tech/spanner-aip/internal/codegen/databasecodegen"
 	"go.einride.tech/spanner-aip/internal/codegen/descriptorcodegen"
 	"go.einride.tech/spanner-aip/internal/config"
+	"go.einride.tech/spanner-aip/internal/migration"
+	"go.einride.tech/spanner-aip/spanindex"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"gopkg.in/yaml.v3"
 )
 
 const generatedBy = "spanner-aip-go"
 
+const usage = `usage:
+  spanner-aip-go [-config <config>] generate
+  spanner-aip-go [-config <config>] migrate -target <database> [-database <name>] [-emulator-host <host>] up [N]
+  spanner-aip-go [-config <config>] migrate -target <database> [-database <name>] [-emulator-host <host>] down [N]
+  spanner-aip-go [-config <config>] migrate -target <database> [-database <name>] [-emulator-host <host>] status
+  spanner-aip-go [-config <config>] explain-index [-database <name>] -table <table> [-columns <columns>] \
+    [-where <condition>] [-order-by <order>] [-allow-full-scan]`
+
 func main() {
 	log.SetFlags(0)
 	configFilePath := flag.String("config", "spanner.yaml", "config file")
 	flag.Parse()
-	if flag.Arg(0) != "generate" {
-		log.Fatal("usage: spanner-aip-go generate [-config <config>]")
+	switch flag.Arg(0) {
+	case "generate":
+		generate(loadConfig(*configFilePath))
+	case "migrate":
+		migrate(context.Background(), loadConfig(*configFilePath), flag.Args()[1:])
+	case "explain-index":
+		explainIndex(context.Background(), loadConfig(*configFilePath), flag.Args()[1:])
+	default:
+		log.Fatal(usage)
 	}
-	configFile, err := os.Open(*configFilePath)
+}
+
+func loadConfig(configFilePath string) *config.CodeGenerationConfig {
+	configFile, err := os.Open(configFilePath)
 	if err != nil {
 		log.Panic(err)
 	}
@@ -35,11 +63,18 @@ func main() {
 	if err := yaml.NewDecoder(configFile).Decode(&codeGenerationConfig); err != nil {
 		log.Panic(err)
 	}
+	return &codeGenerationConfig
+}
+
+func generate(codeGenerationConfig *config.CodeGenerationConfig) {
 	for _, databaseConfig := range codeGenerationConfig.Databases {
-		db, err := databaseConfig.LoadDatabase()
+		db, err := databaseConfig.LoadDatabase(context.Background())
 		if err != nil {
 			log.Panic(err)
 		}
+		if err := databaseConfig.CheckEtagColumn(db); err != nil {
+			log.Panic(err)
+		}
 		if err := os.MkdirAll(databaseConfig.Package.Path, 0o775); err != nil {
 			log.Panic(err)
 		}
@@ -67,7 +102,10 @@ func main() {
 				Package:     databaseConfig.Package.Name,
 				GeneratedBy: generatedBy,
 			})
-			databasecodegen.DatabaseCodeGenerator{Database: db}.GenerateCode(f)
+			databasecodegen.DatabaseCodeGenerator{
+				Database:   db,
+				EtagColumn: databaseConfig.EtagColumn,
+			}.GenerateCode(f)
 			content, err := f.Content()
 			if err != nil {
 				log.Panic(err)
@@ -79,3 +117,174 @@ func main() {
 		}
 	}
 }
+
+func migrate(ctx context.Context, codeGenerationConfig *config.CodeGenerationConfig, args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	target := flags.String("target", "", "resource name of the database to migrate")
+	databaseName := flags.String("database", "", "name of the configured database to migrate from")
+	emulatorHost := flags.String("emulator-host", "", "host of a Spanner emulator serving the target database")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if *target == "" {
+		log.Fatal(usage)
+	}
+	databaseConfig, err := findDatabaseConfig(codeGenerationConfig, *databaseName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrations, err := databaseConfig.Migrations()
+	if err != nil {
+		log.Panic(err)
+	}
+	var opts []option.ClientOption
+	if *emulatorHost != "" {
+		conn, err := grpc.NewClient(*emulatorHost, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			log.Panic(err)
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		opts = append(opts, option.WithGRPCConn(conn))
+	}
+	client, err := spanner.NewClient(ctx, *target, opts...)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer client.Close()
+	adminClient, err := database.NewDatabaseAdminClient(ctx, opts...)
+	if err != nil {
+		log.Panic(err)
+	}
+	defer func() {
+		_ = adminClient.Close()
+	}()
+	runner := migration.Runner{
+		Client:      client,
+		AdminClient: adminClient,
+		Migrations:  migrations,
+	}
+	var n int
+	if flags.NArg() > 1 {
+		if n, err = strconv.Atoi(flags.Arg(1)); err != nil || n <= 0 {
+			log.Fatalf("invalid number of migrations: %s", flags.Arg(1))
+		}
+	}
+	switch flags.Arg(0) {
+	case "up":
+		err = runner.Up(ctx, n)
+	case "down":
+		if n == 0 {
+			n = 1
+		}
+		err = runner.Down(ctx, n)
+	case "status":
+		err = printStatus(ctx, &runner)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if flags.Arg(0) != "status" {
+		if err := printStatus(ctx, &runner); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func explainIndex(ctx context.Context, codeGenerationConfig *config.CodeGenerationConfig, args []string) {
+	flags := flag.NewFlagSet("explain-index", flag.ExitOnError)
+	databaseName := flags.String("database", "", "name of the configured database to analyze")
+	table := flags.String("table", "", "table that is queried")
+	columns := flags.String("columns", "*", "comma-separated columns that are selected")
+	where := flags.String("where", "", "GoogleSQL condition of the query, e.g. a transpiled filter")
+	orderBy := flags.String("order-by", "", "GoogleSQL ordering of the query, e.g. a transpiled ordering")
+	allowFullScan := flags.Bool("allow-full-scan", false, "exit successfully when the query is served by a full scan")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
+	}
+	if *table == "" {
+		log.Fatal(usage)
+	}
+	databaseConfig, err := findDatabaseConfig(codeGenerationConfig, *databaseName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := databaseConfig.LoadDatabase(ctx)
+	if err != nil {
+		log.Panic(err)
+	}
+	sql := "SELECT " + *columns + " FROM " + *table
+	if *where != "" {
+		sql += " WHERE " + *where
+	}
+	if *orderBy != "" {
+		sql += " ORDER BY " + *orderBy
+	}
+	query, err := spanindex.ParseQuery(sql)
+	if err != nil {
+		log.Fatal(err)
+	}
+	advice, err := spanindex.Advise(db, query)
+	if err != nil {
+		log.Fatal(err)
+	}
+	switch {
+	case advice.FullScan:
+		log.Printf("served by: full scan of %s", query.Table)
+	case advice.PrimaryKey:
+		log.Printf("served by: primary key of %s", query.Table)
+	case advice.Index != nil:
+		log.Printf("served by: index %s", advice.Index.Name)
+	default:
+		log.Printf("served by: no index")
+	}
+	if advice.Served() {
+		log.Printf("covering: %t", advice.Covering)
+	}
+	for _, column := range advice.MissingColumns {
+		log.Printf("missing column: %s", column)
+	}
+	if advice.Suggestion != nil {
+		log.Printf("suggested index: %s", advice.Suggestion.SQL())
+	}
+	if !advice.Served() || (advice.FullScan && !*allowFullScan) {
+		os.Exit(1)
+	}
+}
+
+func findDatabaseConfig(codeGenerationConfig *config.CodeGenerationConfig, name string) (*config.DatabaseConfig, error) {
+	if name == "" {
+		if len(codeGenerationConfig.Databases) != 1 {
+			return nil, fmt.Errorf("-database is required when more than one database is configured")
+		}
+		return &codeGenerationConfig.Databases[0], nil
+	}
+	for i := range codeGenerationConfig.Databases {
+		if codeGenerationConfig.Databases[i].Name == name {
+			return &codeGenerationConfig.Databases[i], nil
+		}
+	}
+	return nil, fmt.Errorf("database %s is not configured", name)
+}
+
+func printStatus(ctx context.Context, runner *migration.Runner) error {
+	status, err := runner.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, m := range runner.Migrations {
+		state := "pending"
+		switch {
+		case m.Version == status.Version && status.Dirty:
+			state = "dirty"
+		case m.Version <= status.Version:
+			state = "applied"
+		}
+		log.Printf("%d_%s: %s", m.Version, m.Name, state)
+	}
+	log.Printf("version: %d (dirty: %t)", status.Version, status.Dirty)
+	return nil
+}
@@ -0,0 +1,116 @@
+package spanddl
+
+import (
+	"slices"
+
+	"cloud.google.com/go/spanner/spansql"
+)
+
+// Clone returns a deep copy of the database.
+//
+// Interleaved table references in the copy point to the copied tables, so the copy can be modified
+// without affecting the original.
+func (d *Database) Clone() *Database {
+	clones := make(map[*Table]*Table, len(d.Tables))
+	result := &Database{
+		Tables:        cloneSlice(d.Tables, func(t *Table) *Table { return t.cloneShallow(clones) }),
+		Indexes:       cloneSlice(d.Indexes, (*Index).clone),
+		SearchIndexes: cloneSlice(d.SearchIndexes, (*SearchIndex).clone),
+	}
+	for _, table := range result.Tables {
+		table.InterleavedTables = cloneSlice(table.InterleavedTables, func(t *Table) *Table {
+			return t.cloneShallow(clones)
+		})
+	}
+	return result
+}
+
+// cloneShallow returns a copy of the table, memoized in clones so that each table is copied exactly once.
+// Interleaved table references are left pointing at the original tables.
+func (t *Table) cloneShallow(clones map[*Table]*Table) *Table {
+	if clone, ok := clones[t]; ok {
+		return clone
+	}
+	clone := &Table{
+		Name:              t.Name,
+		Columns:           cloneSlice(t.Columns, (*Column).clone),
+		InterleavedTables: t.InterleavedTables,
+		PrimaryKey:        slices.Clone(t.PrimaryKey),
+		Interleave:        clonePtr(t.Interleave),
+		RowDeletionPolicy: clonePtr(t.RowDeletionPolicy),
+		ForeignKeys:       cloneSlice(t.ForeignKeys, (*ForeignKey).clone),
+	}
+	clones[t] = clone
+	return clone
+}
+
+func (c *Column) clone() *Column {
+	return &Column{
+		Name:      c.Name,
+		Type:      c.Type,
+		NotNull:   c.NotNull,
+		Generated: c.Generated,
+		Options: spansql.ColumnOptions{
+			AllowCommitTimestamp: clonePtr(c.Options.AllowCommitTimestamp),
+		},
+	}
+}
+
+func (fk *ForeignKey) clone() *ForeignKey {
+	return &ForeignKey{
+		Name:       fk.Name,
+		Columns:    slices.Clone(fk.Columns),
+		RefTable:   fk.RefTable,
+		RefColumns: slices.Clone(fk.RefColumns),
+		OnDelete:   fk.OnDelete,
+	}
+}
+
+func (i *Index) clone() *Index {
+	return &Index{
+		Name:         i.Name,
+		Table:        i.Table,
+		Columns:      slices.Clone(i.Columns),
+		Unique:       i.Unique,
+		NullFiltered: i.NullFiltered,
+		Storing:      slices.Clone(i.Storing),
+		Interleave:   i.Interleave,
+	}
+}
+
+func (s *SearchIndex) clone() *SearchIndex {
+	return &SearchIndex{
+		Name:           s.Name,
+		Table:          s.Table,
+		Columns:        slices.Clone(s.Columns),
+		Storing:        slices.Clone(s.Storing),
+		PartitionBy:    slices.Clone(s.PartitionBy),
+		OrderBy:        slices.Clone(s.OrderBy),
+		WhereIsNotNull: slices.Clone(s.WhereIsNotNull),
+		Interleave:     s.Interleave,
+		Options: spansql.SearchIndexOptions{
+			SortOrderSharding:         clonePtr(s.Options.SortOrderSharding),
+			DisableAutomaticUIDColumn: clonePtr(s.Options.DisableAutomaticUIDColumn),
+		},
+	}
+}
+
+// cloneSlice copies a slice element-wise, preserving the distinction between nil and empty slices.
+func cloneSlice[T any](s []T, clone func(T) T) []T {
+	if s == nil {
+		return nil
+	}
+	result := make([]T, len(s))
+	for i, v := range s {
+		result[i] = clone(v)
+	}
+	return result
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
@@ -11,13 +11,16 @@ type Column struct {
 	Name    spansql.ID
 	Type    spansql.Type
 	NotNull bool
-	Options spansql.ColumnOptions
+	// Generated is the expression of a generated column, such as TOKENIZE_FULLTEXT(Name).
+	Generated spansql.Expr
+	Options   spansql.ColumnOptions
 }
 
 func (c *Column) applyColumnDef(def spansql.ColumnDef) error {
 	c.Name = def.Name
 	c.Type = def.Type
 	c.NotNull = def.NotNull
+	c.Generated = def.Generated
 	c.Options = def.Options
 	return nil
 }
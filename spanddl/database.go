@@ -47,10 +47,15 @@ func (d *Database) SearchIndex(name spansql.ID) (*SearchIndex, bool) {
 	return nil, false
 }
 
-// ApplyDDL applies the provided DDL statement to the database.
+// ApplyDDL applies the provided DDL statements to the database.
+//
+// The statements are applied atomically: if any statement fails, the database is rolled back to its state before
+// the call. Tables, indexes and columns looked up before a failed call must be looked up again afterwards.
 func (d *Database) ApplyDDL(ddl *spansql.DDL) error {
+	snapshot := d.Clone()
 	for _, stmt := range ddl.List {
 		if err := d.applyDDLStmt(stmt); err != nil {
+			*d = *snapshot
 			return err
 		}
 	}
@@ -101,6 +106,11 @@ func (d *Database) applyCreateTable(stmt *spansql.CreateTable) (err error) {
 		}
 		table.Columns = append(table.Columns, &column)
 	}
+	for _, constraint := range stmt.Constraints {
+		if err := table.applyTableConstraint(constraint); err != nil {
+			return err
+		}
+	}
 	if table.Interleave != nil {
 		parent, ok := d.Table(table.Interleave.Parent)
 		if !ok {
@@ -142,6 +152,16 @@ func (d *Database) applyDropTable(stmt *spansql.DropTable) (err error) {
 		}
 		return fmt.Errorf("table %s has interleaved tables %s", stmt.Name, strings.Join(names, ", "))
 	}
+	for _, table := range d.Tables {
+		if table.Name == stmt.Name {
+			continue
+		}
+		for _, fk := range table.ForeignKeys {
+			if fk.RefTable == stmt.Name {
+				return fmt.Errorf("table %s is referenced by a foreign key on table %s", stmt.Name, table.Name)
+			}
+		}
+	}
 	d.Tables = append(d.Tables[:i], d.Tables[i+1:]...)
 	d.removeInterleavedReferenceFromParentTable(stmt.Name)
 	return nil
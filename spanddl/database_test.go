@@ -454,7 +454,14 @@ func TestDatabase_ApplyDDL(t *testing.T) {
 						Name: "Singers",
 						Columns: []*Column{
 							{Name: "SingerId", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
-							{Name: "SingerIdTokens", Type: spansql.Type{Base: spansql.Tokenlist}},
+							{
+								Name: "SingerIdTokens",
+								Type: spansql.Type{Base: spansql.Tokenlist},
+								Generated: spansql.Func{
+									Name: "TOKENIZE_NGRAMS",
+									Args: []spansql.Expr{spansql.ID("SingerId")},
+								},
+							},
 						},
 						PrimaryKey: []spansql.KeyPart{
 							{Column: "SingerId"},
@@ -478,7 +485,14 @@ func TestDatabase_ApplyDDL(t *testing.T) {
 						Name: "Singers",
 						Columns: []*Column{
 							{Name: "SingerId", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
-							{Name: "SingerIdTokens", Type: spansql.Type{Base: spansql.Tokenlist}},
+							{
+								Name: "SingerIdTokens",
+								Type: spansql.Type{Base: spansql.Tokenlist},
+								Generated: spansql.Func{
+									Name: "TOKENIZE_NGRAMS",
+									Args: []spansql.Expr{spansql.ID("SingerId")},
+								},
+							},
 						},
 						PrimaryKey: []spansql.KeyPart{
 							{Column: "SingerId"},
@@ -502,7 +516,14 @@ func TestDatabase_ApplyDDL(t *testing.T) {
 						Name: "Singers",
 						Columns: []*Column{
 							{Name: "SingerId", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
-							{Name: "SingerIdTokens", Type: spansql.Type{Base: spansql.Tokenlist}},
+							{
+								Name: "SingerIdTokens",
+								Type: spansql.Type{Base: spansql.Tokenlist},
+								Generated: spansql.Func{
+									Name: "TOKENIZE_NGRAMS",
+									Args: []spansql.Expr{spansql.ID("SingerId")},
+								},
+							},
 						},
 						PrimaryKey: []spansql.KeyPart{
 							{Column: "SingerId"},
@@ -538,7 +559,14 @@ func TestDatabase_ApplyDDL(t *testing.T) {
 						Name: "Singers",
 						Columns: []*Column{
 							{Name: "SingerId", Type: spansql.Type{Base: spansql.Int64}, NotNull: true},
-							{Name: "SingerIdTokens", Type: spansql.Type{Base: spansql.Tokenlist}},
+							{
+								Name: "SingerIdTokens",
+								Type: spansql.Type{Base: spansql.Tokenlist},
+								Generated: spansql.Func{
+									Name: "TOKENIZE_NGRAMS",
+									Args: []spansql.Expr{spansql.ID("SingerId")},
+								},
+							},
 						},
 						PrimaryKey: []spansql.KeyPart{
 							{Column: "SingerId"},
@@ -572,3 +600,68 @@ func TestDatabase_ApplyDDL(t *testing.T) {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestDatabase_ApplyDDL_rollback(t *testing.T) {
+	t.Parallel()
+	var db Database
+	ddl, err := spansql.ParseDDL("setup", `CREATE TABLE Singers (
+	  SingerId   INT64 NOT NULL,
+	  FirstName  STRING(1024),
+	) PRIMARY KEY(SingerId);`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	expected := db.Clone()
+	ddl, err = spansql.ParseDDL("migration", `
+	  CREATE TABLE Albums (
+	    SingerId     INT64 NOT NULL,
+	    AlbumId      INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+	  ALTER TABLE Singers ADD COLUMN LastName STRING(1024);
+	  CREATE INDEX SingersByFirstName ON Singers(FirstName);
+	  DROP TABLE Songs;`)
+	assert.NilError(t, err)
+	assert.ErrorContains(t, db.ApplyDDL(ddl), "DROP TABLE: table Songs does not exist")
+	assert.DeepEqual(t, expected, &db)
+	// The database can be migrated after a failed attempt.
+	ddl, err = spansql.ParseDDL("migration", `
+	  CREATE TABLE Albums (
+	    SingerId     INT64 NOT NULL,
+	    AlbumId      INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+}
+
+func TestDatabase_Clone(t *testing.T) {
+	t.Parallel()
+	var db Database
+	ddl, err := spansql.ParseDDL("setup", `
+	  CREATE TABLE Singers (
+	    SingerId   INT64 NOT NULL,
+	    Timestamp  TIMESTAMP OPTIONS (allow_commit_timestamp=true),
+	  ) PRIMARY KEY(SingerId);
+	  CREATE TABLE Albums (
+	    SingerId     INT64 NOT NULL,
+	    AlbumId      INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+	  CREATE INDEX AlbumsByAlbumId ON Albums(AlbumId) STORING (SingerId);`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	clone := db.Clone()
+	assert.DeepEqual(t, &db, clone)
+	singers, ok := clone.Table("Singers")
+	assert.Assert(t, ok)
+	albums, ok := clone.Table("Albums")
+	assert.Assert(t, ok)
+	assert.Assert(t, singers.InterleavedTables[0] == albums, "interleaved tables must reference cloned tables")
+	*singers.Columns[1].Options.AllowCommitTimestamp = false
+	albums.Interleave.OnDelete = spansql.NoActionOnDelete
+	clone.Indexes[0].Storing[0] = "AlbumId"
+	original, _ := db.Table("Singers")
+	assert.Equal(t, true, *original.Columns[1].Options.AllowCommitTimestamp)
+	assert.Equal(t, spansql.CascadeOnDelete, original.InterleavedTables[0].Interleave.OnDelete)
+	assert.Equal(t, spansql.ID("SingerId"), db.Indexes[0].Storing[0])
+}
@@ -0,0 +1,23 @@
+package spanddl
+
+import "cloud.google.com/go/spanner/spansql"
+
+// ForeignKey represents a foreign key constraint on a Spanner table.
+type ForeignKey struct {
+	// Name of the constraint. May be empty for unnamed constraints.
+	Name       spansql.ID
+	Columns    []spansql.ID
+	RefTable   spansql.ID
+	RefColumns []spansql.ID
+	OnDelete   spansql.OnDelete
+}
+
+func newForeignKey(name spansql.ID, fk spansql.ForeignKey) *ForeignKey {
+	return &ForeignKey{
+		Name:       name,
+		Columns:    fk.Columns,
+		RefTable:   fk.RefTable,
+		RefColumns: fk.RefColumns,
+		OnDelete:   fk.OnDelete,
+	}
+}
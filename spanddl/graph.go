@@ -0,0 +1,194 @@
+package spanddl
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+)
+
+// SchemaObject is a named object in a database schema: a *Table, an *Index or a *SearchIndex.
+type SchemaObject interface {
+	// ObjectName returns the name of the schema object.
+	ObjectName() spansql.ID
+	isSchemaObject()
+}
+
+var (
+	_ SchemaObject = &Table{}
+	_ SchemaObject = &Index{}
+	_ SchemaObject = &SearchIndex{}
+)
+
+// ObjectName implements SchemaObject.
+func (t *Table) ObjectName() spansql.ID { return t.Name }
+
+// ObjectName implements SchemaObject.
+func (i *Index) ObjectName() spansql.ID { return i.Name }
+
+// ObjectName implements SchemaObject.
+func (s *SearchIndex) ObjectName() spansql.ID { return s.Name }
+
+func (*Table) isSchemaObject()       {}
+func (*Index) isSchemaObject()       {}
+func (*SearchIndex) isSchemaObject() {}
+
+// RootTables returns the tables that are not interleaved in a parent table, in declaration order.
+func (d *Database) RootTables() []*Table {
+	var result []*Table
+	for _, table := range d.Tables {
+		if table.Interleave == nil {
+			result = append(result, table)
+		}
+	}
+	return result
+}
+
+// Parent returns the table that the provided table is interleaved in.
+func (d *Database) Parent(table *Table) (*Table, bool) {
+	for _, candidate := range d.Tables {
+		for _, interleaved := range candidate.InterleavedTables {
+			if interleaved == table {
+				return candidate, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Ancestors returns the interleave ancestors of the provided table, starting with its parent and ending with its
+// root table.
+func (d *Database) Ancestors(table *Table) iter.Seq[*Table] {
+	return func(yield func(*Table) bool) {
+		for parent, ok := d.Parent(table); ok; parent, ok = d.Parent(parent) {
+			if !yield(parent) {
+				return
+			}
+		}
+	}
+}
+
+// Descendants returns all tables interleaved in the table, directly or transitively, in depth-first pre-order.
+func (t *Table) Descendants() iter.Seq[*Table] {
+	return func(yield func(*Table) bool) {
+		t.yieldDescendants(yield)
+	}
+}
+
+func (t *Table) yieldDescendants(yield func(*Table) bool) bool {
+	for _, interleaved := range t.InterleavedTables {
+		if !yield(interleaved) || !interleaved.yieldDescendants(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// Dependencies returns the tables that must exist before the provided schema object can be created.
+//
+// For a table these are its interleave parent and the tables referenced by its foreign keys. For an index or search
+// index these are the indexed table and the table the index is interleaved in. Tables missing from the database are
+// omitted.
+func (d *Database) Dependencies(object SchemaObject) []*Table {
+	var names []spansql.ID
+	switch object := object.(type) {
+	case *Table:
+		if object.Interleave != nil {
+			names = append(names, object.Interleave.Parent)
+		}
+		for _, fk := range object.ForeignKeys {
+			if fk.RefTable != object.Name {
+				names = append(names, fk.RefTable)
+			}
+		}
+	case *Index:
+		names = append(names, object.Table)
+		if object.Interleave != "" {
+			names = append(names, object.Interleave)
+		}
+	case *SearchIndex:
+		names = append(names, object.Table)
+		if object.Interleave != "" {
+			names = append(names, object.Interleave)
+		}
+	}
+	var result []*Table
+	for _, name := range names {
+		table, ok := d.Table(name)
+		if !ok || slices.Contains(result, table) {
+			continue
+		}
+		result = append(result, table)
+	}
+	return result
+}
+
+// CreationOrder returns all tables, indexes and search indexes of the database in an order they can be created in.
+//
+// Every schema object is ordered after its dependencies. Among independent objects declaration order is preserved,
+// and tables are ordered before indexes. Foreign keys that form a cycle between tables result in an error, since
+// such tables can not be created without deferring the constraints.
+func (d *Database) CreationOrder() ([]SchemaObject, error) {
+	objects := make([]SchemaObject, 0, len(d.Tables)+len(d.Indexes)+len(d.SearchIndexes))
+	for _, table := range d.Tables {
+		objects = append(objects, table)
+	}
+	for _, index := range d.Indexes {
+		objects = append(objects, index)
+	}
+	for _, index := range d.SearchIndexes {
+		objects = append(objects, index)
+	}
+	result := make([]SchemaObject, 0, len(objects))
+	created := make(map[*Table]bool, len(d.Tables))
+	done := make([]bool, len(objects))
+	for len(result) < len(objects) {
+		progress := false
+		for i, object := range objects {
+			if done[i] || !allCreated(created, d.Dependencies(object)) {
+				continue
+			}
+			if table, ok := object.(*Table); ok {
+				created[table] = true
+			}
+			result = append(result, object)
+			done[i] = true
+			progress = true
+			// restart from the beginning to preserve declaration order among unblocked objects
+			break
+		}
+		if !progress {
+			var names []string
+			for i, object := range objects {
+				if !done[i] {
+					names = append(names, string(object.ObjectName()))
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle between %s", strings.Join(names, ", "))
+		}
+	}
+	return result, nil
+}
+
+// DropOrder returns all tables, indexes and search indexes of the database in an order they can be dropped in.
+//
+// The drop order is the reverse of the creation order: every schema object is ordered before its dependencies.
+func (d *Database) DropOrder() ([]SchemaObject, error) {
+	result, err := d.CreationOrder()
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(result)
+	return result, nil
+}
+
+func allCreated(created map[*Table]bool, tables []*Table) bool {
+	for _, table := range tables {
+		if !created[table] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,165 @@
+package spanddl
+
+import (
+	"slices"
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"gotest.tools/v3/assert"
+)
+
+func TestDatabase_graph(t *testing.T) {
+	t.Parallel()
+	var db Database
+	ddl, err := spansql.ParseDDL("graph", `
+	  CREATE TABLE Singers (
+	    SingerId INT64 NOT NULL,
+	    LabelId  INT64,
+	  ) PRIMARY KEY (SingerId);
+	  CREATE TABLE Albums (
+	    SingerId INT64 NOT NULL,
+	    AlbumId  INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+	  CREATE TABLE Songs (
+	    SingerId INT64 NOT NULL,
+	    AlbumId  INT64 NOT NULL,
+	    TrackId  INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, AlbumId, TrackId),
+	    INTERLEAVE IN PARENT Albums ON DELETE CASCADE;
+	  CREATE TABLE Concerts (
+	    SingerId  INT64 NOT NULL,
+	    ConcertId INT64 NOT NULL,
+	  ) PRIMARY KEY (SingerId, ConcertId),
+	    INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+	  CREATE TABLE Labels (
+	    LabelId INT64 NOT NULL,
+	  ) PRIMARY KEY (LabelId);
+	  ALTER TABLE Singers ADD CONSTRAINT FK_SingerLabel FOREIGN KEY (LabelId) REFERENCES Labels (LabelId);
+	  CREATE INDEX SongsByTrackId ON Songs(TrackId), INTERLEAVE IN Albums;
+	  CREATE SEARCH INDEX SingersSearch ON Singers(LabelId);`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	table := func(name spansql.ID) *Table {
+		table, ok := db.Table(name)
+		assert.Assert(t, ok)
+		return table
+	}
+	names := func(objects []*Table) []spansql.ID {
+		result := make([]spansql.ID, 0, len(objects))
+		for _, object := range objects {
+			result = append(result, object.Name)
+		}
+		return result
+	}
+
+	t.Run("root tables", func(t *testing.T) {
+		t.Parallel()
+		assert.DeepEqual(t, []spansql.ID{"Singers", "Labels"}, names(db.RootTables()))
+	})
+
+	t.Run("ancestors", func(t *testing.T) {
+		t.Parallel()
+		assert.DeepEqual(t, []spansql.ID{"Albums", "Singers"}, names(slices.Collect(db.Ancestors(table("Songs")))))
+		assert.Equal(t, 0, len(slices.Collect(db.Ancestors(table("Singers")))))
+	})
+
+	t.Run("descendants", func(t *testing.T) {
+		t.Parallel()
+		assert.DeepEqual(
+			t,
+			[]spansql.ID{"Albums", "Songs", "Concerts"},
+			names(slices.Collect(table("Singers").Descendants())),
+		)
+	})
+
+	t.Run("dependencies", func(t *testing.T) {
+		t.Parallel()
+		assert.DeepEqual(t, []spansql.ID{"Labels"}, names(db.Dependencies(table("Singers"))))
+		assert.DeepEqual(t, []spansql.ID{"Albums"}, names(db.Dependencies(table("Songs"))))
+		index, ok := db.Index("SongsByTrackId")
+		assert.Assert(t, ok)
+		assert.DeepEqual(t, []spansql.ID{"Songs", "Albums"}, names(db.Dependencies(index)))
+		searchIndex, ok := db.SearchIndex("SingersSearch")
+		assert.Assert(t, ok)
+		assert.DeepEqual(t, []spansql.ID{"Singers"}, names(db.Dependencies(searchIndex)))
+	})
+
+	t.Run("creation and drop order", func(t *testing.T) {
+		t.Parallel()
+		creationOrder, err := db.CreationOrder()
+		assert.NilError(t, err)
+		objectNames := func(objects []SchemaObject) []spansql.ID {
+			result := make([]spansql.ID, 0, len(objects))
+			for _, object := range objects {
+				result = append(result, object.ObjectName())
+			}
+			return result
+		}
+		assert.DeepEqual(
+			t,
+			[]spansql.ID{"Labels", "Singers", "Albums", "Songs", "Concerts", "SongsByTrackId", "SingersSearch"},
+			objectNames(creationOrder),
+		)
+		dropOrder, err := db.DropOrder()
+		assert.NilError(t, err)
+		assert.DeepEqual(
+			t,
+			[]spansql.ID{"SingersSearch", "SongsByTrackId", "Concerts", "Songs", "Albums", "Singers", "Labels"},
+			objectNames(dropOrder),
+		)
+	})
+}
+
+func TestDatabase_CreationOrder_cycle(t *testing.T) {
+	t.Parallel()
+	var db Database
+	ddl, err := spansql.ParseDDL("cycle", `
+	  CREATE TABLE A (
+	    Id  INT64 NOT NULL,
+	    BId INT64,
+	  ) PRIMARY KEY (Id);
+	  CREATE TABLE B (
+	    Id  INT64 NOT NULL,
+	    AId INT64,
+	    CONSTRAINT FK_BA FOREIGN KEY (AId) REFERENCES A (Id),
+	  ) PRIMARY KEY (Id);
+	  ALTER TABLE A ADD CONSTRAINT FK_AB FOREIGN KEY (BId) REFERENCES B (Id);`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	_, err = db.CreationOrder()
+	assert.ErrorContains(t, err, "dependency cycle between A, B")
+}
+
+func TestDatabase_ApplyDDL_foreignKeys(t *testing.T) {
+	t.Parallel()
+	var db Database
+	ddl, err := spansql.ParseDDL("foreign keys", `
+	  CREATE TABLE Labels (
+	    LabelId INT64 NOT NULL,
+	  ) PRIMARY KEY (LabelId);
+	  CREATE TABLE Singers (
+	    SingerId INT64 NOT NULL,
+	    LabelId  INT64,
+	  ) PRIMARY KEY (SingerId);
+	  ALTER TABLE Singers ADD CONSTRAINT FK_SingerLabel FOREIGN KEY (LabelId) REFERENCES Labels (LabelId);`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	singers, ok := db.Table("Singers")
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, []*ForeignKey{
+		{Name: "FK_SingerLabel", Columns: []spansql.ID{"LabelId"}, RefTable: "Labels", RefColumns: []spansql.ID{"LabelId"}},
+	}, singers.ForeignKeys)
+	ddl, err = spansql.ParseDDL("drop referenced", `DROP TABLE Labels;`)
+	assert.NilError(t, err)
+	assert.ErrorContains(t, db.ApplyDDL(ddl), "table Labels is referenced by a foreign key on table Singers")
+	ddl, err = spansql.ParseDDL("drop constraint", `
+	  ALTER TABLE Singers DROP CONSTRAINT FK_SingerLabel;
+	  DROP TABLE Labels;`)
+	assert.NilError(t, err)
+	assert.NilError(t, db.ApplyDDL(ddl))
+	// The failed DROP TABLE rolled back the database, so the table must be looked up again.
+	singers, ok = db.Table("Singers")
+	assert.Assert(t, ok)
+	assert.Equal(t, 0, len(singers.ForeignKeys))
+}
@@ -15,6 +15,7 @@ type Table struct {
 	PrimaryKey        []spansql.KeyPart
 	Interleave        *spansql.Interleave
 	RowDeletionPolicy *spansql.RowDeletionPolicy
+	ForeignKeys       []*ForeignKey
 }
 
 func (t *Table) applyAlterTable(stmt *spansql.AlterTable) error {
@@ -118,26 +119,54 @@ func (t *Table) applyDropColumnAlteration(alteration spansql.DropColumn) (err er
 	return nil
 }
 
-func (t *Table) applyAddConstraintAlteration(_ spansql.AddConstraint) (err error) {
+func (t *Table) applyAddConstraintAlteration(alteration spansql.AddConstraint) (err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("apply ADD CONSTRAINT: %w", err)
 		}
 	}()
-	// constraints are not implemented
-	return nil
+	return t.applyTableConstraint(alteration.Constraint)
 }
 
-func (t *Table) applyDropConstraintAlteration(_ spansql.DropConstraint) (err error) {
+func (t *Table) applyDropConstraintAlteration(alteration spansql.DropConstraint) (err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("apply DROP CONSTRAINT: %w", err)
 		}
 	}()
-	// constraints are not implemented
+	// check constraints are not implemented, so a missing constraint is not an error
+	for i, fk := range t.ForeignKeys {
+		if fk.Name == alteration.Name {
+			t.ForeignKeys = append(t.ForeignKeys[:i], t.ForeignKeys[i+1:]...)
+			return nil
+		}
+	}
 	return nil
 }
 
+func (t *Table) applyTableConstraint(constraint spansql.TableConstraint) error {
+	switch c := constraint.Constraint.(type) {
+	case spansql.ForeignKey:
+		if constraint.Name != "" {
+			for _, fk := range t.ForeignKeys {
+				if fk.Name == constraint.Name {
+					return fmt.Errorf("constraint %s already exists", constraint.Name)
+				}
+			}
+		}
+		for _, column := range c.Columns {
+			if _, ok := t.Column(column); !ok {
+				return fmt.Errorf("column %s does not exist", column)
+			}
+		}
+		t.ForeignKeys = append(t.ForeignKeys, newForeignKey(constraint.Name, c))
+		return nil
+	default:
+		// check constraints are not implemented
+		return nil
+	}
+}
+
 func (t *Table) applySetOnDeleteAlteration(alteration spansql.SetOnDelete) (err error) {
 	defer func() {
 		if err != nil {
@@ -0,0 +1,163 @@
+package spanfiltering
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"go.einride.tech/aip/filtering"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileFilter_in(t *testing.T) {
+	t.Parallel()
+	ident := func(id int64, name string) *expr.Expr {
+		return &expr.Expr{Id: id, ExprKind: &expr.Expr_IdentExpr{IdentExpr: &expr.Expr_Ident{Name: name}}}
+	}
+	str := func(id int64, value string) *expr.Expr {
+		return &expr.Expr{Id: id, ExprKind: &expr.Expr_ConstExpr{
+			ConstExpr: &expr.Constant{ConstantKind: &expr.Constant_StringValue{StringValue: value}},
+		}}
+	}
+	list := func(id int64, elements ...*expr.Expr) *expr.Expr {
+		return &expr.Expr{Id: id, ExprKind: &expr.Expr_ListExpr{ListExpr: &expr.Expr_CreateList{Elements: elements}}}
+	}
+	field := func(id int64, operand *expr.Expr, name string) *expr.Expr {
+		return &expr.Expr{Id: id, ExprKind: &expr.Expr_SelectExpr{
+			SelectExpr: &expr.Expr_Select{Operand: operand, Field: name},
+		}}
+	}
+	in := func(id int64, lhs, rhs *expr.Expr) *expr.Expr {
+		return &expr.Expr{Id: id, ExprKind: &expr.Expr_CallExpr{
+			CallExpr: &expr.Expr_Call{Function: FunctionIn, Args: []*expr.Expr{lhs, rhs}},
+		}}
+	}
+	enumType := filtering.TypeEnum(syntaxv1.Enum(0).Type())
+	for _, tt := range []struct {
+		name           string
+		expr           *expr.Expr
+		typeMap        map[int64]*expr.Type
+		options        []TranspileOption
+		expectedSQL    string
+		expectedParams map[string]interface{}
+		errorContains  string
+	}{
+		{
+			name:        "strings",
+			expr:        in(1, ident(2, "author"), list(3, str(4, "Karin Boye"), str(5, "Selma Lagerlöf"))),
+			typeMap:     map[int64]*expr.Type{2: filtering.TypeString},
+			expectedSQL: `(author IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"Karin Boye", "Selma Lagerlöf"},
+			},
+		},
+
+		{
+			name:    "JSON array element field",
+			expr:    in(1, field(2, ident(3, "line_items"), "title"), list(4, str(5, "pallet"), str(6, "crate"))),
+			typeMap: map[int64]*expr.Type{2: filtering.TypeString},
+			options: []TranspileOption{WithJSONArrayColumns("line_items")},
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE JSON_VALUE(e, "$.title") IN UNNEST(@param_0)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"pallet", "crate"},
+			},
+		},
+
+		{
+			name:    "enums as strings",
+			expr:    in(1, ident(2, "example_enum"), list(3, ident(4, "ENUM_ONE"), ident(5, "ENUM_TWO"))),
+			options: []TranspileOption{WithEnumValuesAsStrings()},
+			typeMap: map[int64]*expr.Type{
+				2: enumType,
+				4: enumType,
+				5: enumType,
+			},
+			expectedSQL: `(example_enum IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"ENUM_ONE", "ENUM_TWO"},
+			},
+		},
+
+		{
+			name:        "escaped asterisks",
+			expr:        in(1, ident(2, "title"), list(3, str(4, `\*`), str(5, `5 \\ 3`))),
+			typeMap:     map[int64]*expr.Type{2: filtering.TypeString},
+			expectedSQL: `(title IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"*", `5 \ 3`},
+			},
+		},
+
+		{
+			name:        "date strings",
+			expr:        in(1, ident(2, "birth_date"), list(3, str(4, "2021-02-14"), str(5, "2021-02-15"))),
+			typeMap:     map[int64]*expr.Type{2: TypeDate},
+			expectedSQL: `(birth_date IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []civil.Date{
+					{Year: 2021, Month: time.February, Day: 14},
+					{Year: 2021, Month: time.February, Day: 15},
+				},
+			},
+		},
+
+		{
+			name:          "invalid date string",
+			expr:          in(1, ident(2, "birth_date"), list(3, str(4, "2021-02-30"))),
+			typeMap:       map[int64]*expr.Type{2: TypeDate},
+			errorContains: "invalid date",
+		},
+
+		{
+			name:        "timestamp strings",
+			expr:        in(1, ident(2, "create_time"), list(3, str(4, "2021-02-14T14:49:34Z"))),
+			typeMap:     map[int64]*expr.Type{2: filtering.TypeTimestamp},
+			expectedSQL: `(create_time IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []time.Time{mustParseTime(t, "2021-02-14T14:49:34Z")},
+			},
+		},
+
+		{
+			name:        "repeated field",
+			expr:        in(1, str(2, "value"), ident(3, "repeated_string")),
+			typeMap:     map[int64]*expr.Type{3: filtering.TypeList(filtering.TypeString)},
+			expectedSQL: `(@param_0 IN UNNEST(repeated_string))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "value",
+			},
+		},
+
+		{
+			name:        "empty list",
+			expr:        in(1, ident(2, "author"), list(3)),
+			typeMap:     map[int64]*expr.Type{2: filtering.TypeString},
+			expectedSQL: `(FALSE)`,
+		},
+
+		{
+			name:          "non-literal element",
+			expr:          in(1, ident(2, "author"), list(3, ident(4, "title"))),
+			typeMap:       map[int64]*expr.Type{2: filtering.TypeString, 4: filtering.TypeString},
+			errorContains: "unsupported element in `in` list",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter := filtering.Filter{
+				CheckedExpr: &expr.CheckedExpr{Expr: tt.expr, TypeMap: tt.typeMap},
+			}
+			actual, params, err := TranspileFilter(filter, tt.options...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, actual.SQL())
+			assert.DeepEqual(t, tt.expectedParams, params)
+		})
+	}
+}
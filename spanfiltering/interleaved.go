@@ -0,0 +1,213 @@
+package spanfiltering
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanddl"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WithInterleavedTable transpiles fields nested in the repeated field path to conditions on the rows of a child table
+// interleaved in the filtered table. The child table can be interleaved directly in the table, or in one of its
+// descendants.
+//
+// Fields of the child table rows are resolved with the field resolver configured with WithFieldResolver or
+// WithFieldMapping, if any, which must resolve them to column names of the child table. Fields nested deeper than one
+// level in the path are rejected.
+//
+// For example, with the path "line_items" mapped to LineItems interleaved in Shipments, `line_items.title = "pallet"`
+// becomes `EXISTS (SELECT 1 FROM LineItems AS c WHERE c.ShipmentId = Shipments.ShipmentId AND c.title = @param_0)`,
+// and `line_items:*` checks that the shipment has any line items.
+//
+// Each condition matches any child row, so `line_items.title = "pallet" AND line_items.weight > 10.0` may be satisfied
+// by different line items. The filtered table must be referenced by its name in the enclosing query, as in the
+// generated List methods.
+func WithInterleavedTable(path string, database *spanddl.Database, table, child spansql.ID) TranspileOption {
+	return func(options *transpileOptions) {
+		options.interleavedTables = append(options.interleavedTables, interleavedTable{
+			path:     path,
+			database: database,
+			table:    table,
+			child:    child,
+		})
+	}
+}
+
+// interleavedTableAlias is the alias of interleaved table rows in EXISTS subqueries.
+const interleavedTableAlias = "c"
+
+type interleavedTable struct {
+	path     string
+	database *spanddl.Database
+	table    spansql.ID
+	child    spansql.ID
+}
+
+// interleavedTableCall returns the interleaved table of a comparison, has, in or string match call on a field nested
+// in its path.
+func (t *Transpiler) interleavedTableCall(e *expr.Expr) (*interleavedTable, bool) {
+	if t.interleavedTable != nil || len(t.options.interleavedTables) == 0 {
+		return nil, false
+	}
+	root, ok := elementCallRoot(e)
+	if !ok {
+		return nil, false
+	}
+	return t.lookupInterleavedTable(root)
+}
+
+// interleavedTablePath returns the interleaved table of an ident expr referring to its path.
+func (t *Transpiler) interleavedTablePath(e *expr.Expr) (*interleavedTable, bool) {
+	if t.interleavedTable != nil || e.GetIdentExpr() == nil {
+		return nil, false
+	}
+	return t.lookupInterleavedTable(e.GetIdentExpr().GetName())
+}
+
+func (t *Transpiler) lookupInterleavedTable(path string) (*interleavedTable, bool) {
+	for i := range t.options.interleavedTables {
+		if t.options.interleavedTables[i].path == path {
+			return &t.options.interleavedTables[i], true
+		}
+	}
+	return nil, false
+}
+
+func (t *Transpiler) transpileInterleavedTableCallExpr(
+	e *expr.Expr,
+	table *interleavedTable,
+) (spansql.BoolExpr, error) {
+	t.interleavedTable = table
+	defer func() {
+		t.interleavedTable = nil
+	}()
+	condition, err := t.transpileCallExpr(e)
+	if err != nil {
+		return nil, err
+	}
+	conditionBoolExpr, ok := condition.(spansql.BoolExpr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected condition on %s: not a bool expr", table.path)
+	}
+	return t.interleavedTableExists(table, conditionBoolExpr)
+}
+
+// interleavedTableExists returns an EXISTS subquery over the child rows of the filtered row, with an optional
+// condition on the child rows.
+func (t *Transpiler) interleavedTableExists(
+	table *interleavedTable,
+	condition spansql.BoolExpr,
+) (spansql.BoolExpr, error) {
+	parent, child, err := table.resolve()
+	if err != nil {
+		return nil, err
+	}
+	var where spansql.BoolExpr
+	for _, keyPart := range parent.PrimaryKey {
+		keyCondition := spansql.ComparisonOp{
+			Op:  spansql.Eq,
+			LHS: spansql.PathExp{interleavedTableAlias, keyPart.Column},
+			RHS: spansql.PathExp{parent.Name, keyPart.Column},
+		}
+		if where == nil {
+			where = keyCondition
+			continue
+		}
+		where = spansql.LogicalOp{Op: spansql.And, LHS: where, RHS: keyCondition}
+	}
+	if condition != nil {
+		where = spansql.LogicalOp{Op: spansql.And, LHS: where, RHS: condition}
+	}
+	return spansql.ExistsOp{
+		Subquery: spansql.Query{
+			Select: spansql.Select{
+				List:  []spansql.Expr{spansql.IntegerLiteral(1)},
+				From:  []spansql.SelectFrom{spansql.SelectFromTable{Table: child.Name, Alias: interleavedTableAlias}},
+				Where: where,
+			},
+		},
+	}, nil
+}
+
+// interleavedTableField reports whether a select expr refers to a field of the interleaved table being filtered.
+func (t *Transpiler) interleavedTableField(e *expr.Expr) bool {
+	if t.interleavedTable == nil {
+		return false
+	}
+	for e.GetSelectExpr() != nil {
+		e = e.GetSelectExpr().GetOperand()
+	}
+	return e.GetIdentExpr().GetName() == t.interleavedTable.path
+}
+
+// interleavedTableColumn returns the column of a field of the interleaved table rows being filtered.
+//
+// Field paths are resolved with the configured field resolver, which must resolve them to column names of the
+// interleaved table.
+func (t *Transpiler) interleavedTableColumn(e *expr.Expr) (spansql.Expr, error) {
+	selectExpr := e.GetSelectExpr()
+	if selectExpr.GetOperand().GetIdentExpr() == nil {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"unsupported field in filter: %s: fields of %s can't be nested",
+			selectExprPath(e),
+			t.interleavedTable.path,
+		)
+	}
+	_, child, err := t.interleavedTable.resolve()
+	if err != nil {
+		return nil, err
+	}
+	name := spansql.ID(selectExpr.GetField())
+	if t.options.fieldResolver != nil {
+		path := selectExprPath(e)
+		resolved, err := t.resolveField(path)
+		if err != nil {
+			return nil, err
+		}
+		id, ok := resolved.(spansql.ID)
+		if !ok {
+			return nil, status.Errorf(
+				codes.Internal, "field %s resolves to %s, not a column of %s", path, resolved.SQL(), child.Name,
+			)
+		}
+		name = id
+	}
+	// Column names are case-insensitive.
+	for _, column := range child.Columns {
+		if strings.EqualFold(string(column.Name), string(name)) {
+			return spansql.PathExp{interleavedTableAlias, column.Name}, nil
+		}
+	}
+	if t.options.fieldResolver != nil {
+		return nil, status.Errorf(
+			codes.Internal, "field %s resolves to unknown column %s of %s", selectExprPath(e), name, child.Name,
+		)
+	}
+	return nil, status.Errorf(codes.InvalidArgument, "unsupported field in filter: %s", selectExprPath(e))
+}
+
+// resolve returns the filtered table and the interleaved child table, and checks that the child table is interleaved
+// in the filtered table. Errors are configuration errors, and are returned with an Internal status.
+func (i *interleavedTable) resolve() (*spanddl.Table, *spanddl.Table, error) {
+	if i.database == nil {
+		return nil, nil, status.Errorf(codes.Internal, "no database for interleaved table %s", i.child)
+	}
+	parent, ok := i.database.Table(i.table)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Internal, "unknown table %s", i.table)
+	}
+	child, ok := i.database.Table(i.child)
+	if !ok {
+		return nil, nil, status.Errorf(codes.Internal, "unknown table %s", i.child)
+	}
+	if !slices.Contains(slices.Collect(i.database.Ancestors(child)), parent) {
+		return nil, nil, status.Errorf(codes.Internal, "table %s is not interleaved in %s", child.Name, parent.Name)
+	}
+	return parent, child, nil
+}
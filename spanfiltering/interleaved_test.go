@@ -0,0 +1,233 @@
+package spanfiltering
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/spanner-aip/spanddl"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileFilter_interleavedTable(t *testing.T) {
+	t.Parallel()
+	ddl, err := spansql.ParseDDL("", `
+		CREATE TABLE Singers (
+			SingerId INT64 NOT NULL,
+			FirstName STRING(MAX),
+		) PRIMARY KEY (SingerId);
+
+		CREATE TABLE Albums (
+			SingerId INT64 NOT NULL,
+			AlbumId INT64 NOT NULL,
+			AlbumTitle STRING(MAX),
+		) PRIMARY KEY (SingerId, AlbumId),
+		INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+
+		CREATE TABLE Songs (
+			SingerId INT64 NOT NULL,
+			AlbumId INT64 NOT NULL,
+			TrackId INT64 NOT NULL,
+			SongName STRING(MAX),
+		) PRIMARY KEY (SingerId, AlbumId, TrackId),
+		INTERLEAVE IN PARENT Albums ON DELETE CASCADE;
+	`)
+	assert.NilError(t, err)
+	var db spanddl.Database
+	assert.NilError(t, db.ApplyDDL(ddl))
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		DeclareRepeatedHasFunction(filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		filtering.DeclareIdent("FirstName", filtering.TypeString),
+		filtering.DeclareIdent("albums", filtering.TypeList(filtering.TypeMap(filtering.TypeString, filtering.TypeString))),
+		filtering.DeclareIdent("albums.AlbumTitle", filtering.TypeString),
+		filtering.DeclareIdent("albums.albumtitle", filtering.TypeString),
+		filtering.DeclareIdent("albums.Year", filtering.TypeInt),
+		filtering.DeclareIdent("albums.album_title", filtering.TypeString),
+		filtering.DeclareIdent("albums.label", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		filtering.DeclareIdent("albums.label.name", filtering.TypeString),
+		filtering.DeclareIdent("songs", filtering.TypeList(filtering.TypeMap(filtering.TypeString, filtering.TypeString))),
+		filtering.DeclareIdent("songs.SongName", filtering.TypeString),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		name           string
+		filter         string
+		table          spansql.ID
+		options        []TranspileOption
+		expectedSQL    string
+		expectedParams map[string]interface{}
+		errorContains  string
+		errorCode      codes.Code
+	}{
+		{
+			name:   "child table field",
+			filter: `albums.AlbumTitle = "Go"`,
+			table:  "Singers",
+			expectedSQL: `(EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Go",
+			},
+		},
+
+		{
+			name:   "child table wildcard and parent field",
+			filter: `FirstName = "Frank" AND albums.AlbumTitle = "Go*"`,
+			table:  "Singers",
+			expectedSQL: `((FirstName = @param_0) AND (EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle LIKE @param_1)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Frank",
+				"param_1": "Go%",
+			},
+		},
+
+		{
+			name:   "child table field disjunction",
+			filter: `albums.AlbumTitle = "Go" OR albums.AlbumTitle = "Come Fly with Me"`,
+			table:  "Singers",
+			expectedSQL: `((EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_0)) OR (EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_1)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Go",
+				"param_1": "Come Fly with Me",
+			},
+		},
+
+		{
+			name:   "case-insensitive column",
+			filter: `albums.albumtitle = "Go"`,
+			table:  "Singers",
+			expectedSQL: `(EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Go",
+			},
+		},
+
+		{
+			name:        "child table rows",
+			filter:      `albums:*`,
+			table:       "Singers",
+			expectedSQL: `(EXISTS (SELECT 1 FROM Albums AS c WHERE c.SingerId = Singers.SingerId))`,
+		},
+
+		{
+			name:   "negated child table field",
+			filter: `NOT albums.AlbumTitle:"Go"`,
+			table:  "Singers",
+			expectedSQL: `(NOT (EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_0)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Go",
+			},
+		},
+
+		{
+			name:   "descendant table",
+			filter: `songs.SongName = "Fly Me to the Moon"`,
+			table:  "Singers",
+			expectedSQL: `(EXISTS (SELECT 1 FROM Songs AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.SongName = @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Fly Me to the Moon",
+			},
+		},
+
+		{
+			name:          "unknown field",
+			filter:        `albums.Year > 1960`,
+			table:         "Singers",
+			errorContains: "unsupported field in filter: albums.Year",
+			errorCode:     codes.InvalidArgument,
+		},
+
+		{
+			name:   "resolved child table field",
+			filter: `FirstName = "Frank" AND albums.album_title = "Go"`,
+			table:  "Singers",
+			options: []TranspileOption{
+				WithFieldMapping(map[string]spansql.Expr{
+					"FirstName":          spansql.ID("FirstName"),
+					"albums.album_title": spansql.ID("AlbumTitle"),
+				}),
+			},
+			expectedSQL: `((FirstName = @param_0) AND (EXISTS (SELECT 1 FROM Albums AS c ` +
+				`WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @param_1)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Frank",
+				"param_1": "Go",
+			},
+		},
+
+		{
+			name:   "unresolved child table field",
+			filter: `albums.AlbumTitle = "Go"`,
+			table:  "Singers",
+			options: []TranspileOption{
+				WithFieldMapping(map[string]spansql.Expr{
+					"albums.album_title": spansql.ID("AlbumTitle"),
+				}),
+			},
+			errorContains: "unsupported field in filter: albums.AlbumTitle",
+			errorCode:     codes.InvalidArgument,
+		},
+
+		{
+			name:   "child table field resolved to unknown column",
+			filter: `albums.album_title = "Go"`,
+			table:  "Singers",
+			options: []TranspileOption{
+				WithFieldMapping(map[string]spansql.Expr{
+					"albums.album_title": spansql.ID("Title"),
+				}),
+			},
+			errorContains: "field albums.album_title resolves to unknown column Title of Albums",
+			errorCode:     codes.Internal,
+		},
+
+		{
+			name:          "nested child table field",
+			filter:        `albums.label.name = "Reprise"`,
+			table:         "Singers",
+			errorContains: "unsupported field in filter: albums.label.name",
+			errorCode:     codes.InvalidArgument,
+		},
+
+		{
+			name:          "not interleaved",
+			filter:        `albums.AlbumTitle = "Go"`,
+			table:         "Songs",
+			errorContains: "table Albums is not interleaved in Songs",
+			errorCode:     codes.Internal,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, params, err := TranspileFilter(
+				filter,
+				append(
+					tt.options,
+					WithInterleavedTable("albums", &db, tt.table, "Albums"),
+					WithInterleavedTable("songs", &db, tt.table, "Songs"),
+				)...,
+			)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				if tt.errorCode != codes.OK {
+					assert.Equal(t, tt.errorCode, status.Code(err))
+				}
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, actual.SQL())
+			assert.DeepEqual(t, tt.expectedParams, params)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package spanfiltering
+
+import (
+	"fmt"
+
+	"go.einride.tech/aip/filtering"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limit is a limit on the size of filter expressions.
+type Limit string
+
+const (
+	// LimitDepth is the limit configured with WithMaxDepth.
+	LimitDepth Limit = "depth"
+	// LimitTerms is the limit configured with WithMaxTerms.
+	LimitTerms Limit = "terms"
+	// LimitParams is the limit configured with WithMaxParams.
+	LimitParams Limit = "params"
+	// LimitWildcardTerms is the limit configured with WithMaxWildcardTerms.
+	LimitWildcardTerms Limit = "wildcard terms"
+)
+
+// LimitError is returned when a filter expression exceeds a limit.
+//
+// It converts to an InvalidArgument status with status.FromError.
+type LimitError struct {
+	// Limit is the exceeded limit.
+	Limit Limit
+	// Max is the configured maximum of the exceeded limit.
+	Max int
+}
+
+// Error implements error.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("filter exceeds the maximum %s of %d", e.Limit, e.Max)
+}
+
+// GRPCStatus returns an InvalidArgument status for the error.
+func (e *LimitError) GRPCStatus() *status.Status {
+	return status.New(codes.InvalidArgument, e.Error())
+}
+
+// checkLimits checks the depth and term limits of a filter expression.
+func (t *Transpiler) checkLimits(e *expr.Expr) error {
+	if t.options.maxDepth > 0 && exprDepth(e) > t.options.maxDepth {
+		return &LimitError{Limit: LimitDepth, Max: t.options.maxDepth}
+	}
+	if t.options.maxTerms > 0 && exprTerms(e) > t.options.maxTerms {
+		return &LimitError{Limit: LimitTerms, Max: t.options.maxTerms}
+	}
+	return nil
+}
+
+// exprDepth returns the nesting depth of an expression.
+func exprDepth(e *expr.Expr) int {
+	return exprDepthIn(e, "")
+}
+
+// exprDepthIn returns the nesting depth of an expression that is an argument of a call to the function parent.
+//
+// The parser nests chains of logical operators, so a call to the same logical operator as its parent continues the
+// chain and adds no level.
+func exprDepthIn(e *expr.Expr, parent string) int {
+	var result int
+	switch kind := e.GetExprKind().(type) {
+	case *expr.Expr_CallExpr:
+		function := kind.CallExpr.GetFunction()
+		for _, arg := range kind.CallExpr.GetArgs() {
+			result = max(result, exprDepthIn(arg, function))
+		}
+		if function == parent && isChainFunction(function) {
+			return result
+		}
+	case *expr.Expr_SelectExpr:
+		result = exprDepthIn(kind.SelectExpr.GetOperand(), "")
+	case *expr.Expr_ListExpr:
+		for _, element := range kind.ListExpr.GetElements() {
+			result = max(result, exprDepthIn(element, ""))
+		}
+	}
+	return result + 1
+}
+
+// isChainFunction reports whether function is a logical operator that chains terms.
+func isChainFunction(function string) bool {
+	switch function {
+	case filtering.FunctionAnd, filtering.FunctionOr, filtering.FunctionFuzzyAnd:
+		return true
+	}
+	return false
+}
+
+// exprTerms returns the number of terms combined with logical operators in an expression.
+func exprTerms(e *expr.Expr) int {
+	switch e.GetCallExpr().GetFunction() {
+	case filtering.FunctionAnd, filtering.FunctionOr, filtering.FunctionNot, filtering.FunctionFuzzyAnd:
+		var result int
+		for _, arg := range e.GetCallExpr().GetArgs() {
+			result += exprTerms(arg)
+		}
+		return result
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,143 @@
+package spanfiltering
+
+import (
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileFilter_limits(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("author", filtering.TypeString),
+		filtering.DeclareIdent("title", filtering.TypeString),
+		filtering.DeclareIdent("read", filtering.TypeBool),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		name     string
+		filter   string
+		option   TranspileOption
+		expected *LimitError
+	}{
+		{
+			name:   "depth within limit",
+			filter: `author = "Karin Boye" AND NOT read`,
+			option: WithMaxDepth(3),
+		},
+
+		{
+			name:     "depth exceeded",
+			filter:   `author = "Karin Boye" AND (title = "Kallocain" OR NOT read)`,
+			option:   WithMaxDepth(3),
+			expected: &LimitError{Limit: LimitDepth, Max: 3},
+		},
+
+		{
+			name:   "depth of flat AND chain within limit",
+			filter: `author = "A" AND author = "B" AND author = "C" AND author = "D" AND author = "E" AND author = "F"`,
+			option: WithMaxDepth(5),
+		},
+
+		{
+			name:   "depth of flat OR chain within limit",
+			filter: `author = "A" OR author = "B" OR author = "C" OR author = "D" OR author = "E" OR author = "F"`,
+			option: WithMaxDepth(5),
+		},
+
+		{
+			name:     "depth of nested chains exceeded",
+			filter:   `author = "A" AND (title = "B" OR (author = "C" AND NOT (title = "D" OR read)))`,
+			option:   WithMaxDepth(5),
+			expected: &LimitError{Limit: LimitDepth, Max: 5},
+		},
+
+		{
+			name:   "terms within limit",
+			filter: `author = "Karin Boye" AND (title = "Kallocain" OR NOT read)`,
+			option: WithMaxTerms(3),
+		},
+
+		{
+			name:     "terms exceeded",
+			filter:   `author = "A" OR author = "B" OR author = "C" OR author = "D"`,
+			option:   WithMaxTerms(3),
+			expected: &LimitError{Limit: LimitTerms, Max: 3},
+		},
+
+		{
+			name:   "params within limit",
+			filter: `author = "A" OR author = "B" OR author = "C" OR author = "D"`,
+			option: WithMaxParams(1),
+		},
+
+		{
+			name:     "params exceeded",
+			filter:   `author = "Karin Boye" AND title = "Kallocain"`,
+			option:   WithMaxParams(1),
+			expected: &LimitError{Limit: LimitParams, Max: 1},
+		},
+
+		{
+			name:   "wildcard terms within limit",
+			filter: `author = "Karin*" AND title = "Kallocain"`,
+			option: WithMaxWildcardTerms(1),
+		},
+
+		{
+			name:     "wildcard terms exceeded",
+			filter:   `author = "Karin*" AND title = "*cain"`,
+			option:   WithMaxWildcardTerms(1),
+			expected: &LimitError{Limit: LimitWildcardTerms, Max: 1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			_, _, err = TranspileFilter(filter, tt.option)
+			if tt.expected == nil {
+				assert.NilError(t, err)
+				return
+			}
+			var limitErr *LimitError
+			assert.Assert(t, errors.As(err, &limitErr))
+			assert.DeepEqual(t, tt.expected, limitErr)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+func TestTranspileFilter_maxParamsInFunction(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("title", filtering.TypeString),
+		filtering.DeclareFunction("similar", filtering.NewFunctionOverload(
+			"similar_string", filtering.TypeBool, filtering.TypeString, filtering.TypeString,
+		)),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `similar(title, "Kallocain")`}, declarations)
+	assert.NilError(t, err)
+	_, _, err = TranspileFilter(
+		filter,
+		WithMaxParams(1),
+		WithFunction("similar", func(args []spansql.Expr, param ParamAllocator) (spansql.Expr, error) {
+			return spansql.ComparisonOp{
+				Op:  spansql.Ge,
+				LHS: spansql.Func{Name: "SIMILARITY", Args: args},
+				RHS: param(0.5),
+			}, nil
+		}),
+	)
+	var limitErr *LimitError
+	assert.Assert(t, errors.As(err, &limitErr))
+	assert.DeepEqual(t, &LimitError{Limit: LimitParams, Max: 1}, limitErr)
+}
@@ -0,0 +1,27 @@
+package spanfiltering
+
+import (
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/spanner-aip/internal/postgresql"
+)
+
+// TranspileFilterPostgreSQL transpiles a parsed AIP filter expression to a PostgreSQL-dialect SQL expression, for
+// databases using the Spanner PostgreSQL interface, and parameters used in the expression.
+//
+// Parameters are positional placeholders $1, $2, ..., keyed p1, p2, ... in the parameter map, as expected by
+// spanner.Statement. The parameter map is nil if the expression does not contain any parameters.
+func TranspileFilterPostgreSQL(
+	filter filtering.Filter,
+	options ...TranspileOption,
+) (string, map[string]interface{}, error) {
+	expr, params, err := TranspileFilter(filter, options...)
+	if err != nil {
+		return "", nil, err
+	}
+	printer := postgresql.NewPrinter(params)
+	sql, err := printer.Expr(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	return sql, printer.Params(), nil
+}
@@ -0,0 +1,175 @@
+package spanfiltering
+
+import (
+	"testing"
+	"time"
+
+	"go.einride.tech/aip/filtering"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileFilterPostgreSQL(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		DeclareTimeFunctions(),
+		DeclareEndsWithFunction(),
+		DeclareEqualsIgnoreCaseFunction(),
+		DeclareSearchFunction(),
+		filtering.DeclareIdent("author", filtering.TypeString),
+		filtering.DeclareIdent("read", filtering.TypeBool),
+		filtering.DeclareIdent("pages", filtering.TypeInt),
+		filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+		filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+		filtering.DeclareIdent("author_tokens", filtering.TypeString),
+		filtering.DeclareIdent("config", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		filtering.DeclareIdent("config.max_weight", filtering.TypeFloat),
+		filtering.DeclareIdent("Order", filtering.TypeInt),
+		DeclareSizeFunction(filtering.TypeString),
+		filtering.DeclareIdent(
+			"line_items",
+			filtering.TypeList(filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		),
+		filtering.DeclareIdent("line_items.weight", filtering.TypeFloat),
+	)
+	assert.NilError(t, err)
+	now := time.Date(2021, 2, 14, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name           string
+		filter         string
+		options        []TranspileOption
+		expectedSQL    string
+		expectedParams map[string]interface{}
+	}{
+		{
+			name:        "flag",
+			filter:      `read`,
+			expectedSQL: `read`,
+		},
+
+		{
+			name:        "comparisons",
+			filter:      `author != "Karin Boye" AND NOT read`,
+			expectedSQL: `((author <> $1) AND (NOT read))`,
+			expectedParams: map[string]interface{}{
+				"p1": "Karin Boye",
+			},
+		},
+
+		{
+			name:        "quoted identifier",
+			filter:      `Order > 1`,
+			expectedSQL: `("Order" > $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": int64(1),
+			},
+		},
+
+		{
+			name:        "wildcard",
+			filter:      `author = "50%*"`,
+			expectedSQL: `(author LIKE $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": `50\%%`,
+			},
+		},
+
+		{
+			name:        "ends with",
+			filter:      `endsWith(author, "_Boye")`,
+			expectedSQL: `(author LIKE $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": `%\_Boye`,
+			},
+		},
+
+		{
+			name:        "equals ignore case",
+			filter:      `equalsIgnoreCase(author, "karin boye")`,
+			expectedSQL: `(author ILIKE $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": "karin boye",
+			},
+		},
+
+		{
+			name:        "repeated has",
+			filter:      `tags:"fiction"`,
+			expectedSQL: `($1 = ANY(tags))`,
+			expectedParams: map[string]interface{}{
+				"p1": "fiction",
+			},
+		},
+
+		{
+			name:        "equality disjunction",
+			filter:      `pages = 100 OR pages = 200`,
+			expectedSQL: `(pages = ANY($1))`,
+			expectedParams: map[string]interface{}{
+				"p1": []int64{100, 200},
+			},
+		},
+
+		{
+			name:        "relative time",
+			filter:      `create_time > timestampSub(now(), duration("24h"))`,
+			options:     []TranspileOption{WithNow(now)},
+			expectedSQL: `(create_time > (($1) - $2 * INTERVAL '1 microsecond'))`,
+			expectedParams: map[string]interface{}{
+				"p1": now,
+				"p2": (24 * time.Hour).Microseconds(),
+			},
+		},
+
+		{
+			name:        "JSON field",
+			filter:      `config.max_weight > 10.5`,
+			options:     []TranspileOption{WithJSONColumns("config")},
+			expectedSQL: `(CAST(config->>'max_weight' AS double precision) > $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": 10.5,
+			},
+		},
+
+		{
+			name:        "size",
+			filter:      `size(tags) > 2`,
+			expectedSQL: `((coalesce(array_length(tags, 1), 0)) > $1)`,
+			expectedParams: map[string]interface{}{
+				"p1": int64(2),
+			},
+		},
+
+		{
+			name:    "JSON array element",
+			filter:  `line_items.weight > 10.5`,
+			options: []TranspileOption{WithJSONArrayColumns("line_items")},
+			expectedSQL: `(EXISTS (SELECT 1 FROM unnest(line_items) AS e ` +
+				`WHERE CAST(e->>'weight' AS double precision) > $1))`,
+			expectedParams: map[string]interface{}{
+				"p1": 10.5,
+			},
+		},
+
+		{
+			name:        "search",
+			filter:      `search(author_tokens, "karin")`,
+			options:     []TranspileOption{WithSearchEnhanceQuery()},
+			expectedSQL: `(spanner.search(author_tokens, $1, enhance_query => $2))`,
+			expectedParams: map[string]interface{}{
+				"p1": "karin",
+				"p2": true,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actualSQL, actualParams, err := TranspileFilterPostgreSQL(filter, tt.options...)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, actualSQL)
+			assert.DeepEqual(t, tt.expectedParams, actualParams)
+		})
+	}
+}
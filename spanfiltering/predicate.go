@@ -0,0 +1,920 @@
+package spanfiltering
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+)
+
+// Row is a row that can be matched by a Predicate, e.g. a generated row type.
+type Row interface {
+	// Mutate returns the table name, column names and column values of the row.
+	Mutate() (string, []string, []interface{})
+}
+
+// Predicate is a filter expression compiled to an in-memory predicate over column values.
+//
+// A predicate is compiled from the transpiled SQL expression, and evaluates it with the same semantics as Spanner:
+// comparisons with NULL are NULL, logical operators use three-valued logic, and a row only matches when the
+// expression is TRUE.
+type Predicate struct {
+	expr spansql.BoolExpr
+	eval evalFunc
+}
+
+// CompilePredicate compiles a parsed AIP filter expression to an in-memory predicate.
+//
+// Expressions that have no in-memory equivalent, such as full-text search functions and custom functions, are
+// rejected with an error.
+func CompilePredicate(filter filtering.Filter, options ...TranspileOption) (*Predicate, error) {
+	sqlExpr, params, err := TranspileFilter(filter, options...)
+	if err != nil {
+		return nil, err
+	}
+	c := predicateCompiler{params: params}
+	eval, err := c.compile(sqlExpr)
+	if err != nil {
+		return nil, err
+	}
+	return &Predicate{expr: sqlExpr, eval: eval}, nil
+}
+
+// RowPredicate returns a function that reports whether a row matches the predicate.
+// Rows that can't be evaluated, for example due to column values of unexpected types, don't match.
+func RowPredicate[R Row](predicate *Predicate) func(R) bool {
+	return func(row R) bool {
+		ok, err := predicate.MatchRow(row)
+		return err == nil && ok
+	}
+}
+
+// Expr returns the SQL expression that the predicate was compiled from.
+func (p *Predicate) Expr() spansql.BoolExpr {
+	return p.expr
+}
+
+// Match reports whether the column values match the predicate.
+// Column values can be Go values or spanner.Null* values. Missing columns are NULL.
+func (p *Predicate) Match(columns map[string]interface{}) (bool, error) {
+	value, err := p.eval(columns)
+	if err != nil {
+		return false, err
+	}
+	switch value := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return value, nil
+	default:
+		return false, fmt.Errorf("filter evaluated to non-bool value %v", value)
+	}
+}
+
+// MatchRow reports whether the row matches the predicate.
+func (p *Predicate) MatchRow(row Row) (bool, error) {
+	_, columnNames, values := row.Mutate()
+	if len(columnNames) != len(values) {
+		return false, fmt.Errorf("row has %d columns and %d values", len(columnNames), len(values))
+	}
+	columns := make(map[string]interface{}, len(columnNames))
+	for i, columnName := range columnNames {
+		columns[columnName] = values[i]
+	}
+	return p.Match(columns)
+}
+
+type evalFunc func(columns map[string]interface{}) (interface{}, error)
+
+type predicateCompiler struct {
+	params map[string]interface{}
+}
+
+func (c *predicateCompiler) compile(e spansql.Expr) (evalFunc, error) {
+	switch e := e.(type) {
+	case spansql.Paren:
+		return c.compile(e.Expr)
+	case spansql.ID:
+		return func(columns map[string]interface{}) (interface{}, error) {
+			return columnValue(columns, string(e))
+		}, nil
+	case spansql.Param:
+		value, ok := c.params[string(e)]
+		if !ok {
+			return nil, fmt.Errorf("unbound param %s in predicate", e.SQL())
+		}
+		normalized, err := normalizeValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return constant(normalized), nil
+	case spansql.BoolLiteral:
+		return constant(bool(e)), nil
+	case spansql.IntegerLiteral:
+		return constant(int64(e)), nil
+	case spansql.FloatLiteral:
+		return constant(float64(e)), nil
+	case spansql.StringLiteral:
+		return constant(string(e)), nil
+	case spansql.NullLiteral:
+		return constant(nil), nil
+	case spansql.LogicalOp:
+		return c.compileLogicalOp(e)
+	case spansql.ComparisonOp:
+		return c.compileComparisonOp(e)
+	case spansql.IsOp:
+		return c.compileIsOp(e)
+	case spansql.InOp:
+		return c.compileInOp(e)
+	case spansql.Func:
+		return c.compileFunc(e)
+	case spansql.ExistsOp:
+		return c.compileExistsOp(e)
+	default:
+		return nil, fmt.Errorf("unsupported expression in predicate: %s", e.SQL())
+	}
+}
+
+func constant(value interface{}) evalFunc {
+	return func(map[string]interface{}) (interface{}, error) {
+		return value, nil
+	}
+}
+
+func (c *predicateCompiler) compileLogicalOp(e spansql.LogicalOp) (evalFunc, error) {
+	rhs, err := c.compile(e.RHS)
+	if err != nil {
+		return nil, err
+	}
+	if e.Op == spansql.Not {
+		return func(columns map[string]interface{}) (interface{}, error) {
+			value, err := evalBool(rhs, columns)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			return !*value, nil
+		}, nil
+	}
+	lhs, err := c.compile(e.LHS)
+	if err != nil {
+		return nil, err
+	}
+	// The absorbing value short-circuits the operator: FALSE for AND and TRUE for OR.
+	absorbing := e.Op == spansql.Or
+	return func(columns map[string]interface{}) (interface{}, error) {
+		lhsValue, err := evalBool(lhs, columns)
+		if err != nil {
+			return nil, err
+		}
+		if lhsValue != nil && *lhsValue == absorbing {
+			return absorbing, nil
+		}
+		rhsValue, err := evalBool(rhs, columns)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case rhsValue != nil && *rhsValue == absorbing:
+			return absorbing, nil
+		case lhsValue == nil || rhsValue == nil:
+			return nil, nil
+		default:
+			return !absorbing, nil
+		}
+	}, nil
+}
+
+func evalBool(eval evalFunc, columns map[string]interface{}) (*bool, error) {
+	value, err := eval(columns)
+	if err != nil {
+		return nil, err
+	}
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return &value, nil
+	default:
+		return nil, fmt.Errorf("expected bool value, got %T", value)
+	}
+}
+
+func (c *predicateCompiler) compileComparisonOp(e spansql.ComparisonOp) (evalFunc, error) {
+	lhs, err := c.compile(e.LHS)
+	if err != nil {
+		return nil, err
+	}
+	if e.Op == spansql.Like || e.Op == spansql.NotLike {
+		pattern, err := c.compilePattern(e.RHS, likeRegexp)
+		if err != nil {
+			return nil, err
+		}
+		return func(columns map[string]interface{}) (interface{}, error) {
+			value, err := evalString(lhs, columns)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			re, err := pattern(columns)
+			if err != nil || re == nil {
+				return nil, err
+			}
+			return re.MatchString(*value) == (e.Op == spansql.Like), nil
+		}, nil
+	}
+	rhs, err := c.compile(e.RHS)
+	if err != nil {
+		return nil, err
+	}
+	var test func(int) bool
+	switch e.Op {
+	case spansql.Lt:
+		test = func(cmp int) bool { return cmp < 0 }
+	case spansql.Le:
+		test = func(cmp int) bool { return cmp <= 0 }
+	case spansql.Gt:
+		test = func(cmp int) bool { return cmp > 0 }
+	case spansql.Ge:
+		test = func(cmp int) bool { return cmp >= 0 }
+	case spansql.Eq:
+		test = func(cmp int) bool { return cmp == 0 }
+	case spansql.Ne:
+		test = func(cmp int) bool { return cmp != 0 }
+	default:
+		return nil, fmt.Errorf("unsupported comparison in predicate: %s", e.SQL())
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		lhsValue, err := lhs(columns)
+		if err != nil {
+			return nil, err
+		}
+		rhsValue, err := rhs(columns)
+		if err != nil {
+			return nil, err
+		}
+		if lhsValue == nil || rhsValue == nil {
+			return nil, nil
+		}
+		cmp, err := compareValues(lhsValue, rhsValue)
+		if err != nil {
+			return nil, err
+		}
+		return test(cmp), nil
+	}, nil
+}
+
+// compilePattern compiles a pattern argument to a regexp.
+// Constant patterns are compiled once, and invalid constant patterns are rejected up front.
+func (c *predicateCompiler) compilePattern(
+	e spansql.Expr,
+	compile func(string) (*regexp.Regexp, error),
+) (func(map[string]interface{}) (*regexp.Regexp, error), error) {
+	eval, err := c.compile(e)
+	if err != nil {
+		return nil, err
+	}
+	switch e.(type) {
+	case spansql.Param, spansql.StringLiteral:
+		value, err := evalString(eval, nil)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return func(map[string]interface{}) (*regexp.Regexp, error) { return nil, nil }, nil
+		}
+		re, err := compile(*value)
+		if err != nil {
+			return nil, err
+		}
+		return func(map[string]interface{}) (*regexp.Regexp, error) { return re, nil }, nil
+	}
+	return func(columns map[string]interface{}) (*regexp.Regexp, error) {
+		value, err := evalString(eval, columns)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return compile(*value)
+	}, nil
+}
+
+// likeRegexp converts a LIKE pattern to an equivalent regexp.
+// A backslash escapes the following character, % matches any sequence of characters and _ matches one character.
+func likeRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch r {
+		case '\\':
+			if i == len(pattern) {
+				return nil, fmt.Errorf("LIKE pattern ends with a backslash: %q", pattern)
+			}
+			escaped, size := utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			b.WriteString(regexp.QuoteMeta(string(escaped)))
+		case '%':
+			b.WriteString(`.*`)
+		case '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
+
+func evalString(eval evalFunc, columns map[string]interface{}) (*string, error) {
+	value, err := eval(columns)
+	if err != nil {
+		return nil, err
+	}
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return &value, nil
+	default:
+		return nil, fmt.Errorf("expected string value, got %T", value)
+	}
+}
+
+func (c *predicateCompiler) compileIsOp(e spansql.IsOp) (evalFunc, error) {
+	lhs, err := c.compile(e.LHS)
+	if err != nil {
+		return nil, err
+	}
+	var test func(interface{}) bool
+	switch e.RHS {
+	case spansql.Null:
+		test = func(value interface{}) bool { return value == nil }
+	case spansql.True, spansql.False:
+		expected := e.RHS == spansql.True
+		test = func(value interface{}) bool { return value == expected }
+	default:
+		return nil, fmt.Errorf("unsupported IS expression in predicate: %s", e.SQL())
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		value, err := lhs(columns)
+		if err != nil {
+			return nil, err
+		}
+		return test(value) != e.Neg, nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileInOp(e spansql.InOp) (evalFunc, error) {
+	lhs, err := c.compile(e.LHS)
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]evalFunc, 0, len(e.RHS))
+	for _, rhs := range e.RHS {
+		element, err := c.compile(rhs)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	if e.Unnest && len(elements) != 1 {
+		return nil, fmt.Errorf("unsupported IN UNNEST expression in predicate: %s", e.SQL())
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		var values []interface{}
+		if e.Unnest {
+			array, err := elements[0](columns)
+			if err != nil {
+				return nil, err
+			}
+			// UNNEST of a NULL array is empty.
+			if array == nil {
+				return e.Neg, nil
+			}
+			var ok bool
+			if values, ok = array.([]interface{}); !ok {
+				return nil, fmt.Errorf("expected array value, got %T", array)
+			}
+			if len(values) == 0 {
+				return e.Neg, nil
+			}
+		} else {
+			values = make([]interface{}, 0, len(elements))
+			for _, element := range elements {
+				value, err := element(columns)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, value)
+			}
+		}
+		lhsValue, err := lhs(columns)
+		if err != nil || lhsValue == nil {
+			return nil, err
+		}
+		// As in SQL, a value that isn't found among values containing NULL is neither IN nor NOT IN the values.
+		var hasNull bool
+		for _, value := range values {
+			if value == nil {
+				hasNull = true
+				continue
+			}
+			cmp, err := compareValues(lhsValue, value)
+			if err != nil {
+				return nil, err
+			}
+			if cmp == 0 {
+				return !e.Neg, nil
+			}
+		}
+		if hasNull {
+			return nil, nil
+		}
+		return e.Neg, nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileFunc(e spansql.Func) (evalFunc, error) {
+	switch e.Name {
+	case "STARTS_WITH", "ENDS_WITH":
+		return c.compileStringFunc(e, 2, func(args []string) interface{} {
+			if e.Name == "STARTS_WITH" {
+				return strings.HasPrefix(args[0], args[1])
+			}
+			return strings.HasSuffix(args[0], args[1])
+		})
+	case "STRPOS":
+		return c.compileStringFunc(e, 2, func(args []string) interface{} {
+			i := strings.Index(args[0], args[1])
+			if i < 0 {
+				return int64(0)
+			}
+			return int64(utf8.RuneCountInString(args[0][:i]) + 1)
+		})
+	case "LOWER":
+		return c.compileStringFunc(e, 1, func(args []string) interface{} {
+			return strings.ToLower(args[0])
+		})
+	case "REGEXP_CONTAINS":
+		return c.compileRegexpContains(e)
+	case "TIMESTAMP_ADD", "TIMESTAMP_SUB":
+		return c.compileTimestampArithmetic(e)
+	case "JSON_VALUE":
+		return c.compileJSONValue(e)
+	case "CAST":
+		return c.compileCast(e)
+	case "ARRAY_LENGTH":
+		return c.compileArrayLength(e)
+	case "COALESCE":
+		return c.compileCoalesce(e)
+	default:
+		return nil, fmt.Errorf("unsupported function in predicate: %s", e.Name)
+	}
+}
+
+func (c *predicateCompiler) compileArrayLength(e spansql.Func) (evalFunc, error) {
+	if len(e.Args) != 1 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	array, err := c.compile(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		value, err := array(columns)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array value, got %T", value)
+		}
+		return int64(len(values)), nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileCoalesce(e spansql.Func) (evalFunc, error) {
+	args := make([]evalFunc, 0, len(e.Args))
+	for _, arg := range e.Args {
+		eval, err := c.compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, eval)
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		for _, arg := range args {
+			value, err := arg(columns)
+			if err != nil || value != nil {
+				return value, err
+			}
+		}
+		return nil, nil
+	}, nil
+}
+
+// compileExistsOp compiles EXISTS subqueries over the elements of an array, as in
+// `EXISTS (SELECT 1 FROM UNNEST(array) AS e WHERE condition)`.
+func (c *predicateCompiler) compileExistsOp(e spansql.ExistsOp) (evalFunc, error) {
+	query := e.Subquery
+	if len(query.Select.From) != 1 || query.Select.Where == nil || query.Limit != nil || len(query.Order) != 0 {
+		return nil, fmt.Errorf("unsupported subquery in predicate: %s", e.SQL())
+	}
+	from, ok := query.Select.From[0].(spansql.SelectFromUnnest)
+	if !ok || from.Alias == "" {
+		return nil, fmt.Errorf("unsupported subquery in predicate: %s", e.SQL())
+	}
+	array, err := c.compile(from.Expr)
+	if err != nil {
+		return nil, err
+	}
+	condition, err := c.compile(query.Select.Where)
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		value, err := array(columns)
+		if err != nil || value == nil {
+			return false, err
+		}
+		elements, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array value, got %T", value)
+		}
+		scope := make(map[string]interface{}, len(columns)+1)
+		for column, value := range columns {
+			scope[column] = value
+		}
+		for _, element := range elements {
+			scope[string(from.Alias)] = element
+			match, err := evalBool(condition, scope)
+			if err != nil {
+				return nil, err
+			}
+			if match != nil && *match {
+				return true, nil
+			}
+		}
+		return false, nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileStringFunc(
+	e spansql.Func,
+	numArgs int,
+	fn func(args []string) interface{},
+) (evalFunc, error) {
+	if len(e.Args) != numArgs {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	args := make([]evalFunc, 0, len(e.Args))
+	for _, arg := range e.Args {
+		eval, err := c.compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, eval)
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		values := make([]string, 0, len(args))
+		for _, arg := range args {
+			value, err := evalString(arg, columns)
+			if err != nil || value == nil {
+				return nil, err
+			}
+			values = append(values, *value)
+		}
+		return fn(values), nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileRegexpContains(e spansql.Func) (evalFunc, error) {
+	if len(e.Args) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	value, err := c.compile(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := c.compilePattern(e.Args[1], regexp.Compile)
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		s, err := evalString(value, columns)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		re, err := pattern(columns)
+		if err != nil || re == nil {
+			return nil, err
+		}
+		return re.MatchString(*s), nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileTimestampArithmetic(e spansql.Func) (evalFunc, error) {
+	if len(e.Args) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	interval, ok := e.Args[1].(spansql.IntervalExpr)
+	if !ok {
+		return nil, fmt.Errorf("unsupported argument to %s in predicate: %s", e.Name, e.Args[1].SQL())
+	}
+	var unit time.Duration
+	switch strings.ToUpper(interval.DatePart) {
+	case "NANOSECOND":
+		unit = time.Nanosecond
+	case "MICROSECOND":
+		unit = time.Microsecond
+	case "MILLISECOND":
+		unit = time.Millisecond
+	case "SECOND":
+		unit = time.Second
+	case "MINUTE":
+		unit = time.Minute
+	case "HOUR":
+		unit = time.Hour
+	case "DAY":
+		unit = 24 * time.Hour
+	default:
+		return nil, fmt.Errorf("unsupported interval in predicate: %s", interval.SQL())
+	}
+	if e.Name == "TIMESTAMP_SUB" {
+		unit = -unit
+	}
+	timestamp, err := c.compile(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	amount, err := c.compile(interval.Expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		timestampValue, err := timestamp(columns)
+		if err != nil || timestampValue == nil {
+			return nil, err
+		}
+		amountValue, err := amount(columns)
+		if err != nil || amountValue == nil {
+			return nil, err
+		}
+		t, ok := timestampValue.(time.Time)
+		if !ok {
+			return nil, fmt.Errorf("expected timestamp value, got %T", timestampValue)
+		}
+		n, ok := amountValue.(int64)
+		if !ok {
+			return nil, fmt.Errorf("expected int64 interval, got %T", amountValue)
+		}
+		return t.Add(time.Duration(n) * unit), nil
+	}, nil
+}
+
+func (c *predicateCompiler) compileJSONValue(e spansql.Func) (evalFunc, error) {
+	if len(e.Args) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	jsonPath, ok := e.Args[1].(spansql.StringLiteral)
+	if !ok || !strings.HasPrefix(string(jsonPath), "$.") {
+		return nil, fmt.Errorf("unsupported JSON path in predicate: %s", e.Args[1].SQL())
+	}
+	path := strings.Split(strings.TrimPrefix(string(jsonPath), "$."), ".")
+	document, err := c.compile(e.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		value, err := document(columns)
+		if err != nil || value == nil {
+			return nil, err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var node interface{}
+		if err := decoder.Decode(&node); err != nil {
+			return nil, err
+		}
+		for _, field := range path {
+			object, ok := node.(map[string]interface{})
+			if !ok {
+				return nil, nil
+			}
+			node = object[field]
+		}
+		// As JSON_VALUE, return scalars as strings, and NULL for JSON nulls, objects and arrays.
+		switch node := node.(type) {
+		case string:
+			return node, nil
+		case json.Number:
+			return node.String(), nil
+		case bool:
+			return strconv.FormatBool(node), nil
+		default:
+			return nil, nil
+		}
+	}, nil
+}
+
+func (c *predicateCompiler) compileCast(e spansql.Func) (evalFunc, error) {
+	if len(e.Args) != 1 {
+		return nil, fmt.Errorf("unexpected number of arguments to %s in predicate: %d", e.Name, len(e.Args))
+	}
+	typedExpr, ok := e.Args[0].(spansql.TypedExpr)
+	if !ok || typedExpr.Type.Array {
+		return nil, fmt.Errorf("unsupported CAST in predicate: %s", e.SQL())
+	}
+	var cast func(string) (interface{}, error)
+	switch typedExpr.Type.Base {
+	case spansql.String:
+		cast = func(s string) (interface{}, error) { return s, nil }
+	case spansql.Int64:
+		cast = func(s string) (interface{}, error) { return strconv.ParseInt(s, 10, 64) }
+	case spansql.Float64:
+		cast = func(s string) (interface{}, error) { return strconv.ParseFloat(s, 64) }
+	case spansql.Bool:
+		cast = func(s string) (interface{}, error) { return strconv.ParseBool(strings.ToLower(s)) }
+	case spansql.Timestamp:
+		cast = func(s string) (interface{}, error) { return time.Parse(time.RFC3339Nano, s) }
+	case spansql.Date:
+		cast = func(s string) (interface{}, error) { return civil.ParseDate(s) }
+	default:
+		return nil, fmt.Errorf("unsupported CAST in predicate: %s", e.SQL())
+	}
+	value, err := c.compile(typedExpr.Expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(columns map[string]interface{}) (interface{}, error) {
+		s, err := evalString(value, columns)
+		if err != nil || s == nil {
+			return nil, err
+		}
+		result, err := cast(*s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAST in predicate: %w", err)
+		}
+		return result, nil
+	}, nil
+}
+
+func columnValue(columns map[string]interface{}, column string) (interface{}, error) {
+	value, ok := columns[column]
+	if !ok {
+		// Column names are case-insensitive.
+		for name, candidate := range columns {
+			if strings.EqualFold(name, column) {
+				value = candidate
+				break
+			}
+		}
+	}
+	return normalizeValue(value)
+}
+
+// normalizeValue converts a column or param value to one of the value types used in predicates:
+// nil for NULL, bool, int64, float64, string, []byte, time.Time, civil.Date, *big.Rat, a decoded JSON value, or
+// []interface{} for arrays.
+func normalizeValue(value interface{}) (interface{}, error) {
+	if nullable, ok := value.(spanner.NullableValue); ok && nullable.IsNull() {
+		return nil, nil
+	}
+	switch value := value.(type) {
+	case nil, bool, int64, float64, string, []byte, time.Time, civil.Date:
+		return value, nil
+	case int:
+		return int64(value), nil
+	case int32:
+		return int64(value), nil
+	case float32:
+		return float64(value), nil
+	case big.Rat:
+		return &value, nil
+	case *big.Rat:
+		return value, nil
+	case spanner.NullString:
+		return value.StringVal, nil
+	case spanner.NullInt64:
+		return value.Int64, nil
+	case spanner.NullFloat64:
+		return value.Float64, nil
+	case spanner.NullFloat32:
+		return float64(value.Float32), nil
+	case spanner.NullBool:
+		return value.Bool, nil
+	case spanner.NullTime:
+		return value.Time, nil
+	case spanner.NullDate:
+		return value.Date, nil
+	case spanner.NullNumeric:
+		return &value.Numeric, nil
+	case spanner.NullJSON:
+		return value.Value, nil
+	}
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Slice {
+		if v.IsNil() {
+			return nil, nil
+		}
+		values := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			element, err := normalizeValue(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, element)
+		}
+		return values, nil
+	}
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		// Enum values.
+		return v.Int(), nil
+	case reflect.Map, reflect.Struct, reflect.Pointer:
+		// JSON values.
+		return value, nil
+	}
+	return nil, fmt.Errorf("unsupported value in predicate: %T", value)
+}
+
+// compareValues compares two non-NULL values of comparable types.
+func compareValues(lhs, rhs interface{}) (int, error) {
+	switch lhs := lhs.(type) {
+	case bool:
+		if rhs, ok := rhs.(bool); ok {
+			switch {
+			case lhs == rhs:
+				return 0, nil
+			case rhs:
+				return -1, nil
+			default:
+				return 1, nil
+			}
+		}
+	case int64:
+		switch rhs := rhs.(type) {
+		case int64:
+			return compareOrdered(lhs, rhs), nil
+		case float64:
+			return compareOrdered(float64(lhs), rhs), nil
+		case *big.Rat:
+			return new(big.Rat).SetInt64(lhs).Cmp(rhs), nil
+		}
+	case float64:
+		switch rhs := rhs.(type) {
+		case int64:
+			return compareOrdered(lhs, float64(rhs)), nil
+		case float64:
+			return compareOrdered(lhs, rhs), nil
+		}
+	case *big.Rat:
+		switch rhs := rhs.(type) {
+		case int64:
+			return lhs.Cmp(new(big.Rat).SetInt64(rhs)), nil
+		case *big.Rat:
+			return lhs.Cmp(rhs), nil
+		}
+	case string:
+		if rhs, ok := rhs.(string); ok {
+			return strings.Compare(lhs, rhs), nil
+		}
+	case []byte:
+		if rhs, ok := rhs.([]byte); ok {
+			return bytes.Compare(lhs, rhs), nil
+		}
+	case time.Time:
+		if rhs, ok := rhs.(time.Time); ok {
+			return lhs.Compare(rhs), nil
+		}
+	case civil.Date:
+		if rhs, ok := rhs.(civil.Date); ok {
+			return lhs.Compare(rhs), nil
+		}
+	}
+	return 0, fmt.Errorf("can't compare values of types %T and %T", lhs, rhs)
+}
+
+func compareOrdered[T int64 | float64](lhs, rhs T) int {
+	switch {
+	case lhs < rhs:
+		return -1
+	case lhs > rhs:
+		return 1
+	default:
+		return 0
+	}
+}
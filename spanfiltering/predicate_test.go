@@ -0,0 +1,241 @@
+package spanfiltering
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	"gotest.tools/v3/assert"
+)
+
+func TestCompilePredicate(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2021, 2, 14, 12, 0, 0, 0, time.UTC)
+	lineItemType := filtering.TypeMap(filtering.TypeString, filtering.TypeString)
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		DeclareTimeFunctions(),
+		DeclareDateFunctions(),
+		DeclareStartsWithFunction(),
+		DeclareContainsFunction(),
+		DeclareMatchesFunction(),
+		DeclareEqualsIgnoreCaseFunction(),
+		DeclareSizeFunction(filtering.TypeString),
+		DeclareRepeatedHasFunction(filtering.TypeString, lineItemType),
+		filtering.DeclareIdent("line_items", filtering.TypeList(lineItemType)),
+		filtering.DeclareIdent("line_items.title", filtering.TypeString),
+		filtering.DeclareIdent("line_items.weight", filtering.TypeFloat),
+		filtering.DeclareIdent("author", filtering.TypeString),
+		filtering.DeclareIdent("title", filtering.TypeString),
+		filtering.DeclareIdent("subtitle", filtering.TypeString),
+		filtering.DeclareIdent("literal", filtering.TypeString),
+		filtering.DeclareIdent("read", filtering.TypeBool),
+		filtering.DeclareIdent("pages", filtering.TypeInt),
+		filtering.DeclareIdent("rating", filtering.TypeFloat),
+		filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+		filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+		filtering.DeclareIdent("publish_date", TypeDate),
+		filtering.DeclareIdent("config", filtering.TypeMap(filtering.TypeString, filtering.TypeString)),
+		filtering.DeclareIdent("config.max_weight", filtering.TypeFloat),
+		filtering.DeclareEnumIdent("example_enum", syntaxv1.Enum(0).Type()),
+	)
+	assert.NilError(t, err)
+	columns := map[string]interface{}{
+		"author":       spanner.NullString{StringVal: "Karin Boye", Valid: true},
+		"title":        spanner.NullString{},
+		"read":         true,
+		"pages":        spanner.NullInt64{Int64: 224, Valid: true},
+		"rating":       4.5,
+		"tags":         []string{"fiction", "dystopia"},
+		"create_time":  spanner.NullTime{Time: now.Add(-time.Hour), Valid: true},
+		"publish_date": civil.Date{Year: 1940, Month: 1, Day: 1},
+		"config":       spanner.NullJSON{Value: map[string]interface{}{"max_weight": 10.5}, Valid: true},
+		"example_enum": int64(syntaxv1.Enum_ENUM_ONE),
+		"literal":      spanner.NullString{StringVal: "50%_off", Valid: true},
+		"line_items": []spanner.NullJSON{
+			{Value: map[string]interface{}{"title": "pallet", "weight": 12.5}, Valid: true},
+			{Value: map[string]interface{}{"title": "parcel", "weight": 8}, Valid: true},
+		},
+	}
+	for _, tt := range []struct {
+		name          string
+		filter        string
+		options       []TranspileOption
+		expected      bool
+		errorContains string
+	}{
+		{name: "flag", filter: `read`, expected: true},
+		{name: "negated flag", filter: `NOT read`, expected: false},
+		{name: "string equality", filter: `author = "Karin Boye"`, expected: true},
+		{name: "string inequality", filter: `author != "Karin Boye"`, expected: false},
+		{name: "leading wildcard", filter: `author = "*Boye"`, expected: true},
+		{name: "trailing wildcard", filter: `author = "Kar*"`, expected: true},
+		{name: "wildcard mismatch", filter: `author = "Boye*"`, expected: false},
+		{name: "integer comparison", filter: `pages > 200`, expected: true},
+		{name: "float comparison", filter: `rating >= 4.5`, expected: true},
+		{name: "float upper bound", filter: `rating < 5.0`, expected: true},
+		{name: "repeated has", filter: `tags:"fiction"`, expected: true},
+		{name: "repeated has mismatch", filter: `tags:"poetry"`, expected: false},
+		{name: "equality disjunction", filter: `pages = 100 OR pages = 224`, expected: true},
+		{name: "enum equality", filter: `example_enum = ENUM_ONE`, expected: true},
+		{name: "enum inequality", filter: `example_enum = ENUM_TWO`, expected: false},
+		{name: "timestamp", filter: `create_time < timestamp("2021-02-14T12:00:00Z")`, expected: true},
+		{
+			name:     "relative time",
+			filter:   `create_time > timestampSub(now(), duration("24h"))`,
+			options:  []TranspileOption{WithNow(now)},
+			expected: true,
+		},
+		{name: "date string", filter: `publish_date < "1941-01-01"`, expected: true},
+		{name: "starts with", filter: `startsWith(author, "Karin")`, expected: true},
+		{name: "contains", filter: `contains(author, "n B")`, expected: true},
+		{name: "matches", filter: `matches(author, "^K.*e$")`, expected: true},
+		{name: "equals ignore case", filter: `equalsIgnoreCase(author, "KARIN BOYE")`, expected: true},
+		{
+			name:     "JSON field",
+			filter:   `config.max_weight > 10.0`,
+			options:  []TranspileOption{WithJSONColumns("config")},
+			expected: true,
+		},
+
+		{name: "wildcard with LIKE metacharacters", filter: `literal = "50%_*"`, expected: true},
+		{name: "wildcard with LIKE metacharacters mismatch", filter: `literal = "50%x*"`, expected: false},
+
+		{name: "size", filter: `size(tags) > 1`, expected: true},
+		{name: "non-empty", filter: `tags:*`, expected: true},
+		{
+			name:     "JSON array any element",
+			filter:   `line_items.weight > 10.0`,
+			options:  []TranspileOption{WithJSONArrayColumns("line_items")},
+			expected: true,
+		},
+		{
+			name:     "JSON array all elements",
+			filter:   `NOT line_items.weight <= 10.0`,
+			options:  []TranspileOption{WithJSONArrayColumns("line_items")},
+			expected: false,
+		},
+		{
+			name:     "JSON array element has",
+			filter:   `line_items.title:"parcel"`,
+			options:  []TranspileOption{WithJSONArrayColumns("line_items")},
+			expected: true,
+		},
+
+		// NULL semantics.
+		{name: "NULL equality", filter: `title = "Kallocain"`, expected: false},
+		{name: "NULL inequality", filter: `title != "Kallocain"`, expected: false},
+		{name: "NOT NULL comparison", filter: `NOT title = "Kallocain"`, expected: false},
+		{name: "NULL OR true", filter: `title = "Kallocain" OR read`, expected: true},
+		{name: "NULL AND true", filter: `title = "Kallocain" AND read`, expected: false},
+		{name: "NULL presence", filter: `title:*`, expected: false},
+		{name: "missing column", filter: `NOT subtitle = "Kallocain"`, expected: false},
+		{name: "NOT NULL OR false", filter: `NOT (title = "Kallocain" OR author = "Kallocain")`, expected: false},
+
+		{
+			name:          "unsupported function",
+			filter:        `distanceKm(1, 2) < 10.0`,
+			errorContains: "unsupported function in predicate: DISTANCE",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations := declarations
+			options := tt.options
+			if tt.errorContains != "" {
+				var err error
+				declarations, err = filtering.NewDeclarations(
+					filtering.DeclareStandardFunctions(),
+					filtering.DeclareFunction(
+						"distanceKm",
+						filtering.NewFunctionOverload(
+							"distanceKm_int_int", filtering.TypeFloat, filtering.TypeInt, filtering.TypeInt,
+						),
+					),
+				)
+				assert.NilError(t, err)
+				options = append(options, WithFunction("distanceKm", distanceFunction))
+			}
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			predicate, err := CompilePredicate(filter, options...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			actual, err := predicate.Match(columns)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual, predicate.Expr().SQL())
+		})
+	}
+}
+
+func TestRowPredicate(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("FirstName", filtering.TypeString),
+		filtering.DeclareIdent("LastName", filtering.TypeString),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(
+		&mockRequest{filter: `FirstName = "Frank" AND LastName != "Sinatra"`},
+		declarations,
+	)
+	assert.NilError(t, err)
+	predicate, err := CompilePredicate(filter)
+	assert.NilError(t, err)
+	match := RowPredicate[*singersRow](predicate)
+	assert.Assert(t, match(&singersRow{
+		FirstName: spanner.NullString{StringVal: "Frank", Valid: true},
+		LastName:  spanner.NullString{StringVal: "Zappa", Valid: true},
+	}))
+	assert.Assert(t, !match(&singersRow{
+		FirstName: spanner.NullString{StringVal: "Frank", Valid: true},
+		LastName:  spanner.NullString{StringVal: "Sinatra", Valid: true},
+	}))
+	assert.Assert(t, !match(&singersRow{
+		FirstName: spanner.NullString{StringVal: "Frank", Valid: true},
+	}))
+}
+
+func TestLikeRegexp(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{pattern: `abc`, value: `abc`, expected: true},
+		{pattern: `abc`, value: `abcd`, expected: false},
+		{pattern: `a%`, value: "a\nb", expected: true},
+		{pattern: `a_c`, value: `abc`, expected: true},
+		{pattern: `a\_c`, value: `abc`, expected: false},
+		{pattern: `a\_c`, value: `a_c`, expected: true},
+		{pattern: `50\%%`, value: `50% off`, expected: true},
+		{pattern: `a\\%`, value: `a\b`, expected: true},
+		{pattern: `a.c`, value: `abc`, expected: false},
+	} {
+		re, err := likeRegexp(tt.pattern)
+		assert.NilError(t, err)
+		assert.Equal(t, tt.expected, re.MatchString(tt.value), "%s LIKE %s", tt.value, tt.pattern)
+	}
+}
+
+func distanceFunction(args []spansql.Expr, _ ParamAllocator) (spansql.Expr, error) {
+	return spansql.Func{Name: "DISTANCE", Args: args}, nil
+}
+
+type singersRow struct {
+	FirstName spanner.NullString
+	LastName  spanner.NullString
+}
+
+func (r *singersRow) Mutate() (string, []string, []interface{}) {
+	return "Singers", []string{"FirstName", "LastName"}, []interface{}{r.FirstName, r.LastName}
+}
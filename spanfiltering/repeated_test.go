@@ -0,0 +1,188 @@
+package spanfiltering
+
+import (
+	"testing"
+
+	"go.einride.tech/aip/filtering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileFilter_repeated(t *testing.T) {
+	t.Parallel()
+	lineItemType := filtering.TypeMap(filtering.TypeString, filtering.TypeString)
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		DeclareSizeFunction(filtering.TypeString),
+		DeclareFieldSizeFunctions("tags"),
+		DeclareRepeatedHasFunction(filtering.TypeString, lineItemType),
+		DeclareStartsWithFunction(),
+		DeclareMatchesFunction(),
+		filtering.DeclareIdent("tags", filtering.TypeList(filtering.TypeString)),
+		filtering.DeclareIdent("line_items", filtering.TypeList(lineItemType)),
+		filtering.DeclareIdent("line_items.title", filtering.TypeString),
+		filtering.DeclareIdent("line_items.weight", filtering.TypeFloat),
+		filtering.DeclareIdent("line_items.tags", filtering.TypeList(filtering.TypeString)),
+	)
+	assert.NilError(t, err)
+	for _, tt := range []struct {
+		name           string
+		filter         string
+		expectedSQL    string
+		expectedParams map[string]interface{}
+	}{
+		{
+			name:        "size function",
+			filter:      `size(tags) > 2`,
+			expectedSQL: `((COALESCE(ARRAY_LENGTH(tags), 0)) > @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": int64(2),
+			},
+		},
+
+		{
+			name:        "size method",
+			filter:      `tags.size() = 0`,
+			expectedSQL: `((COALESCE(ARRAY_LENGTH(tags), 0)) = @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": int64(0),
+			},
+		},
+
+		{
+			name:        "non-empty",
+			filter:      `tags:*`,
+			expectedSQL: `(ARRAY_LENGTH(tags) > 0)`,
+		},
+
+		{
+			name:        "negated membership",
+			filter:      `NOT tags:"fiction"`,
+			expectedSQL: `(NOT (@param_0 IN UNNEST(tags)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "fiction",
+			},
+		},
+
+		{
+			name:        "negated membership with minus",
+			filter:      `-tags:"fiction"`,
+			expectedSQL: `(NOT (@param_0 IN UNNEST(tags)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "fiction",
+			},
+		},
+
+		{
+			name:        "JSON array non-empty",
+			filter:      `line_items:*`,
+			expectedSQL: `(ARRAY_LENGTH(line_items) > 0)`,
+		},
+
+		{
+			name:   "JSON array element comparison",
+			filter: `line_items.weight > 10.5`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE CAST(JSON_VALUE(e, "$.weight") AS FLOAT64) > @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": 10.5,
+			},
+		},
+
+		{
+			name:   "JSON array element has",
+			filter: `line_items.title:"pallet"`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE JSON_VALUE(e, "$.title") = @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "pallet",
+			},
+		},
+
+		{
+			name:   "JSON array element has wildcard",
+			filter: `line_items.title:*`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE JSON_VALUE(e, "$.title") IS NOT NULL))`,
+		},
+
+		{
+			name:   "JSON array element wildcard",
+			filter: `line_items.title = "pal*"`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE JSON_VALUE(e, "$.title") LIKE @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "pal%",
+			},
+		},
+
+		{
+			name:   "JSON array element starts with",
+			filter: `startsWith(line_items.title, "pal")`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE STARTS_WITH(JSON_VALUE(e, "$.title"), @param_0)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "pal",
+			},
+		},
+
+		{
+			name:   "JSON array element matches",
+			filter: `matches(line_items.title, "^pal+et$")`,
+			expectedSQL: `(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE REGEXP_CONTAINS(JSON_VALUE(e, "$.title"), @param_0)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "^pal+et$",
+			},
+		},
+
+		{
+			name:   "JSON array element equality disjunction",
+			filter: `line_items.title = "pallet" OR line_items.title = "crate"`,
+			expectedSQL: `((EXISTS (SELECT 1 FROM UNNEST(line_items) AS e WHERE JSON_VALUE(e, "$.title") = @param_0)) OR ` +
+				`(EXISTS (SELECT 1 FROM UNNEST(line_items) AS e WHERE JSON_VALUE(e, "$.title") = @param_1)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "pallet",
+				"param_1": "crate",
+			},
+		},
+
+		{
+			name:   "JSON array all elements",
+			filter: `NOT line_items.weight <= 10.0`,
+			expectedSQL: `(NOT (EXISTS (SELECT 1 FROM UNNEST(line_items) AS e ` +
+				`WHERE CAST(JSON_VALUE(e, "$.weight") AS FLOAT64) <= @param_0)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": 10.0,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, params, err := TranspileFilter(filter, WithJSONArrayColumns("line_items"))
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, actual.SQL())
+			assert.DeepEqual(t, tt.expectedParams, params)
+		})
+	}
+}
+
+func TestTranspileFilter_unsupportedJSONArrayField(t *testing.T) {
+	t.Parallel()
+	lineItemType := filtering.TypeMap(filtering.TypeString, filtering.TypeString)
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		DeclareSizeFunction(filtering.TypeString),
+		filtering.DeclareIdent("line_items", filtering.TypeList(lineItemType)),
+		filtering.DeclareIdent("line_items.tags", filtering.TypeList(filtering.TypeString)),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `size(line_items.tags) > 1`}, declarations)
+	assert.NilError(t, err)
+	_, _, err = TranspileFilter(filter, WithJSONArrayColumns("line_items"))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.ErrorContains(t, err, "line_items.tags")
+}
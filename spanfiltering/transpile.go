@@ -1,6 +1,8 @@
 package spanfiltering
 
 import (
+	"fmt"
+
 	"cloud.google.com/go/spanner/spansql"
 	"go.einride.tech/aip/filtering"
 )
@@ -16,3 +18,45 @@ func TranspileFilter(
 	t.Init(filter, options...)
 	return t.Transpile()
 }
+
+// TranspiledFilter is a transpiled filter expression and the parameters used in the expression.
+type TranspiledFilter struct {
+	Expr   spansql.BoolExpr
+	Params map[string]interface{}
+}
+
+// And combines transpiled filter expressions with AND, and merges their parameters.
+// Filters without an expression, or with a TRUE expression, are skipped.
+// An error is returned if more than one filter uses the same parameter name, see WithParamPrefix.
+// The parameter map is nil if the combined expression does not contain any parameters.
+func And(filters ...TranspiledFilter) (spansql.BoolExpr, map[string]interface{}, error) {
+	var result spansql.BoolExpr
+	var params map[string]interface{}
+	for _, filter := range filters {
+		if filter.Expr == nil || filter.Expr == spansql.True {
+			continue
+		}
+		for param, value := range filter.Params {
+			if _, ok := params[param]; ok {
+				return nil, nil, fmt.Errorf("param %s is used by more than one filter", param)
+			}
+			if params == nil {
+				params = make(map[string]interface{})
+			}
+			params[param] = value
+		}
+		expr := filter.Expr
+		if _, ok := expr.(spansql.Paren); !ok {
+			expr = spansql.Paren{Expr: expr}
+		}
+		if result == nil {
+			result = expr
+			continue
+		}
+		result = spansql.LogicalOp{Op: spansql.And, LHS: result, RHS: expr}
+	}
+	if result == nil {
+		return spansql.True, nil, nil
+	}
+	return result, params, nil
+}
@@ -4,8 +4,13 @@ import (
 	"testing"
 	"time"
 
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner/spansql"
 	"go.einride.tech/aip/filtering"
 	syntaxv1 "go.einride.tech/aip/proto/gen/einride/example/syntax/v1"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gotest.tools/v3/assert"
 )
 
@@ -66,6 +71,103 @@ func TestTranspileFilter(t *testing.T) {
 			},
 		},
 
+		{
+			name:   "wildcard with LIKE metacharacters",
+			filter: `title = "50%_off*"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `(title LIKE @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": `50\%\_off%`,
+			},
+		},
+
+		{
+			name:   "wildcard with escaped asterisk and backslash",
+			filter: `title = "*C:\\\\Program Files\\**"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `(title LIKE @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": `%C:\\Program Files*%`,
+			},
+		},
+
+		{
+			name:   "escaped asterisk",
+			filter: `title = "\\*" OR title != "5 \\* 3"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `((title = @param_0) OR (title != @param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "*",
+				"param_1": "5 * 3",
+			},
+		},
+
+		{
+			name:   "escaped asterisk in equality disjunction",
+			filter: `title = "\\*" OR title = "5 \\ 3"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `(title IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"*", `5 \ 3`},
+			},
+		},
+
+		{
+			name:   "custom functions",
+			filter: `distanceKm(lat, lng, 59.33, 18.07) < 10.0 AND isOverdue()`,
+			options: []TranspileOption{
+				WithFunction("distanceKm", func(args []spansql.Expr, _ ParamAllocator) (spansql.Expr, error) {
+					return spansql.Func{Name: "DISTANCE_KM", Args: args}, nil
+				}),
+				WithFunction("isOverdue", func(_ []spansql.Expr, param ParamAllocator) (spansql.Expr, error) {
+					return spansql.ComparisonOp{
+						Op:  spansql.Lt,
+						LHS: spansql.ID("due_time"),
+						RHS: param(mustParseTime(t, "2021-02-14T14:49:34Z")),
+					}, nil
+				}),
+			},
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareFunction("distanceKm", filtering.NewFunctionOverload(
+					"distanceKm_float",
+					filtering.TypeFloat,
+					filtering.TypeFloat, filtering.TypeFloat, filtering.TypeFloat, filtering.TypeFloat,
+				)),
+				filtering.DeclareFunction("isOverdue", filtering.NewFunctionOverload("isOverdue", filtering.TypeBool)),
+				filtering.DeclareIdent("lat", filtering.TypeFloat),
+				filtering.DeclareIdent("lng", filtering.TypeFloat),
+			},
+			expectedSQL: `(((DISTANCE_KM(lat, lng, @param_0, @param_1)) < @param_2) AND (due_time < @param_3))`,
+			expectedParams: map[string]interface{}{
+				"param_0": 59.33,
+				"param_1": 18.07,
+				"param_2": 10.0,
+				"param_3": mustParseTime(t, "2021-02-14T14:49:34Z"),
+			},
+		},
+
+		{
+			name:   "unregistered custom function",
+			filter: `isOverdue()`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareFunction("isOverdue", filtering.NewFunctionOverload("isOverdue", filtering.TypeBool)),
+			},
+			errorContains: "unsupported function call: isOverdue",
+		},
+
 		{
 			name:   "timestamp",
 			filter: `create_time > timestamp("2021-02-14T14:49:34+01:00")`,
@@ -79,6 +181,193 @@ func TestTranspileFilter(t *testing.T) {
 			},
 		},
 
+		{
+			name:   "timestamp string",
+			filter: `create_time > "2021-02-14T14:49:34+01:00"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+			},
+			expectedSQL: `(create_time > @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": mustParseTime(t, "2021-02-14T14:49:34+01:00"),
+			},
+		},
+
+		{
+			name:    "relative timestamp",
+			options: []TranspileOption{WithNow(mustParseTime(t, "2021-02-14T14:49:34Z"))},
+			filter:  `create_time > timestampSub(now(), duration("24h"))`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareTimeFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+			},
+			expectedSQL: `(create_time > (TIMESTAMP_SUB((@param_0), INTERVAL @param_1 MICROSECOND)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": mustParseTime(t, "2021-02-14T14:49:34Z"),
+				"param_1": int64(24 * time.Hour / time.Microsecond),
+			},
+		},
+
+		{
+			name:   "timestamp column arithmetic",
+			filter: `timestampAdd(create_time, duration("90s")) < timestamp("2021-02-14T14:49:34Z")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareTimeFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+			},
+			expectedSQL: `((TIMESTAMP_ADD(create_time, INTERVAL @param_0 MICROSECOND)) < (@param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": int64(90 * time.Second / time.Microsecond),
+				"param_1": mustParseTime(t, "2021-02-14T14:49:34Z"),
+			},
+		},
+
+		{
+			name:   "now without bound value",
+			filter: `create_time > timestampSub(now(), duration("24h"))`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareTimeFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+			},
+			errorContains: "no value bound with WithNow",
+		},
+
+		{
+			name:   "date string",
+			filter: `birth_date >= "2021-02-14"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareDateFunctions(),
+				filtering.DeclareIdent("birth_date", TypeDate),
+			},
+			expectedSQL: `(birth_date >= @param_0)`,
+			expectedParams: map[string]interface{}{
+				"param_0": civil.Date{Year: 2021, Month: time.February, Day: 14},
+			},
+		},
+
+		{
+			name:   "date literal",
+			filter: `birth_date < date("2021-02-14")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareDateFunctions(),
+				filtering.DeclareIdent("birth_date", TypeDate),
+			},
+			expectedSQL: `(birth_date < (@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": civil.Date{Year: 2021, Month: time.February, Day: 14},
+			},
+		},
+
+		{
+			name:   "invalid date string",
+			filter: `birth_date = "2021-02-30"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareDateFunctions(),
+				filtering.DeclareIdent("birth_date", TypeDate),
+			},
+			errorContains: "invalid date",
+		},
+
+		{
+			name: "field mapping",
+			options: []TranspileOption{
+				WithFieldMapping(map[string]spansql.Expr{
+					"displayName": spansql.ID("display_name"),
+					"origin.site": spansql.ID("origin_site_id"),
+				}),
+			},
+			filter: `displayName = "Karin*" AND origin.site = "sites/1"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("displayName", filtering.TypeString),
+				filtering.DeclareIdent("origin.site", filtering.TypeString),
+			},
+			expectedSQL: `((display_name LIKE @param_0) AND (origin_site_id = @param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Karin%",
+				"param_1": "sites/1",
+			},
+		},
+
+		{
+			name: "unmapped field",
+			options: []TranspileOption{
+				WithFieldMapping(map[string]spansql.Expr{
+					"displayName": spansql.ID("display_name"),
+				}),
+			},
+			filter: `displayName = "Karin Boye" AND secret = "x"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("displayName", filtering.TypeString),
+				filtering.DeclareIdent("secret", filtering.TypeString),
+			},
+			errorContains: "unsupported field in filter: secret",
+		},
+
+		{
+			name:    "JSON column",
+			options: []TranspileOption{WithJSONColumns("config")},
+			filter:  `config.max_weight > 10.5 AND config.name = "Karin Boye" AND config.limits.count < 3`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("config.max_weight", filtering.TypeFloat),
+				filtering.DeclareIdent("config.name", filtering.TypeString),
+				filtering.DeclareIdent("config.limits.count", filtering.TypeInt),
+			},
+			expectedSQL: `(((CAST(JSON_VALUE(config, "$.max_weight") AS FLOAT64) > @param_0) AND ` +
+				`(JSON_VALUE(config, "$.name") = @param_1)) AND ` +
+				`(CAST(JSON_VALUE(config, "$.limits.count") AS INT64) < @param_2))`,
+			expectedParams: map[string]interface{}{
+				"param_0": 10.5,
+				"param_1": "Karin Boye",
+				"param_2": int64(3),
+			},
+		},
+
+		{
+			name: "string matching functions",
+			filter: `startsWith(author, "Karin") AND endsWith(author, "Boye") AND contains(author, "rin B") ` +
+				`AND matches(title, "^K.*n$") AND equalsIgnoreCase(title, "kallocain")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareStartsWithFunction(),
+				DeclareEndsWithFunction(),
+				DeclareContainsFunction(),
+				DeclareMatchesFunction(),
+				DeclareEqualsIgnoreCaseFunction(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `(((((STARTS_WITH(author, @param_0)) AND (ENDS_WITH(author, @param_1))) ` +
+				`AND (STRPOS(author, @param_2) > 0)) AND (REGEXP_CONTAINS(title, @param_3))) ` +
+				`AND (LOWER(title) = LOWER(@param_4)))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Karin",
+				"param_1": "Boye",
+				"param_2": "rin B",
+				"param_3": "^K.*n$",
+				"param_4": "kallocain",
+			},
+		},
+
+		{
+			name:   "invalid regular expression",
+			filter: `matches(title, "(")`,
+			declarations: []filtering.DeclarationOption{
+				DeclareMatchesFunction(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			errorContains: "invalid pattern",
+		},
+
 		{
 			name:   "enum equality",
 			filter: `example_enum = ENUM_ONE`,
@@ -129,6 +418,119 @@ func TestTranspileFilter(t *testing.T) {
 			},
 		},
 
+		{
+			name:   "equality disjunction",
+			filter: `author = "Karin Boye" OR author = "Selma Lagerlöf" OR author = "Astrid Lindgren"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+			},
+			expectedSQL: `(author IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"Karin Boye", "Selma Lagerlöf", "Astrid Lindgren"},
+			},
+		},
+
+		{
+			name:   "equality disjunction of enums",
+			filter: `example_enum = ENUM_ONE OR example_enum = ENUM_TWO`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareEnumIdent("example_enum", syntaxv1.Enum(0).Type()),
+			},
+			expectedSQL: `(example_enum IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []int64{1, 2},
+			},
+		},
+
+		{
+			name:    "equality disjunction of enums as strings",
+			options: []TranspileOption{WithEnumValuesAsStrings()},
+			filter:  `example_enum = ENUM_ONE OR example_enum = ENUM_TWO`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareEnumIdent("example_enum", syntaxv1.Enum(0).Type()),
+			},
+			expectedSQL: `(example_enum IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []string{"ENUM_ONE", "ENUM_TWO"},
+			},
+		},
+
+		{
+			name:   "equality disjunction of date strings",
+			filter: `birth_date = "2021-02-14" OR birth_date = "2021-02-15"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareDateFunctions(),
+				filtering.DeclareIdent("birth_date", TypeDate),
+			},
+			expectedSQL: `(birth_date IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []civil.Date{
+					{Year: 2021, Month: time.February, Day: 14},
+					{Year: 2021, Month: time.February, Day: 15},
+				},
+			},
+		},
+
+		{
+			name:   "equality disjunction of invalid date strings",
+			filter: `birth_date = "2021-02-14" OR birth_date = "2021-02-30"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				DeclareDateFunctions(),
+				filtering.DeclareIdent("birth_date", TypeDate),
+			},
+			errorContains: "invalid date",
+		},
+
+		{
+			name:   "equality disjunction of timestamp strings",
+			filter: `create_time = "2021-02-14T14:49:34Z" OR create_time = "2021-02-15T14:49:34Z"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("create_time", filtering.TypeTimestamp),
+			},
+			expectedSQL: `(create_time IN UNNEST(@param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": []time.Time{
+					mustParseTime(t, "2021-02-14T14:49:34Z"),
+					mustParseTime(t, "2021-02-15T14:49:34Z"),
+				},
+			},
+		},
+
+		{
+			name:   "disjunction of equalities on different fields",
+			filter: `author = "Karin Boye" OR title = "Kallocain"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			},
+			expectedSQL: `((author = @param_0) OR (title = @param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Karin Boye",
+				"param_1": "Kallocain",
+			},
+		},
+
+		{
+			name:   "disjunction of equality and substring match",
+			filter: `author = "Karin Boye" OR author = "Selma*"`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("author", filtering.TypeString),
+			},
+			expectedSQL: `((author = @param_0) OR (author LIKE @param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "Karin Boye",
+				"param_1": "Selma%",
+			},
+		},
+
 		{
 			name:   "empty filter",
 			filter: ``,
@@ -243,6 +645,74 @@ func TestTranspileFilter(t *testing.T) {
 			errorContains: "only supports wildcard",
 		},
 
+		{
+			name:   "search: 2-arg basic",
+			filter: `search(display_name_tokens, "abc")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("display_name_tokens", filtering.TypeString),
+				DeclareSearchFunction(),
+			},
+			expectedSQL: `(SEARCH(display_name_tokens, @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "abc",
+			},
+		},
+
+		{
+			name:    "search: 3-arg all set",
+			options: []TranspileOption{WithSearchEnhanceQuery()},
+			filter:  `search(display_name_tokens, "abc", "en")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("display_name_tokens", filtering.TypeString),
+				DeclareSearchFunction(),
+			},
+			expectedSQL: `(SEARCH(display_name_tokens, @param_0, ` +
+				`enhance_query => @param_1, language_tag => @param_2))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "abc",
+				"param_1": true,
+				"param_2": "en",
+			},
+		},
+
+		{
+			name:   "search: 3-arg skip language_tag",
+			filter: `search(display_name_tokens, "abc", "")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("display_name_tokens", filtering.TypeString),
+				DeclareSearchFunction(),
+			},
+			expectedSQL: `(SEARCH(display_name_tokens, @param_0))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "abc",
+			},
+		},
+
+		{
+			name:   "searchSubstring: 3-arg all set",
+			filter: `searchSubstring(display_name_tokens, "abc", "en")`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("display_name_tokens", filtering.TypeString),
+				DeclareSearchSubstringFunction(),
+			},
+			expectedSQL: `(SEARCH_SUBSTRING(display_name_tokens, @param_0, language_tag => @param_1))`,
+			expectedParams: map[string]interface{}{
+				"param_0": "abc",
+				"param_1": "en",
+			},
+		},
+
+		{
+			name:   "searchSubstring: non-constant query",
+			filter: `searchSubstring(display_name_tokens, display_name)`,
+			declarations: []filtering.DeclarationOption{
+				filtering.DeclareIdent("display_name_tokens", filtering.TypeString),
+				filtering.DeclareIdent("display_name", filtering.TypeString),
+				DeclareSearchSubstringFunction(),
+			},
+			errorContains: "second argument to searchSubstring must be a string constant",
+		},
+
 		{
 			name:   "searchNgrams: 2-arg basic",
 			filter: `searchNgrams(display_name_tokens, "abc")`,
@@ -343,6 +813,107 @@ func TestTranspileFilter(t *testing.T) {
 	}
 }
 
+func TestTranspileFilter_fieldResolver(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("shipment.origin_site", filtering.TypeString),
+	)
+	assert.NilError(t, err)
+	filter, err := filtering.ParseFilter(&mockRequest{filter: `shipment.origin_site = "sites/1"`}, declarations)
+	assert.NilError(t, err)
+	resolver := func(path string) (spansql.Expr, bool) {
+		if path == "shipment.origin_site" {
+			return spansql.ID("origin_site_id"), true
+		}
+		return nil, false
+	}
+	actual, params, err := TranspileFilter(filter, WithFieldResolver(resolver))
+	assert.NilError(t, err)
+	assert.Equal(t, `(origin_site_id = @param_0)`, actual.SQL())
+	assert.DeepEqual(t, map[string]interface{}{"param_0": "sites/1"}, params)
+	_, _, err = TranspileFilter(filter, WithFieldResolver(func(string) (spansql.Expr, bool) { return nil, false }))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestTranspileFilter_unsupportedSelectOperand(t *testing.T) {
+	t.Parallel()
+	// A select on a constant, such as `"sites/1".origin_site`, has no field path.
+	filter := filtering.Filter{
+		CheckedExpr: &expr.CheckedExpr{
+			Expr: &expr.Expr{Id: 1, ExprKind: &expr.Expr_CallExpr{CallExpr: &expr.Expr_Call{
+				Function: filtering.FunctionEquals,
+				Args: []*expr.Expr{
+					{Id: 2, ExprKind: &expr.Expr_SelectExpr{SelectExpr: &expr.Expr_Select{
+						Operand: &expr.Expr{Id: 3, ExprKind: &expr.Expr_ConstExpr{ConstExpr: &expr.Constant{
+							ConstantKind: &expr.Constant_StringValue{StringValue: "sites/1"},
+						}}},
+						Field: "origin_site",
+					}}},
+					{Id: 4, ExprKind: &expr.Expr_ConstExpr{ConstExpr: &expr.Constant{
+						ConstantKind: &expr.Constant_StringValue{StringValue: "sites/1"},
+					}}},
+				},
+			}}},
+			TypeMap: map[int64]*expr.Type{2: filtering.TypeString, 3: filtering.TypeString, 4: filtering.TypeString},
+		},
+	}
+	for _, options := range [][]TranspileOption{
+		nil,
+		{WithFieldMapping(map[string]spansql.Expr{"origin_site": spansql.ID("origin_site_id")})},
+	} {
+		_, _, err := TranspileFilter(filter, options...)
+		assert.ErrorContains(t, err, "unsupported field in filter: origin_site")
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAnd(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("author", filtering.TypeString),
+		filtering.DeclareIdent("owner", filtering.TypeString),
+		filtering.DeclareIdent("read", filtering.TypeBool),
+	)
+	assert.NilError(t, err)
+	transpile := func(t *testing.T, filter string, options ...TranspileOption) TranspiledFilter {
+		t.Helper()
+		parsed, err := filtering.ParseFilter(&mockRequest{filter: filter}, declarations)
+		assert.NilError(t, err)
+		expr, params, err := TranspileFilter(parsed, options...)
+		assert.NilError(t, err)
+		return TranspiledFilter{Expr: expr, Params: params}
+	}
+	t.Run("prefixes", func(t *testing.T) {
+		t.Parallel()
+		actual, params, err := And(
+			transpile(t, `author = "Karin Boye" OR read`),
+			transpile(t, ``),
+			transpile(t, `owner = "users/1"`, WithParamPrefix("auth_")),
+			transpile(t, `read`, WithParamPrefix("read_")),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, `((author = @param_0) OR read) AND (owner = @auth_0) AND (read)`, actual.SQL())
+		assert.DeepEqual(t, map[string]interface{}{"param_0": "Karin Boye", "auth_0": "users/1"}, params)
+	})
+	t.Run("param collision", func(t *testing.T) {
+		t.Parallel()
+		_, _, err := And(
+			transpile(t, `author = "Karin Boye"`),
+			transpile(t, `owner = "users/1"`),
+		)
+		assert.ErrorContains(t, err, "param param_0 is used by more than one filter")
+	})
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		actual, params, err := And()
+		assert.NilError(t, err)
+		assert.Equal(t, spansql.True, actual)
+		assert.Assert(t, params == nil)
+	})
+}
+
 func mustParseTime(t *testing.T, s string) time.Time {
 	t.Helper()
 	tm, err := time.Parse(time.RFC3339, s)
@@ -2,13 +2,18 @@ package spanfiltering
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"cloud.google.com/go/civil"
 	"cloud.google.com/go/spanner/spansql"
 	"go.einride.tech/aip/filtering"
 	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 )
@@ -16,6 +21,263 @@ import (
 // FunctionSearchNgrams is the function name for SEARCH_NGRAMS in filter expressions.
 const FunctionSearchNgrams = "searchNgrams"
 
+// FunctionSearch is the function name for SEARCH in filter expressions.
+const FunctionSearch = "search"
+
+// FunctionSearchSubstring is the function name for SEARCH_SUBSTRING in filter expressions.
+const FunctionSearchSubstring = "searchSubstring"
+
+// FunctionIn is the CEL function name of the `in` operator, e.g. `state in [ACTIVE, PENDING]`.
+//
+// The AIP-160 filter grammar has no list literals, so filters parsed with filtering.ParseFilter never contain `in`
+// calls. Filters built programmatically, for example by macros, can use it. Disjunctions of equality comparisons on
+// the same field, such as `state = ACTIVE OR state = PENDING`, are transpiled the same way.
+const FunctionIn = "@in"
+
+// Time function names in filter expressions.
+//
+// The AIP-160 filter grammar has no arithmetic operators, so timestamp arithmetic is expressed with functions, e.g.
+// `create_time > timestampSub(now(), duration("24h"))`.
+const (
+	// FunctionNow is the function name for the current time, bound with WithNow.
+	FunctionNow = "now"
+	// FunctionTimestampAdd is the function name for TIMESTAMP_ADD in filter expressions.
+	FunctionTimestampAdd = "timestampAdd"
+	// FunctionTimestampSub is the function name for TIMESTAMP_SUB in filter expressions.
+	FunctionTimestampSub = "timestampSub"
+	// FunctionDate is the function name for date literals in filter expressions, e.g. `date("2021-02-14")`.
+	FunctionDate = "date"
+)
+
+// TypeDate is the type of DATE columns in filter expressions.
+//
+// DATE columns can be compared with date literals and with date strings, e.g. `birth_date >= "2021-02-14"`.
+var TypeDate = &expr.Type{TypeKind: &expr.Type_MessageType{MessageType: "google.type.Date"}}
+
+// DeclareTimeFunctions declares the now, timestampAdd and timestampSub functions for use in filter expressions.
+func DeclareTimeFunctions() filtering.DeclarationOption {
+	return func(declarations *filtering.Declarations) error {
+		for _, option := range []filtering.DeclarationOption{
+			filtering.DeclareFunction(
+				FunctionNow,
+				filtering.NewFunctionOverload(FunctionNow, filtering.TypeTimestamp),
+			),
+			filtering.DeclareFunction(
+				FunctionTimestampAdd,
+				filtering.NewFunctionOverload(
+					FunctionTimestampAdd+"_timestamp_duration",
+					filtering.TypeTimestamp,
+					filtering.TypeTimestamp, filtering.TypeDuration,
+				),
+			),
+			filtering.DeclareFunction(
+				FunctionTimestampSub,
+				filtering.NewFunctionOverload(
+					FunctionTimestampSub+"_timestamp_duration",
+					filtering.TypeTimestamp,
+					filtering.TypeTimestamp, filtering.TypeDuration,
+				),
+			),
+		} {
+			if err := option(declarations); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DeclareDateFunctions declares the date function, and comparisons of dates with dates and date strings.
+func DeclareDateFunctions() filtering.DeclarationOption {
+	return func(declarations *filtering.Declarations) error {
+		if err := filtering.DeclareFunction(
+			FunctionDate,
+			filtering.NewFunctionOverload(FunctionDate+"_string", TypeDate, filtering.TypeString),
+		)(declarations); err != nil {
+			return err
+		}
+		for _, function := range []string{
+			filtering.FunctionEquals,
+			filtering.FunctionNotEquals,
+			filtering.FunctionLessThan,
+			filtering.FunctionLessEquals,
+			filtering.FunctionGreaterThan,
+			filtering.FunctionGreaterEquals,
+		} {
+			if err := filtering.DeclareFunction(
+				function,
+				filtering.NewFunctionOverload(function+"_date", filtering.TypeBool, TypeDate, TypeDate),
+				filtering.NewFunctionOverload(function+"_date_string", filtering.TypeBool, TypeDate, filtering.TypeString),
+			)(declarations); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// String matching function names in filter expressions.
+const (
+	// FunctionStartsWith is the function name for STARTS_WITH in filter expressions.
+	FunctionStartsWith = "startsWith"
+	// FunctionEndsWith is the function name for ENDS_WITH in filter expressions.
+	FunctionEndsWith = "endsWith"
+	// FunctionContains is the function name for substring matching in filter expressions.
+	FunctionContains = "contains"
+	// FunctionMatches is the function name for REGEXP_CONTAINS in filter expressions.
+	FunctionMatches = "matches"
+	// FunctionEqualsIgnoreCase is the function name for case-insensitive equality in filter expressions.
+	FunctionEqualsIgnoreCase = "equalsIgnoreCase"
+)
+
+// DeclareStartsWithFunction declares the startsWith function for use in filter expressions,
+// e.g. `startsWith(display_name, "Karin")`.
+func DeclareStartsWithFunction() filtering.DeclarationOption {
+	return declareStringMatchFunction(FunctionStartsWith)
+}
+
+// DeclareEndsWithFunction declares the endsWith function for use in filter expressions,
+// e.g. `endsWith(display_name, "Boye")`.
+func DeclareEndsWithFunction() filtering.DeclarationOption {
+	return declareStringMatchFunction(FunctionEndsWith)
+}
+
+// DeclareContainsFunction declares the contains function for use in filter expressions,
+// e.g. `contains(display_name, "rin B")`.
+func DeclareContainsFunction() filtering.DeclarationOption {
+	return declareStringMatchFunction(FunctionContains)
+}
+
+// DeclareMatchesFunction declares the matches function for use in filter expressions,
+// e.g. `matches(display_name, "^K.*e$")`. Patterns use the RE2 syntax.
+func DeclareMatchesFunction() filtering.DeclarationOption {
+	return declareStringMatchFunction(FunctionMatches)
+}
+
+// DeclareEqualsIgnoreCaseFunction declares the equalsIgnoreCase function for use in filter expressions,
+// e.g. `equalsIgnoreCase(display_name, "karin boye")`.
+func DeclareEqualsIgnoreCaseFunction() filtering.DeclarationOption {
+	return declareStringMatchFunction(FunctionEqualsIgnoreCase)
+}
+
+func declareStringMatchFunction(name string) filtering.DeclarationOption {
+	return filtering.DeclareFunction(
+		name,
+		filtering.NewFunctionOverload(name+"_string", filtering.TypeBool, filtering.TypeString, filtering.TypeString),
+	)
+}
+
+// FunctionSize is the function name for the size of repeated fields in filter expressions, e.g. `size(tags) > 2`.
+//
+// The size of a repeated field can also be expressed as a method on the field, e.g. `tags.size() > 2`, see
+// DeclareFieldSizeFunctions. A NULL array has size 0, as an unset repeated field.
+const FunctionSize = "size"
+
+// DeclareSizeFunction declares the size function for repeated fields with the provided element types.
+func DeclareSizeFunction(elementTypes ...*expr.Type) filtering.DeclarationOption {
+	overloads := make([]*expr.Decl_FunctionDecl_Overload, 0, len(elementTypes))
+	for _, elementType := range elementTypes {
+		overloads = append(overloads, filtering.NewFunctionOverload(
+			FunctionSize+"_list_"+typeName(elementType),
+			filtering.TypeInt,
+			filtering.TypeList(elementType),
+		))
+	}
+	return filtering.DeclareFunction(FunctionSize, overloads...)
+}
+
+// DeclareFieldSizeFunctions declares size methods on the provided repeated fields, e.g. `tags.size()` for "tags".
+//
+// The filter grammar parses `tags.size()` as a call to a function named "tags.size", so each field needs its own
+// declaration.
+func DeclareFieldSizeFunctions(paths ...string) filtering.DeclarationOption {
+	return func(declarations *filtering.Declarations) error {
+		for _, path := range paths {
+			if err := filtering.DeclareFunction(
+				path+"."+FunctionSize,
+				filtering.NewFunctionOverload(path+"."+FunctionSize, filtering.TypeInt),
+			)(declarations); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// DeclareRepeatedHasFunction declares the `:` operator on repeated fields with the provided element types, e.g.
+// `line_items:*` for a non-empty repeated field of messages.
+func DeclareRepeatedHasFunction(elementTypes ...*expr.Type) filtering.DeclarationOption {
+	overloads := make([]*expr.Decl_FunctionDecl_Overload, 0, len(elementTypes))
+	for _, elementType := range elementTypes {
+		overloads = append(overloads, filtering.NewFunctionOverload(
+			filtering.FunctionHas+"_list_"+typeName(elementType),
+			filtering.TypeBool,
+			filtering.TypeList(elementType), filtering.TypeString,
+		))
+	}
+	return filtering.DeclareFunction(filtering.FunctionHas, overloads...)
+}
+
+func typeName(t *expr.Type) string {
+	switch {
+	case t.GetPrimitive() != expr.Type_PRIMITIVE_TYPE_UNSPECIFIED:
+		return strings.ToLower(t.GetPrimitive().String())
+	case t.GetWellKnown() != expr.Type_WELL_KNOWN_TYPE_UNSPECIFIED:
+		return strings.ToLower(t.GetWellKnown().String())
+	case t.GetMessageType() != "":
+		return t.GetMessageType()
+	case t.GetMapType() != nil:
+		return "map_" + typeName(t.GetMapType().GetKeyType()) + "_" + typeName(t.GetMapType().GetValueType())
+	case t.GetListType() != nil:
+		return "list_" + typeName(t.GetListType().GetElemType())
+	default:
+		return "dyn"
+	}
+}
+
+// DeclareSearchFunction declares the search function for use in filter expressions.
+// It declares two overloads:
+//   - 2-arg: search(column, query) — required params only
+//   - 3-arg: search(column, query, language_tag) — all params
+//
+// The filter grammar has no boolean literals, so enhance_query is enabled for all search calls of a filter with
+// WithSearchEnhanceQuery.
+func DeclareSearchFunction() filtering.DeclarationOption {
+	return filtering.DeclareFunction(
+		FunctionSearch,
+		filtering.NewFunctionOverload(
+			FunctionSearch+"_2",
+			filtering.TypeBool,
+			filtering.TypeString, filtering.TypeString,
+		),
+		filtering.NewFunctionOverload(
+			FunctionSearch+"_3",
+			filtering.TypeBool,
+			filtering.TypeString, filtering.TypeString, filtering.TypeString,
+		),
+	)
+}
+
+// DeclareSearchSubstringFunction declares the searchSubstring function for use in filter expressions.
+// It declares two overloads:
+//   - 2-arg: searchSubstring(column, query) — required params only
+//   - 3-arg: searchSubstring(column, query, language_tag) — all params
+func DeclareSearchSubstringFunction() filtering.DeclarationOption {
+	return filtering.DeclareFunction(
+		FunctionSearchSubstring,
+		filtering.NewFunctionOverload(
+			FunctionSearchSubstring+"_2",
+			filtering.TypeBool,
+			filtering.TypeString, filtering.TypeString,
+		),
+		filtering.NewFunctionOverload(
+			FunctionSearchSubstring+"_3",
+			filtering.TypeBool,
+			filtering.TypeString, filtering.TypeString, filtering.TypeString,
+		),
+	)
+}
+
 // DeclareSearchNgramsFunction declares the searchNgrams function for use in filter expressions.
 // It declares two overloads:
 //   - 2-arg: searchNgrams(column, query) — required params only
@@ -37,10 +299,15 @@ func DeclareSearchNgramsFunction() filtering.DeclarationOption {
 }
 
 type Transpiler struct {
-	filter       filtering.Filter
-	params       map[string]interface{}
-	paramCounter int
-	options      transpileOptions
+	filter            filtering.Filter
+	params            map[string]interface{}
+	paramCounter      int
+	wildcardTermCount int
+	// jsonArrayElement is the JSON array column whose elements are being filtered, if any.
+	jsonArrayElement string
+	// interleavedTable is the interleaved table whose rows are being filtered, if any.
+	interleavedTable *interleavedTable
+	options          transpileOptions
 }
 
 type TranspileOption func(options *transpileOptions)
@@ -51,14 +318,154 @@ func WithEnumValuesAsStrings() TranspileOption {
 	}
 }
 
+// WithNow binds the value of the now function in filter expressions.
+//
+// Filters calling now are rejected when no value is bound.
+func WithNow(now time.Time) TranspileOption {
+	return func(options *transpileOptions) {
+		options.now = now
+	}
+}
+
+// FieldResolver resolves the SQL expression of a field path in a filter expression, such as "display_name" or
+// "origin.site". Field paths that are not resolved are rejected.
+type FieldResolver func(path string) (spansql.Expr, bool)
+
+// WithFieldResolver resolves the SQL expressions of field paths with the provided resolver, instead of using the field
+// names as column names.
+//
+// Filters with unresolved field paths are rejected with an InvalidArgument error.
+func WithFieldResolver(resolver FieldResolver) TranspileOption {
+	return func(options *transpileOptions) {
+		options.fieldResolver = resolver
+	}
+}
+
+// WithFieldMapping maps field paths to SQL expressions, e.g. "displayName" to spansql.ID("display_name").
+//
+// Filters with unmapped field paths are rejected with an InvalidArgument error.
+func WithFieldMapping(mapping map[string]spansql.Expr) TranspileOption {
+	return WithFieldResolver(func(path string) (spansql.Expr, bool) {
+		result, ok := mapping[path]
+		return result, ok
+	})
+}
+
+// WithJSONColumns transpiles fields nested in the provided JSON columns to typed JSON extraction, based on the checked
+// type of the field. For example, `config.max_weight > 10.5` on a JSON column config becomes
+// `CAST(JSON_VALUE(config, '$.max_weight') AS FLOAT64) > @param_0`.
+func WithJSONColumns(columns ...string) TranspileOption {
+	return func(options *transpileOptions) {
+		options.jsonColumns = append(options.jsonColumns, columns...)
+	}
+}
+
+// WithJSONArrayColumns transpiles fields nested in the provided ARRAY<JSON> columns to conditions on any element of
+// the array. For example, `line_items.weight > 10.0` on an ARRAY<JSON> column line_items becomes
+// `EXISTS (SELECT 1 FROM UNNEST(line_items) AS e WHERE CAST(JSON_VALUE(e, '$.weight') AS FLOAT64) > @param_0)`.
+//
+// Negated conditions hold when no element matches, so `NOT line_items.weight <= 10.0` requires all elements to have a
+// weight over 10.
+func WithJSONArrayColumns(columns ...string) TranspileOption {
+	return func(options *transpileOptions) {
+		options.jsonArrayColumns = append(options.jsonArrayColumns, columns...)
+	}
+}
+
+// ParamAllocator allocates a query parameter with the provided value, and returns the parameter.
+type ParamAllocator func(value interface{}) spansql.Param
+
+// FunctionTranspiler transpiles calls of a custom filter function.
+//
+// The arguments are transpiled before the call, with constant values bound as parameters. Additional parameters can be
+// allocated with param.
+type FunctionTranspiler func(args []spansql.Expr, param ParamAllocator) (spansql.Expr, error)
+
+// WithFunction registers a transpiler for calls of a custom function, declared with filtering.DeclareFunction.
+//
+// Registered functions take precedence over the built-in functions of the same name.
+func WithFunction(name string, transpiler FunctionTranspiler) TranspileOption {
+	return func(options *transpileOptions) {
+		if options.functions == nil {
+			options.functions = make(map[string]FunctionTranspiler)
+		}
+		options.functions[name] = transpiler
+	}
+}
+
+// WithSearchEnhanceQuery enables enhance_query in the SEARCH calls of the search function, to include spelling
+// corrections and synonyms in the search.
+//
+// The option is global: it applies to every search call in the filter, since the filter grammar has no boolean
+// literals to enable it per call.
+func WithSearchEnhanceQuery() TranspileOption {
+	return func(options *transpileOptions) {
+		options.searchEnhanceQuery = true
+	}
+}
+
+// WithMaxDepth limits the nesting depth of filter expressions.
+//
+// A chain of the same logical operator, such as `a = 1 AND b = 2 AND c = 3`, counts as a single level.
+func WithMaxDepth(maxDepth int) TranspileOption {
+	return func(options *transpileOptions) {
+		options.maxDepth = maxDepth
+	}
+}
+
+// WithMaxTerms limits the number of terms in filter expressions, such as comparisons and function calls combined with
+// AND, OR and NOT.
+func WithMaxTerms(maxTerms int) TranspileOption {
+	return func(options *transpileOptions) {
+		options.maxTerms = maxTerms
+	}
+}
+
+// WithMaxParams limits the number of parameters in transpiled filter expressions.
+func WithMaxParams(maxParams int) TranspileOption {
+	return func(options *transpileOptions) {
+		options.maxParams = maxParams
+	}
+}
+
+// WithMaxWildcardTerms limits the number of wildcard comparisons in filter expressions, such as `author = "*Boye"`.
+func WithMaxWildcardTerms(maxWildcardTerms int) TranspileOption {
+	return func(options *transpileOptions) {
+		options.maxWildcardTerms = maxWildcardTerms
+	}
+}
+
+// WithParamPrefix names the parameters of transpiled filter expressions with the provided prefix, followed by a
+// counter. The default prefix is "param_".
+//
+// Use distinct prefixes for filters that are combined in the same query, for example with And.
+func WithParamPrefix(prefix string) TranspileOption {
+	return func(options *transpileOptions) {
+		options.paramPrefix = prefix
+	}
+}
+
 type transpileOptions struct {
+	paramPrefix         string
+	maxDepth            int
+	maxTerms            int
+	maxParams           int
+	maxWildcardTerms    int
+	searchEnhanceQuery  bool
 	enumValuesAsStrings bool
+	now                 time.Time
+	fieldResolver       FieldResolver
+	jsonColumns         []string
+	jsonArrayColumns    []string
+	interleavedTables   []interleavedTable
+	functions           map[string]FunctionTranspiler
 }
 
 func (t *Transpiler) Init(filter filtering.Filter, options ...TranspileOption) {
 	*t = Transpiler{
-		filter: filter,
-		params: make(map[string]interface{}),
+		filter:  filter,
+		params:  make(map[string]interface{}),
+		options: transpileOptions{paramPrefix: "param_"},
 	}
 	for _, option := range options {
 		option(&t.options)
@@ -69,6 +476,9 @@ func (t *Transpiler) Transpile() (spansql.BoolExpr, map[string]interface{}, erro
 	if t.filter.CheckedExpr == nil {
 		return spansql.True, nil, nil
 	}
+	if err := t.checkLimits(t.filter.CheckedExpr.GetExpr()); err != nil {
+		return nil, nil, err
+	}
 	resultExpr, err := t.transpileExpr(t.filter.CheckedExpr.GetExpr())
 	if err != nil {
 		return nil, nil, err
@@ -104,24 +514,41 @@ func (t *Transpiler) transpileExpr(e *expr.Expr) (spansql.Expr, error) {
 }
 
 func (t *Transpiler) transpileConstExpr(e *expr.Expr) (spansql.Expr, error) {
+	value, err := t.constValue(e)
+	if err != nil {
+		return nil, err
+	}
+	return t.param(value)
+}
+
+func (t *Transpiler) constValue(e *expr.Expr) (interface{}, error) {
 	switch kind := e.GetConstExpr().GetConstantKind().(type) {
 	case *expr.Constant_BoolValue:
-		return t.param(kind.BoolValue), nil
+		return kind.BoolValue, nil
 	case *expr.Constant_DoubleValue:
-		return t.param(kind.DoubleValue), nil
+		return kind.DoubleValue, nil
 	case *expr.Constant_Int64Value:
-		return t.param(kind.Int64Value), nil
+		return kind.Int64Value, nil
 	case *expr.Constant_StringValue:
-		return t.param(kind.StringValue), nil
+		return kind.StringValue, nil
 	case *expr.Constant_Uint64Value:
 		// spanner does not support uint64
-		return t.param(int64(kind.Uint64Value)), nil
+		return int64(kind.Uint64Value), nil
 	default:
 		return nil, fmt.Errorf("unsupported const expr: %v", kind)
 	}
 }
 
 func (t *Transpiler) transpileCallExpr(e *expr.Expr) (spansql.Expr, error) {
+	if function, ok := t.options.functions[e.GetCallExpr().GetFunction()]; ok {
+		return t.transpileFunctionCallExpr(e, function)
+	}
+	if column, ok := t.jsonArrayCall(e); ok {
+		return t.transpileJSONArrayCallExpr(e, column)
+	}
+	if table, ok := t.interleavedTableCall(e); ok {
+		return t.transpileInterleavedTableCallExpr(e, table)
+	}
 	switch e.GetCallExpr().GetFunction() {
 	case filtering.FunctionHas:
 		return t.transpileHasCallExpr(e)
@@ -143,40 +570,258 @@ func (t *Transpiler) transpileCallExpr(e *expr.Expr) (spansql.Expr, error) {
 	case filtering.FunctionAnd:
 		return t.transpileBinaryLogicalCallExpr(e, spansql.And)
 	case filtering.FunctionOr:
+		if lhs, values, ok := t.equalityDisjunction(e); ok {
+			return t.transpileInList(lhs, values)
+		}
 		return t.transpileBinaryLogicalCallExpr(e, spansql.Or)
+	case FunctionIn:
+		return t.transpileInCallExpr(e)
 	case filtering.FunctionNot:
 		return t.transpileNotCallExpr(e)
 	case filtering.FunctionTimestamp:
 		return t.transpileTimestampCallExpr(e)
+	case FunctionNow:
+		return t.transpileNowCallExpr(e)
+	case FunctionTimestampAdd:
+		return t.transpileTimestampArithmeticCallExpr(e, "TIMESTAMP_ADD")
+	case FunctionTimestampSub:
+		return t.transpileTimestampArithmeticCallExpr(e, "TIMESTAMP_SUB")
+	case FunctionDate:
+		return t.transpileDateCallExpr(e)
 	case FunctionSearchNgrams:
 		return t.transpileSearchNgramsCallExpr(e)
+	case FunctionSearch:
+		return t.transpileSearchCallExpr(e)
+	case FunctionSearchSubstring:
+		return t.transpileSearchSubstringCallExpr(e)
+	case FunctionStartsWith, FunctionEndsWith, FunctionContains, FunctionMatches, FunctionEqualsIgnoreCase:
+		return t.transpileStringMatchCallExpr(e)
+	case FunctionSize:
+		return t.transpileSizeCallExpr(e)
 	default:
+		if path, ok := strings.CutSuffix(e.GetCallExpr().GetFunction(), "."+FunctionSize); ok {
+			return t.transpileFieldSizeCallExpr(e, path)
+		}
 		return nil, fmt.Errorf("unsupported function call: %s", e.GetCallExpr().GetFunction())
 	}
 }
 
+func (t *Transpiler) transpileSizeCallExpr(e *expr.Expr) (spansql.Expr, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 1 {
+		return nil, fmt.Errorf("unexpected number of arguments to `%s`: %d", FunctionSize, len(callExpr.GetArgs()))
+	}
+	array, err := t.transpileExpr(callExpr.GetArgs()[0])
+	if err != nil {
+		return nil, err
+	}
+	return arrayLength(array), nil
+}
+
+func (t *Transpiler) transpileFieldSizeCallExpr(e *expr.Expr, path string) (spansql.Expr, error) {
+	if len(e.GetCallExpr().GetArgs()) != 0 {
+		return nil, fmt.Errorf(
+			"unexpected number of arguments to `%s`: %d", e.GetCallExpr().GetFunction(), len(e.GetCallExpr().GetArgs()),
+		)
+	}
+	var array spansql.Expr = spansql.ID(path)
+	if t.options.fieldResolver != nil {
+		var err error
+		if array, err = t.resolveField(path); err != nil {
+			return nil, err
+		}
+	}
+	return arrayLength(array), nil
+}
+
+// arrayLength returns the length of an array, where a NULL array has length 0.
+func arrayLength(array spansql.Expr) spansql.Expr {
+	return spansql.Func{
+		Name: "COALESCE",
+		Args: []spansql.Expr{spansql.Func{Name: "ARRAY_LENGTH", Args: []spansql.Expr{array}}, spansql.IntegerLiteral(0)},
+	}
+}
+
+// jsonArrayElementAlias is the alias of JSON array elements in EXISTS subqueries.
+const jsonArrayElementAlias = "e"
+
+// jsonArrayCall returns the ARRAY<JSON> column of a comparison, has, in or string match call on a field nested in the
+// column.
+func (t *Transpiler) jsonArrayCall(e *expr.Expr) (string, bool) {
+	if t.jsonArrayElement != "" || len(t.options.jsonArrayColumns) == 0 {
+		return "", false
+	}
+	root, ok := elementCallRoot(e)
+	if !ok || !slices.Contains(t.options.jsonArrayColumns, root) {
+		return "", false
+	}
+	return root, true
+}
+
+// elementCallRoot returns the root ident of the field in a comparison, has, in or string match call on a nested
+// field, such as "line_items" in `line_items.title = "pallet"` and `startsWith(line_items.title, "pal")`.
+func elementCallRoot(e *expr.Expr) (string, bool) {
+	switch e.GetCallExpr().GetFunction() {
+	case filtering.FunctionHas,
+		filtering.FunctionEquals,
+		filtering.FunctionNotEquals,
+		filtering.FunctionLessThan,
+		filtering.FunctionLessEquals,
+		filtering.FunctionGreaterThan,
+		filtering.FunctionGreaterEquals,
+		FunctionStartsWith,
+		FunctionEndsWith,
+		FunctionContains,
+		FunctionMatches,
+		FunctionEqualsIgnoreCase,
+		FunctionIn:
+	default:
+		return "", false
+	}
+	if len(e.GetCallExpr().GetArgs()) != 2 || e.GetCallExpr().GetArgs()[0].GetSelectExpr() == nil {
+		return "", false
+	}
+	root := e.GetCallExpr().GetArgs()[0]
+	for root.GetSelectExpr() != nil {
+		root = root.GetSelectExpr().GetOperand()
+	}
+	if root.GetIdentExpr() == nil {
+		return "", false
+	}
+	return root.GetIdentExpr().GetName(), true
+}
+
+// transpileJSONArrayCallExpr transpiles a call on fields nested in an ARRAY<JSON> column to an EXISTS subquery over
+// the elements of the array.
+func (t *Transpiler) transpileJSONArrayCallExpr(e *expr.Expr, column string) (spansql.BoolExpr, error) {
+	var array spansql.Expr = spansql.ID(column)
+	if t.options.fieldResolver != nil {
+		var err error
+		if array, err = t.resolveField(column); err != nil {
+			return nil, err
+		}
+	}
+	t.jsonArrayElement = column
+	defer func() {
+		t.jsonArrayElement = ""
+	}()
+	condition, err := t.transpileCallExpr(e)
+	if err != nil {
+		return nil, err
+	}
+	conditionBoolExpr, ok := condition.(spansql.BoolExpr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected condition on %s: not a bool expr", column)
+	}
+	return spansql.ExistsOp{
+		Subquery: spansql.Query{
+			Select: spansql.Select{
+				List:  []spansql.Expr{spansql.IntegerLiteral(1)},
+				From:  []spansql.SelectFrom{spansql.SelectFromUnnest{Expr: array, Alias: jsonArrayElementAlias}},
+				Where: conditionBoolExpr,
+			},
+		},
+	}, nil
+}
+
+func (t *Transpiler) transpileFunctionCallExpr(e *expr.Expr, function FunctionTranspiler) (spansql.Expr, error) {
+	callExpr := e.GetCallExpr()
+	args := make([]spansql.Expr, 0, len(callExpr.GetArgs()))
+	for _, arg := range callExpr.GetArgs() {
+		argExpr, err := t.transpileExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, argExpr)
+	}
+	var paramErr error
+	result, err := function(args, func(value interface{}) spansql.Param {
+		param, err := t.param(value)
+		if err != nil && paramErr == nil {
+			paramErr = err
+		}
+		return param
+	})
+	if paramErr != nil {
+		return nil, paramErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transpile function call %s: %w", callExpr.GetFunction(), err)
+	}
+	return result, nil
+}
+
 func (t *Transpiler) transpileIdentExpr(e *expr.Expr) (spansql.Expr, error) {
 	identExpr := e.GetIdentExpr()
-	identType, ok := t.filter.CheckedExpr.GetTypeMap()[e.GetId()]
-	if !ok {
+	if _, ok := t.filter.CheckedExpr.GetTypeMap()[e.GetId()]; !ok {
 		return nil, fmt.Errorf("unknown type of ident expr %d", e.GetId())
 	}
-	if messageType := identType.GetMessageType(); messageType != "" {
-		if enumType, err := protoregistry.GlobalTypes.FindEnumByName(protoreflect.FullName(messageType)); err == nil {
-			if enumValue := enumType.Descriptor().Values().ByName(protoreflect.Name(identExpr.GetName())); enumValue != nil {
-				if t.options.enumValuesAsStrings {
-					return t.param(string(enumValue.Name())), nil
-				}
-				// spanner does not support int32
-				return t.param(int64(enumValue.Number())), nil
-			}
-		}
+	if value, ok := t.enumValue(e); ok {
+		return t.param(value)
+	}
+	if t.options.fieldResolver != nil {
+		return t.resolveField(identExpr.GetName())
 	}
 	return spansql.ID(identExpr.GetName()), nil
 }
 
+func (t *Transpiler) resolveField(path string) (spansql.Expr, error) {
+	result, ok := t.options.fieldResolver(path)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported field in filter: %s", path)
+	}
+	return result, nil
+}
+
+// enumValue returns the parameter value of an ident expr referring to an enum value.
+func (t *Transpiler) enumValue(e *expr.Expr) (interface{}, bool) {
+	identType, ok := t.filter.CheckedExpr.GetTypeMap()[e.GetId()]
+	if !ok || e.GetIdentExpr() == nil {
+		return nil, false
+	}
+	messageType := identType.GetMessageType()
+	if messageType == "" {
+		return nil, false
+	}
+	enumType, err := protoregistry.GlobalTypes.FindEnumByName(protoreflect.FullName(messageType))
+	if err != nil {
+		return nil, false
+	}
+	enumValue := enumType.Descriptor().Values().ByName(protoreflect.Name(e.GetIdentExpr().GetName()))
+	if enumValue == nil {
+		return nil, false
+	}
+	if t.options.enumValuesAsStrings {
+		return string(enumValue.Name()), true
+	}
+	// spanner does not support int32
+	return int64(enumValue.Number()), true
+}
+
 func (t *Transpiler) transpileSelectExpr(e *expr.Expr) (spansql.Expr, error) {
 	selectExpr := e.GetSelectExpr()
+	if t.interleavedTableField(e) {
+		return t.interleavedTableColumn(e)
+	}
+	if column, path, ok := t.jsonField(e); ok {
+		return t.transpileJSONField(e, column, path)
+	}
+	if column, ok := t.jsonArrayField(e); ok {
+		return nil, status.Errorf(
+			codes.InvalidArgument,
+			"unsupported use of field %s in filter: fields of ARRAY<JSON> column %s are only supported in comparisons, "+
+				"has, in and string match calls",
+			selectExprPath(e),
+			column,
+		)
+	}
+	if t.options.fieldResolver != nil {
+		path, ok := t.fieldPath(e)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported field in filter: %s", selectExprPath(e))
+		}
+		return t.resolveField(path)
+	}
 	operand, err := t.transpileExpr(selectExpr.GetOperand())
 	if err != nil {
 		return nil, err
@@ -187,8 +832,90 @@ func (t *Transpiler) transpileSelectExpr(e *expr.Expr) (spansql.Expr, error) {
 	case spansql.ID:
 		return spansql.PathExp{operand, spansql.ID(selectExpr.GetField())}, nil
 	default:
-		return nil, fmt.Errorf("unsupported select expr operand")
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported field in filter: %s", selectExprPath(e))
+	}
+}
+
+// selectExprPath returns the dot-separated path of a select expr, for error messages.
+func selectExprPath(e *expr.Expr) string {
+	var path []string
+	for ; e.GetSelectExpr() != nil; e = e.GetSelectExpr().GetOperand() {
+		path = append([]string{e.GetSelectExpr().GetField()}, path...)
 	}
+	if e.GetIdentExpr() != nil {
+		path = append([]string{e.GetIdentExpr().GetName()}, path...)
+	}
+	return strings.Join(path, ".")
+}
+
+// jsonField returns the JSON column and the nested field path of a select expr rooted at a JSON column.
+func (t *Transpiler) jsonField(e *expr.Expr) (string, []string, bool) {
+	var path []string
+	for e.GetSelectExpr() != nil {
+		path = append([]string{e.GetSelectExpr().GetField()}, path...)
+		e = e.GetSelectExpr().GetOperand()
+	}
+	if e.GetIdentExpr() == nil || len(path) == 0 {
+		return "", nil, false
+	}
+	column := e.GetIdentExpr().GetName()
+	if column != t.jsonArrayElement && !slices.Contains(t.options.jsonColumns, column) {
+		return "", nil, false
+	}
+	return column, path, true
+}
+
+// jsonArrayField returns the ARRAY<JSON> column of a select expr rooted at the column, outside of a condition on the
+// elements of the column.
+func (t *Transpiler) jsonArrayField(e *expr.Expr) (string, bool) {
+	for e.GetSelectExpr() != nil {
+		e = e.GetSelectExpr().GetOperand()
+	}
+	column := e.GetIdentExpr().GetName()
+	if e.GetIdentExpr() == nil || column == t.jsonArrayElement || !slices.Contains(t.options.jsonArrayColumns, column) {
+		return "", false
+	}
+	return column, true
+}
+
+func (t *Transpiler) transpileJSONField(e *expr.Expr, column string, path []string) (spansql.Expr, error) {
+	var columnExpr spansql.Expr = spansql.ID(column)
+	if column == t.jsonArrayElement {
+		columnExpr = spansql.ID(jsonArrayElementAlias)
+	} else if t.options.fieldResolver != nil {
+		var err error
+		if columnExpr, err = t.resolveField(column); err != nil {
+			return nil, err
+		}
+	}
+	value := spansql.Func{
+		Name: "JSON_VALUE",
+		Args: []spansql.Expr{columnExpr, spansql.StringLiteral("$." + strings.Join(path, "."))},
+	}
+	fieldType := t.filter.CheckedExpr.GetTypeMap()[e.GetId()]
+	var base spansql.TypeBase
+	switch {
+	case fieldType.GetPrimitive() == expr.Type_STRING:
+		return value, nil
+	case fieldType.GetPrimitive() == expr.Type_INT64, fieldType.GetPrimitive() == expr.Type_UINT64:
+		base = spansql.Int64
+	case fieldType.GetPrimitive() == expr.Type_DOUBLE:
+		base = spansql.Float64
+	case fieldType.GetPrimitive() == expr.Type_BOOL:
+		base = spansql.Bool
+	case fieldType.GetWellKnown() == expr.Type_TIMESTAMP:
+		base = spansql.Timestamp
+	case fieldType.GetMessageType() == TypeDate.GetMessageType():
+		base = spansql.Date
+	default:
+		return nil, status.Errorf(
+			codes.Internal, "unsupported type of JSON field %s.%s: %v", column, strings.Join(path, "."), fieldType,
+		)
+	}
+	return spansql.Func{
+		Name: "CAST",
+		Args: []spansql.Expr{spansql.TypedExpr{Type: spansql.Type{Base: base}, Expr: value}},
+	}, nil
 }
 
 func (t *Transpiler) transpileNotCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
@@ -230,7 +957,7 @@ func (t *Transpiler) transpileComparisonCallExpr(
 	if err != nil {
 		return nil, err
 	}
-	rhsExpr, err := t.transpileExpr(callExpr.GetArgs()[1])
+	rhsExpr, err := t.transpileComparisonRHS(callExpr.GetArgs()[0], callExpr.GetArgs()[1])
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +968,49 @@ func (t *Transpiler) transpileComparisonCallExpr(
 	}, nil
 }
 
+// transpileComparisonRHS transpiles the right-hand side of a comparison.
+// String literals compared with timestamps and dates are bound as TIMESTAMP and DATE parameters. Escaped asterisks and
+// backslashes in other string literals are unescaped, with any comparison operator, so that `\*` is a literal asterisk
+// both where asterisks can be wildcards and where they can't.
+func (t *Transpiler) transpileComparisonRHS(lhs, rhs *expr.Expr) (spansql.Expr, error) {
+	stringValue, ok := rhs.GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	if !ok {
+		return t.transpileExpr(rhs)
+	}
+	value, err := t.typedStringValue(lhs, stringValue.StringValue)
+	if err != nil {
+		return nil, err
+	}
+	if stringValue, ok := value.(string); ok {
+		value = unescapeWildcards(stringValue)
+	}
+	return t.param(value)
+}
+
+// typedStringValue returns the value of a string literal compared with the field lhs.
+// String literals compared with timestamps and dates are parsed as TIMESTAMP and DATE values.
+func (t *Transpiler) typedStringValue(lhs *expr.Expr, value string) (interface{}, error) {
+	lhsType := t.filter.CheckedExpr.GetTypeMap()[lhs.GetId()]
+	switch {
+	case lhsType.GetWellKnown() == expr.Type_TIMESTAMP:
+		result, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", value, err)
+		}
+		return result, nil
+	case lhsType.GetMessageType() == TypeDate.GetMessageType():
+		result, err := civil.ParseDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %q: %w", value, err)
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// isSubstringMatchExpr reports whether e is an equality comparison between a field and a string value with a leading
+// or trailing wildcard, such as `author = "*Boye"`.
 func (t *Transpiler) isSubstringMatchExpr(
 	e *expr.Expr,
 ) bool {
@@ -248,7 +1018,7 @@ func (t *Transpiler) isSubstringMatchExpr(
 		return false
 	}
 	lhs := e.GetCallExpr().GetArgs()[0]
-	if lhs.GetIdentExpr() == nil {
+	if lhs.GetIdentExpr() == nil && lhs.GetSelectExpr() == nil {
 		return false
 	}
 	rhs := e.GetCallExpr().GetArgs()[1]
@@ -259,24 +1029,84 @@ func (t *Transpiler) isSubstringMatchExpr(
 	if !ok {
 		return false
 	}
-	return strings.HasPrefix(rhsStringExpr.StringValue, "*") || strings.HasSuffix(rhsStringExpr.StringValue, "*")
+	_, leading, trailing, err := parseWildcardValue(rhsStringExpr.StringValue)
+	return err != nil || leading || trailing
 }
 
 func (t *Transpiler) transpileSubstringMatchExpr(e *expr.Expr) (spansql.BoolExpr, error) {
 	lhs := e.GetCallExpr().GetArgs()[0]
 	rhs := e.GetCallExpr().GetArgs()[1]
 	rhsString := rhs.GetConstExpr().GetConstantKind().(*expr.Constant_StringValue).StringValue
-	if strings.Contains(strings.TrimSuffix(strings.TrimPrefix(rhsString, "*"), "*"), "*") {
-		return nil, fmt.Errorf(
-			"unsupported argument to `%s`: wildcard only supported in leading or trailing positions",
-			e.GetCallExpr().GetFunction(),
-		)
+	literal, leading, trailing, err := parseWildcardValue(rhsString)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported argument to `%s`: %w", e.GetCallExpr().GetFunction(), err)
 	}
-	return spansql.ComparisonOp{
-		Op:  spansql.Like,
-		LHS: spansql.ID(lhs.GetIdentExpr().GetName()),
-		RHS: t.param(strings.ReplaceAll(rhsString, "*", "%")),
-	}, nil
+	t.wildcardTermCount++
+	if t.options.maxWildcardTerms > 0 && t.wildcardTermCount > t.options.maxWildcardTerms {
+		return nil, &LimitError{Limit: LimitWildcardTerms, Max: t.options.maxWildcardTerms}
+	}
+	lhsExpr, err := t.transpileExpr(lhs)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := t.param(likePattern(literal, leading, trailing))
+	if err != nil {
+		return nil, err
+	}
+	return spansql.ComparisonOp{Op: spansql.Like, LHS: lhsExpr, RHS: pattern}, nil
+}
+
+// parseWildcardValue parses a string value compared with a field.
+//
+// An unescaped leading or trailing asterisk is a wildcard. Within the value, `\*` is a literal asterisk and `\\` is a
+// literal backslash. Other asterisks are literal, unless the value has a wildcard.
+func parseWildcardValue(value string) (literal string, leading, trailing bool, err error) {
+	var result strings.Builder
+	var inner bool
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value) && (value[i+1] == '*' || value[i+1] == '\\'):
+			i++
+			_ = result.WriteByte(value[i])
+		case c == '*' && i == 0:
+			leading = true
+		case c == '*' && i == len(value)-1:
+			trailing = true
+		case c == '*':
+			inner = true
+			_ = result.WriteByte(c)
+		default:
+			_ = result.WriteByte(c)
+		}
+	}
+	if inner && (leading || trailing) {
+		return "", false, false, fmt.Errorf("wildcard only supported in leading or trailing positions")
+	}
+	return result.String(), leading, trailing, nil
+}
+
+// wildcardUnescaper unescapes literal asterisks and backslashes in string values.
+var wildcardUnescaper = strings.NewReplacer(`\\`, `\`, `\*`, `*`)
+
+// unescapeWildcards unescapes literal asterisks and backslashes in a string value without wildcards.
+func unescapeWildcards(value string) string {
+	return wildcardUnescaper.Replace(value)
+}
+
+// likeEscaper escapes the metacharacters of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likePattern returns a LIKE pattern matching the literal value, with optional leading and trailing wildcards.
+func likePattern(literal string, leading, trailing bool) string {
+	var result strings.Builder
+	if leading {
+		_ = result.WriteByte('%')
+	}
+	_, _ = likeEscaper.WriteString(&result, literal)
+	if trailing {
+		_ = result.WriteByte('%')
+	}
+	return result.String()
 }
 
 func (t *Transpiler) transpileBinaryLogicalCallExpr(
@@ -326,6 +1156,15 @@ func (t *Transpiler) transpileHasCallExpr(e *expr.Expr) (spansql.BoolExpr, error
 	}
 	identExpr := callExpr.GetArgs()[0]
 	constExpr := callExpr.GetArgs()[1]
+	if identExpr.GetSelectExpr() != nil && (t.jsonArrayElement != "" || t.interleavedTable != nil) {
+		return t.transpileElementHasCallExpr(identExpr, constExpr)
+	}
+	if table, ok := t.interleavedTablePath(identExpr); ok {
+		if !isHasWildcard(constExpr) {
+			return nil, fmt.Errorf("unsupported: HAS operator on interleaved table only supports wildcard (:*)")
+		}
+		return t.interleavedTableExists(table, nil)
+	}
 	if identExpr.GetIdentExpr() == nil {
 		return nil, fmt.Errorf("TODO: add support for transpiling `:` where LHS is other than Ident")
 	}
@@ -337,6 +1176,17 @@ func (t *Transpiler) transpileHasCallExpr(e *expr.Expr) (spansql.BoolExpr, error
 		return nil, fmt.Errorf("unknown type of ident expr %d", e.GetId())
 	}
 	switch {
+	// Repeated fields: wildcard checks presence (non-null and non-empty).
+	case identType.GetListType() != nil && isHasWildcard(constExpr):
+		iden, err := t.transpileIdentExpr(identExpr)
+		if err != nil {
+			return nil, err
+		}
+		return spansql.ComparisonOp{
+			Op:  spansql.Gt,
+			LHS: spansql.Func{Name: "ARRAY_LENGTH", Args: []spansql.Expr{iden}},
+			RHS: spansql.IntegerLiteral(0),
+		}, nil
 	// Repeated primitives:
 	// > Repeated fields query to see if the repeated structure contains a matching element.
 	case identType.GetListType().GetElemType().GetPrimitive() != expr.Type_PRIMITIVE_TYPE_UNSPECIFIED:
@@ -362,10 +1212,14 @@ func (t *Transpiler) transpileHasCallExpr(e *expr.Expr) (spansql.BoolExpr, error
 		if err != nil {
 			return nil, err
 		}
+		empty, err := t.param("")
+		if err != nil {
+			return nil, err
+		}
 		return spansql.LogicalOp{
 			Op:  spansql.And,
 			LHS: spansql.IsOp{LHS: col, Neg: true, RHS: spansql.Null},
-			RHS: spansql.ComparisonOp{Op: spansql.Ne, LHS: col, RHS: t.param("")},
+			RHS: spansql.ComparisonOp{Op: spansql.Ne, LHS: col, RHS: empty},
 		}, nil
 	// Timestamp: wildcard checks presence (non-null and not the proto default).
 	// The proto default for google.protobuf.Timestamp is UTC Epoch (seconds: 0, nanos: 0).
@@ -377,10 +1231,14 @@ func (t *Transpiler) transpileHasCallExpr(e *expr.Expr) (spansql.BoolExpr, error
 		if err != nil {
 			return nil, err
 		}
+		epoch, err := t.param(time.Unix(0, 0).UTC())
+		if err != nil {
+			return nil, err
+		}
 		return spansql.LogicalOp{
 			Op:  spansql.And,
 			LHS: spansql.IsOp{LHS: col, Neg: true, RHS: spansql.Null},
-			RHS: spansql.ComparisonOp{Op: spansql.Ne, LHS: col, RHS: t.param(time.Unix(0, 0).UTC())},
+			RHS: spansql.ComparisonOp{Op: spansql.Ne, LHS: col, RHS: epoch},
 		}, nil
 	default:
 		return nil, fmt.Errorf(
@@ -389,38 +1247,382 @@ func (t *Transpiler) transpileHasCallExpr(e *expr.Expr) (spansql.BoolExpr, error
 	}
 }
 
+// transpileElementHasCallExpr transpiles `:` on a field of a JSON array element or an interleaved table row, where a
+// wildcard checks presence and other values check equality.
+func (t *Transpiler) transpileElementHasCallExpr(field, value *expr.Expr) (spansql.BoolExpr, error) {
+	fieldExpr, err := t.transpileSelectExpr(field)
+	if err != nil {
+		return nil, err
+	}
+	if isHasWildcard(value) {
+		return spansql.IsOp{LHS: fieldExpr, Neg: true, RHS: spansql.Null}, nil
+	}
+	valueExpr, err := t.transpileComparisonRHS(field, value)
+	if err != nil {
+		return nil, err
+	}
+	return spansql.ComparisonOp{Op: spansql.Eq, LHS: fieldExpr, RHS: valueExpr}, nil
+}
+
 func (t *Transpiler) transpileTimestampCallExpr(e *expr.Expr) (spansql.Expr, error) {
+	value, err := t.timestampValue(e)
+	if err != nil {
+		return nil, err
+	}
+	return t.param(value)
+}
+
+func (t *Transpiler) timestampValue(e *expr.Expr) (time.Time, error) {
 	callExpr := e.GetCallExpr()
 	if len(callExpr.GetArgs()) != 1 {
-		return nil, fmt.Errorf(
+		return time.Time{}, fmt.Errorf(
 			"unexpected number of arguments to `%s`: %d", callExpr.GetFunction(), len(callExpr.GetArgs()),
 		)
 	}
 	constArg, ok := callExpr.GetArgs()[0].GetExprKind().(*expr.Expr_ConstExpr)
 	if !ok {
-		return nil, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
+		return time.Time{}, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
 	}
 	stringArg, ok := constArg.ConstExpr.GetConstantKind().(*expr.Constant_StringValue)
 	if !ok {
-		return nil, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
+		return time.Time{}, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
 	}
 	timeArg, err := time.Parse(time.RFC3339, stringArg.StringValue)
 	if err != nil {
-		return nil, fmt.Errorf("invalid string arg to %s: %w", callExpr.GetFunction(), err)
+		return time.Time{}, fmt.Errorf("invalid string arg to %s: %w", callExpr.GetFunction(), err)
+	}
+	return timeArg, nil
+}
+
+func (t *Transpiler) transpileNowCallExpr(e *expr.Expr) (spansql.Expr, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 0 {
+		return nil, fmt.Errorf("unexpected number of arguments to `%s`: %d", callExpr.GetFunction(), len(callExpr.GetArgs()))
+	}
+	if t.options.now.IsZero() {
+		return nil, status.Errorf(
+			codes.Internal, "unsupported function call: %s: no value bound with WithNow", callExpr.GetFunction(),
+		)
+	}
+	return t.param(t.options.now)
+}
+
+func (t *Transpiler) transpileTimestampArithmeticCallExpr(e *expr.Expr, function string) (spansql.Expr, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to `%s`: %d", callExpr.GetFunction(), len(callExpr.GetArgs()))
+	}
+	timestampExpr, err := t.transpileExpr(callExpr.GetArgs()[0])
+	if err != nil {
+		return nil, err
+	}
+	duration, err := t.durationValue(callExpr.GetArgs()[1])
+	if err != nil {
+		return nil, err
+	}
+	microseconds, err := t.param(duration.Microseconds())
+	if err != nil {
+		return nil, err
+	}
+	return spansql.Func{
+		Name: function,
+		Args: []spansql.Expr{
+			timestampExpr,
+			spansql.IntervalExpr{Expr: microseconds, DatePart: "MICROSECOND"},
+		},
+	}, nil
+}
+
+func (t *Transpiler) durationValue(e *expr.Expr) (time.Duration, error) {
+	callExpr := e.GetCallExpr()
+	if callExpr.GetFunction() != filtering.FunctionDuration || len(callExpr.GetArgs()) != 1 {
+		return 0, fmt.Errorf("unsupported duration: only `%s` literals are supported", filtering.FunctionDuration)
+	}
+	stringArg, ok := callExpr.GetArgs()[0].GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	if !ok {
+		return 0, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
+	}
+	duration, err := time.ParseDuration(stringArg.StringValue)
+	if err != nil {
+		return 0, fmt.Errorf("invalid string arg to %s: %w", callExpr.GetFunction(), err)
+	}
+	return duration, nil
+}
+
+func (t *Transpiler) transpileDateCallExpr(e *expr.Expr) (spansql.Expr, error) {
+	value, err := t.dateValue(e)
+	if err != nil {
+		return nil, err
+	}
+	return t.param(value)
+}
+
+func (t *Transpiler) dateValue(e *expr.Expr) (civil.Date, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 1 {
+		return civil.Date{}, fmt.Errorf(
+			"unexpected number of arguments to `%s`: %d", callExpr.GetFunction(), len(callExpr.GetArgs()),
+		)
+	}
+	stringArg, ok := callExpr.GetArgs()[0].GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	if !ok {
+		return civil.Date{}, fmt.Errorf("expected constant string arg to %s", callExpr.GetFunction())
+	}
+	date, err := civil.ParseDate(stringArg.StringValue)
+	if err != nil {
+		return civil.Date{}, fmt.Errorf("invalid string arg to %s: %w", callExpr.GetFunction(), err)
+	}
+	return date, nil
+}
+
+// literalValue returns the parameter value of a literal compared for equality with the field lhs: a constant, an enum
+// value, or a timestamp or date literal. String constants are converted with typedStringValue, and escaped asterisks
+// and backslashes in other string constants are unescaped, as in equality comparisons.
+//
+// The result is false when e is not a literal, and an error is returned for invalid literals.
+func (t *Transpiler) literalValue(lhs, e *expr.Expr) (interface{}, bool, error) {
+	switch {
+	case e.GetConstExpr() != nil:
+		value, err := t.constValue(e)
+		if err != nil {
+			return nil, false, err
+		}
+		if stringValue, ok := value.(string); ok {
+			if value, err = t.typedStringValue(lhs, stringValue); err != nil {
+				return nil, false, err
+			}
+		}
+		if stringValue, ok := value.(string); ok {
+			value = unescapeWildcards(stringValue)
+		}
+		return value, true, nil
+	case e.GetIdentExpr() != nil:
+		value, ok := t.enumValue(e)
+		return value, ok, nil
+	case e.GetCallExpr().GetFunction() == filtering.FunctionTimestamp:
+		value, err := t.timestampValue(e)
+		return value, err == nil, err
+	case e.GetCallExpr().GetFunction() == FunctionDate:
+		value, err := t.dateValue(e)
+		return value, err == nil, err
+	default:
+		return nil, false, nil
+	}
+}
+
+// fieldPath returns the dot-separated field path of an ident or select expr that does not refer to an enum value.
+func (t *Transpiler) fieldPath(e *expr.Expr) (string, bool) {
+	switch {
+	case e.GetIdentExpr() != nil:
+		if _, ok := t.enumValue(e); ok {
+			return "", false
+		}
+		return e.GetIdentExpr().GetName(), true
+	case e.GetSelectExpr() != nil:
+		operand, ok := t.fieldPath(e.GetSelectExpr().GetOperand())
+		if !ok {
+			return "", false
+		}
+		return operand + "." + e.GetSelectExpr().GetField(), true
+	default:
+		return "", false
+	}
+}
+
+// equalityDisjunction returns the field and the values of a disjunction of two or more equality comparisons between
+// the same field and literal values, such as `state = ACTIVE OR state = PENDING`.
+func (t *Transpiler) equalityDisjunction(e *expr.Expr) (*expr.Expr, []interface{}, bool) {
+	var lhs *expr.Expr
+	var lhsPath string
+	var values []interface{}
+	var collect func(e *expr.Expr) bool
+	collect = func(e *expr.Expr) bool {
+		callExpr := e.GetCallExpr()
+		if len(callExpr.GetArgs()) != 2 {
+			return false
+		}
+		switch callExpr.GetFunction() {
+		case filtering.FunctionOr:
+			return collect(callExpr.GetArgs()[0]) && collect(callExpr.GetArgs()[1])
+		case filtering.FunctionEquals:
+			if t.isSubstringMatchExpr(e) {
+				return false
+			}
+			// Comparisons on fields of ARRAY<JSON> elements and interleaved table rows are transpiled to EXISTS
+			// subqueries one by one.
+			if _, ok := t.jsonArrayCall(e); ok {
+				return false
+			}
+			if _, ok := t.interleavedTableCall(e); ok {
+				return false
+			}
+			path, ok := t.fieldPath(callExpr.GetArgs()[0])
+			if !ok || (lhs != nil && path != lhsPath) {
+				return false
+			}
+			// Invalid literals are reported when the comparisons are transpiled one by one.
+			value, ok, err := t.literalValue(callExpr.GetArgs()[0], callExpr.GetArgs()[1])
+			if !ok || err != nil {
+				return false
+			}
+			lhs, lhsPath = callExpr.GetArgs()[0], path
+			values = append(values, value)
+			return true
+		default:
+			return false
+		}
+	}
+	if !collect(e) || len(values) < 2 {
+		return nil, nil, false
+	}
+	return lhs, values, true
+}
+
+func (t *Transpiler) transpileInCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 2 {
+		return nil, fmt.Errorf("unexpected number of arguments to `in`: %d", len(callExpr.GetArgs()))
+	}
+	lhs, rhs := callExpr.GetArgs()[0], callExpr.GetArgs()[1]
+	listExpr := rhs.GetListExpr()
+	if listExpr == nil {
+		lhsExpr, err := t.transpileExpr(lhs)
+		if err != nil {
+			return nil, err
+		}
+		rhsExpr, err := t.transpileExpr(rhs)
+		if err != nil {
+			return nil, err
+		}
+		return spansql.InOp{LHS: lhsExpr, Unnest: true, RHS: []spansql.Expr{rhsExpr}}, nil
+	}
+	if len(listExpr.GetElements()) == 0 {
+		return spansql.False, nil
+	}
+	values := make([]interface{}, 0, len(listExpr.GetElements()))
+	for _, element := range listExpr.GetElements() {
+		value, ok, err := t.literalValue(lhs, element)
+		if err != nil {
+			return nil, fmt.Errorf("invalid element in `in` list: %w", err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("unsupported element in `in` list: only literal values are supported")
+		}
+		values = append(values, value)
+	}
+	return t.transpileInList(lhs, values)
+}
+
+func (t *Transpiler) transpileInList(lhs *expr.Expr, values []interface{}) (spansql.BoolExpr, error) {
+	lhsExpr, err := t.transpileExpr(lhs)
+	if err != nil {
+		return nil, err
+	}
+	array, err := arrayParam(values)
+	if err != nil {
+		return nil, err
 	}
-	return t.param(timeArg), nil
+	valuesParam, err := t.param(array)
+	if err != nil {
+		return nil, err
+	}
+	return spansql.InOp{LHS: lhsExpr, Unnest: true, RHS: []spansql.Expr{valuesParam}}, nil
+}
+
+// arrayParam converts a list of literal values of the same type to a typed array parameter value.
+func arrayParam(values []interface{}) (interface{}, error) {
+	switch values[0].(type) {
+	case string:
+		return typedArray[string](values)
+	case int64:
+		return typedArray[int64](values)
+	case float64:
+		return typedArray[float64](values)
+	case bool:
+		return typedArray[bool](values)
+	case time.Time:
+		return typedArray[time.Time](values)
+	case civil.Date:
+		return typedArray[civil.Date](values)
+	default:
+		return nil, fmt.Errorf("unsupported list element type %T", values[0])
+	}
+}
+
+func typedArray[T any](values []interface{}) ([]T, error) {
+	result := make([]T, 0, len(values))
+	for _, value := range values {
+		typed, ok := value.(T)
+		if !ok {
+			return nil, fmt.Errorf("mixed list element types %T and %T", values[0], value)
+		}
+		result = append(result, typed)
+	}
+	return result, nil
 }
 
-func (t *Transpiler) param(param interface{}) spansql.Param {
-	p := t.nextParam()
+// param allocates a query parameter with the provided value, and fails if the parameter exceeds the maximum number
+// of parameters configured with WithMaxParams.
+func (t *Transpiler) param(param interface{}) (spansql.Param, error) {
+	p, err := t.nextParam()
+	if err != nil {
+		return "", err
+	}
 	t.params[p] = param
-	return spansql.Param(p)
+	return spansql.Param(p), nil
 }
 
-func (t *Transpiler) nextParam() string {
-	param := "param_" + strconv.Itoa(t.paramCounter)
+func (t *Transpiler) nextParam() (string, error) {
+	if t.options.maxParams > 0 && t.paramCounter >= t.options.maxParams {
+		return "", &LimitError{Limit: LimitParams, Max: t.options.maxParams}
+	}
+	param := t.options.paramPrefix + strconv.Itoa(t.paramCounter)
 	t.paramCounter++
-	return param
+	return param, nil
+}
+
+func (t *Transpiler) transpileStringMatchCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
+	callExpr := e.GetCallExpr()
+	if len(callExpr.GetArgs()) != 2 {
+		return nil, fmt.Errorf(
+			"unexpected number of arguments to `%s`: %d", callExpr.GetFunction(), len(callExpr.GetArgs()),
+		)
+	}
+	if callExpr.GetFunction() == FunctionMatches {
+		if pattern, ok := callExpr.GetArgs()[1].GetConstExpr().GetConstantKind().(*expr.Constant_StringValue); ok {
+			if _, err := regexp.Compile(pattern.StringValue); err != nil {
+				return nil, fmt.Errorf("invalid pattern in `%s`: %w", callExpr.GetFunction(), err)
+			}
+		}
+	}
+	lhsExpr, err := t.transpileExpr(callExpr.GetArgs()[0])
+	if err != nil {
+		return nil, err
+	}
+	rhsExpr, err := t.transpileExpr(callExpr.GetArgs()[1])
+	if err != nil {
+		return nil, err
+	}
+	switch callExpr.GetFunction() {
+	case FunctionStartsWith:
+		return spansql.Func{Name: "STARTS_WITH", Args: []spansql.Expr{lhsExpr, rhsExpr}}, nil
+	case FunctionEndsWith:
+		return spansql.Func{Name: "ENDS_WITH", Args: []spansql.Expr{lhsExpr, rhsExpr}}, nil
+	case FunctionContains:
+		return spansql.ComparisonOp{
+			Op:  spansql.Gt,
+			LHS: spansql.Func{Name: "STRPOS", Args: []spansql.Expr{lhsExpr, rhsExpr}},
+			RHS: spansql.IntegerLiteral(0),
+		}, nil
+	case FunctionMatches:
+		return spansql.Func{Name: "REGEXP_CONTAINS", Args: []spansql.Expr{lhsExpr, rhsExpr}}, nil
+	default:
+		return spansql.ComparisonOp{
+			Op:  spansql.Eq,
+			LHS: spansql.Func{Name: "LOWER", Args: []spansql.Expr{lhsExpr}},
+			RHS: spansql.Func{Name: "LOWER", Args: []spansql.Expr{rhsExpr}},
+		}, nil
+	}
 }
 
 func (t *Transpiler) transpileSearchNgramsCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
@@ -433,11 +1635,13 @@ func (t *Transpiler) transpileSearchNgramsCallExpr(e *expr.Expr) (spansql.BoolEx
 		)
 	}
 	// Arg 0: column identifier
-	identExpr := args[0].GetIdentExpr()
-	if identExpr == nil {
+	if args[0].GetIdentExpr() == nil {
 		return nil, fmt.Errorf("first argument to %s must be an identifier", callExpr.GetFunction())
 	}
-	tokenColumn := spansql.ID(identExpr.GetName())
+	tokenColumn, err := t.transpileIdentExpr(args[0])
+	if err != nil {
+		return nil, err
+	}
 	// Arg 1: ngrams_query string, must be at least 2 characters.
 	queryConst := args[1].GetConstExpr()
 	if queryConst == nil {
@@ -490,3 +1694,90 @@ func (t *Transpiler) transpileSearchNgramsCallExpr(e *expr.Expr) (spansql.BoolEx
 	}
 	return spansql.Func{Name: "SEARCH_NGRAMS", Args: sqlArgs}, nil
 }
+
+func (t *Transpiler) transpileSearchCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
+	callExpr := e.GetCallExpr()
+	args := callExpr.GetArgs()
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf(
+			"unexpected number of arguments to %s: %d (expected 2 or 3)",
+			callExpr.GetFunction(), len(args),
+		)
+	}
+	sqlArgs, err := t.transpileSearchArgs(callExpr)
+	if err != nil {
+		return nil, err
+	}
+	if t.options.searchEnhanceQuery {
+		enhanceQuery, err := t.param(true)
+		if err != nil {
+			return nil, err
+		}
+		sqlArgs = append(sqlArgs, spansql.DefinitionExpr{Key: "enhance_query", Value: enhanceQuery})
+	}
+	// 3-arg form: optional named parameters.
+	if len(args) == 3 {
+		// Arg 2: language_tag (string, skip if empty).
+		if sqlArgs, err = t.appendLanguageTagArg(sqlArgs, args[2]); err != nil {
+			return nil, err
+		}
+	}
+	return spansql.Func{Name: "SEARCH", Args: sqlArgs}, nil
+}
+
+func (t *Transpiler) transpileSearchSubstringCallExpr(e *expr.Expr) (spansql.BoolExpr, error) {
+	callExpr := e.GetCallExpr()
+	args := callExpr.GetArgs()
+	if len(args) != 2 && len(args) != 3 {
+		return nil, fmt.Errorf(
+			"unexpected number of arguments to %s: %d (expected 2 or 3)",
+			callExpr.GetFunction(), len(args),
+		)
+	}
+	sqlArgs, err := t.transpileSearchArgs(callExpr)
+	if err != nil {
+		return nil, err
+	}
+	// 3-arg form: optional named parameters.
+	if len(args) == 3 {
+		// Arg 2: language_tag (string, skip if empty).
+		if sqlArgs, err = t.appendLanguageTagArg(sqlArgs, args[2]); err != nil {
+			return nil, err
+		}
+	}
+	return spansql.Func{Name: "SEARCH_SUBSTRING", Args: sqlArgs}, nil
+}
+
+// transpileSearchArgs transpiles the required column and query arguments of a search function.
+func (t *Transpiler) transpileSearchArgs(callExpr *expr.Expr_Call) ([]spansql.Expr, error) {
+	args := callExpr.GetArgs()
+	// Arg 0: column identifier
+	if args[0].GetIdentExpr() == nil {
+		return nil, fmt.Errorf("first argument to %s must be an identifier", callExpr.GetFunction())
+	}
+	tokenColumn, err := t.transpileIdentExpr(args[0])
+	if err != nil {
+		return nil, err
+	}
+	// Arg 1: query string
+	if _, ok := args[1].GetConstExpr().GetConstantKind().(*expr.Constant_StringValue); !ok {
+		return nil, fmt.Errorf("second argument to %s must be a string constant", callExpr.GetFunction())
+	}
+	queryParam, err := t.transpileConstExpr(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return []spansql.Expr{tokenColumn, queryParam}, nil
+}
+
+func (t *Transpiler) appendLanguageTagArg(sqlArgs []spansql.Expr, arg *expr.Expr) ([]spansql.Expr, error) {
+	langConst, ok := arg.GetConstExpr().GetConstantKind().(*expr.Constant_StringValue)
+	if !ok || langConst.StringValue == "" {
+		return sqlArgs, nil
+	}
+	langParam, err := t.transpileConstExpr(arg)
+	if err != nil {
+		return nil, err
+	}
+	return append(sqlArgs, spansql.DefinitionExpr{Key: "language_tag", Value: langParam}), nil
+}
@@ -0,0 +1,156 @@
+package spanfiltering
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"go.einride.tech/aip/filtering"
+	expr "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	"gotest.tools/v3/assert"
+)
+
+// FuzzTranspileFilter_wildcard checks that the transpiled LIKE patterns match the same strings as the AIP-160
+// wildcard semantics of the filter values.
+func FuzzTranspileFilter_wildcard(f *testing.F) {
+	f.Add("Karin", "Karin Boye", false, true)
+	f.Add("Boye", "Karin Boye", true, false)
+	f.Add("rin B", "Karin Boye", true, true)
+	f.Add("50%_off", "50% off", false, true)
+	f.Add("50%_off", "50%_off!", false, true)
+	f.Add(`C:\Program Files*`, `C:\Program Files* (x86)`, true, true)
+	f.Add("*", "*", false, false)
+	f.Add("", "anything", true, false)
+	f.Add(`\`, `\`, false, false)
+	f.Fuzz(func(t *testing.T, literal, candidate string, leading, trailing bool) {
+		if !utf8.ValidString(literal) || !utf8.ValidString(candidate) {
+			t.Skip()
+		}
+		value := strings.NewReplacer(`\`, `\\`, `*`, `\*`).Replace(literal)
+		if leading {
+			value = "*" + value
+		}
+		if trailing {
+			value += "*"
+		}
+		filter := filtering.Filter{
+			CheckedExpr: &expr.CheckedExpr{
+				Expr: &expr.Expr{Id: 1, ExprKind: &expr.Expr_CallExpr{CallExpr: &expr.Expr_Call{
+					Function: filtering.FunctionEquals,
+					Args: []*expr.Expr{
+						{Id: 2, ExprKind: &expr.Expr_IdentExpr{IdentExpr: &expr.Expr_Ident{Name: "title"}}},
+						{Id: 3, ExprKind: &expr.Expr_ConstExpr{ConstExpr: filtering.NewStringConstant(value)}},
+					},
+				}}},
+				TypeMap: map[int64]*expr.Type{2: filtering.TypeString},
+			},
+		}
+		actual, params, err := TranspileFilter(filter)
+		assert.NilError(t, err)
+		var expected bool
+		switch {
+		case leading && trailing:
+			expected = strings.Contains(candidate, literal)
+		case leading:
+			expected = strings.HasSuffix(candidate, literal)
+		case trailing:
+			expected = strings.HasPrefix(candidate, literal)
+		default:
+			expected = candidate == literal
+		}
+		param := params["param_0"].(string)
+		switch actual.SQL() {
+		case `(title LIKE @param_0)`:
+			assert.Equal(t, expected, likeMatch(t, param, candidate), "value %q, pattern %q", value, param)
+		case `(title = @param_0)`:
+			assert.Equal(t, expected, param == candidate, "value %q, param %q", value, param)
+		default:
+			t.Fatalf("unexpected SQL: %s", actual.SQL())
+		}
+	})
+}
+
+func TestTranspileFilter_escapedComparison(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name           string
+		filter         string
+		expectedSQL    string
+		expectedParams map[string]interface{}
+	}{
+		{
+			name:           "not equals escaped asterisk",
+			filter:         `title != "5 \\* 3"`,
+			expectedSQL:    `(title != @param_0)`,
+			expectedParams: map[string]interface{}{"param_0": "5 * 3"},
+		},
+
+		{
+			name:           "not equals escaped backslash",
+			filter:         `title != "C:\\\\"`,
+			expectedSQL:    `(title != @param_0)`,
+			expectedParams: map[string]interface{}{"param_0": `C:\`},
+		},
+
+		{
+			name:           "not equals trailing asterisk",
+			filter:         `title != "Zap*"`,
+			expectedSQL:    `(title != @param_0)`,
+			expectedParams: map[string]interface{}{"param_0": "Zap*"},
+		},
+
+		{
+			name:           "not equals escaped trailing asterisk",
+			filter:         `title != "Zap\\*"`,
+			expectedSQL:    `(title != @param_0)`,
+			expectedParams: map[string]interface{}{"param_0": "Zap*"},
+		},
+
+		{
+			name:           "less than escaped asterisk",
+			filter:         `title < "\\*"`,
+			expectedSQL:    `(title < @param_0)`,
+			expectedParams: map[string]interface{}{"param_0": "*"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			declarations, err := filtering.NewDeclarations(
+				filtering.DeclareStandardFunctions(),
+				filtering.DeclareIdent("title", filtering.TypeString),
+			)
+			assert.NilError(t, err)
+			filter, err := filtering.ParseFilter(&mockRequest{filter: tt.filter}, declarations)
+			assert.NilError(t, err)
+			actual, params, err := TranspileFilter(filter)
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedSQL, actual.SQL())
+			assert.DeepEqual(t, tt.expectedParams, params)
+		})
+	}
+}
+
+// likeMatch matches a string against a LIKE pattern, with backslash as the escape character.
+func likeMatch(t *testing.T, pattern, s string) bool {
+	t.Helper()
+	var result strings.Builder
+	_, _ = result.WriteString(`(?s)^`)
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+			assert.Assert(t, i < len(runes), "trailing escape character in pattern %q", pattern)
+			_, _ = result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		case '%':
+			_, _ = result.WriteString(`.*`)
+		case '_':
+			_, _ = result.WriteString(`.`)
+		default:
+			_, _ = result.WriteString(regexp.QuoteMeta(string(runes[i])))
+		}
+	}
+	_, _ = result.WriteString(`$`)
+	return regexp.MustCompile(result.String()).MatchString(s)
+}
@@ -0,0 +1,482 @@
+package spanindex
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/stoewer/go-strcase"
+	"go.einride.tech/spanner-aip/spanddl"
+)
+
+// Query is a query on a table to analyze, e.g. with a transpiled filter and ordering.
+type Query struct {
+	// Table that is queried.
+	Table spansql.ID
+	// Columns that are selected. Defaults to all queryable columns of the table.
+	Columns []spansql.ID
+	// Where condition of the query.
+	Where spansql.BoolExpr
+	// Order of the query.
+	Order []spansql.Order
+}
+
+// Advice on how a query can be served by the primary key or the secondary indexes of a table.
+type Advice struct {
+	// PrimaryKey is true if the query is served by the primary key of the table.
+	PrimaryKey bool
+	// FullScan is true if the query has no conditions or ordering that the primary key or an index can seek on, so
+	// that it is served by a scan of the whole table.
+	FullScan bool
+	// Index that serves the query, if the query is served by a secondary index.
+	Index *spanddl.Index
+	// Covering is true if the primary key or index covers all columns read by the query, so that no join back to the
+	// table is needed.
+	Covering bool
+	// MissingColumns are the columns read by the query that are not covered by the index, and need to be added to its
+	// STORING clause for the index to be covering.
+	MissingColumns []spansql.ID
+	// Suggestion of an index that serves the query, when neither the primary key nor any index does. There is no
+	// suggestion when no index can serve both the conditions and the order of the query.
+	Suggestion *spansql.CreateIndex
+}
+
+// Served returns true if the query is served by the primary key of the table or by a secondary index.
+func (a *Advice) Served() bool {
+	return a.PrimaryKey || a.Index != nil
+}
+
+// Advise analyzes which index of the database can serve a query.
+//
+// An index serves a query when it can seek to the rows matching the query, and return them in the order of the query:
+//   - all equality conditions (=, IN and IS NULL) in the top-level conjunction of the condition are on a prefix of
+//     the index key,
+//   - the order of the query follows the index key after that prefix, or, when the query is not ordered, a range
+//     condition (<, <=, >, >=, BETWEEN or STARTS_WITH) is on the next column of the index key.
+//
+// Only single-value equality conditions make a column constant in the result. An IN condition with multiple values
+// seeks to several ranges of the index key, so an ordered query is only served when the order of the query includes
+// the column of the IN condition where it is in the index key.
+//
+// Orders on whether a column IS NULL, such as the nulls orders of spanordering, are served when the column is never
+// NULL or constant, or when NULL values come first in the order of the column as in the index key. Other orders on
+// expressions, such as collations, are not served by any index.
+//
+// Index keys include the primary key columns of the table. Among the primary key and the indexes serving the query, the
+// one using the most key columns is preferred, then the one missing the fewest read columns, then the primary key.
+// Queries without any conditions or ordering that an index can seek on are served by a full scan of the table.
+func Advise(database *spanddl.Database, query Query) (*Advice, error) {
+	table, ok := database.Table(query.Table)
+	if !ok {
+		return nil, fmt.Errorf("advise index: unknown table %s", query.Table)
+	}
+	a, err := analyze(table, query)
+	if err != nil {
+		return nil, fmt.Errorf("advise index: %w", err)
+	}
+	var result Advice
+	if len(a.equalities) == 0 && len(a.ranges) == 0 && !a.orderable() {
+		result.PrimaryKey = true
+		result.FullScan = true
+		result.Covering = true
+		return &result, nil
+	}
+	bestScore := 0
+	if serves, score := a.match(table.PrimaryKey); serves {
+		result.PrimaryKey = true
+		result.Covering = true
+		bestScore = score
+	}
+	for _, index := range database.Indexes {
+		if index.Table != table.Name {
+			continue
+		}
+		serves, score := a.match(indexKey(table, index))
+		if !serves {
+			continue
+		}
+		missingColumns := a.missingColumns(table, index)
+		if result.Served() &&
+			(score < bestScore || score == bestScore && len(missingColumns) >= len(result.MissingColumns)) {
+			continue
+		}
+		result = Advice{Index: index, Covering: len(missingColumns) == 0, MissingColumns: missingColumns}
+		bestScore = score
+	}
+	if !result.Served() {
+		result.Suggestion = a.suggestion(table)
+	}
+	return &result, nil
+}
+
+// analysis of the columns that a query seeks on, orders by and reads.
+type analysis struct {
+	table *spanddl.Table
+	// equalities are columns with equality conditions.
+	equalities []spansql.ID
+	// constants are columns with single-value equality conditions, which are constant in the result.
+	constants []spansql.ID
+	// ranges are columns with range conditions.
+	ranges []spansql.ID
+	// order of the query, if all ordered expressions are columns.
+	order []spansql.KeyPart
+	// unorderable is true if the query is ordered by expressions that are not columns.
+	unorderable bool
+	// reads are the columns read by the query.
+	reads []spansql.ID
+}
+
+func analyze(table *spanddl.Table, query Query) (*analysis, error) {
+	a := analysis{table: table}
+	if len(query.Columns) == 0 {
+		for column := range table.QueryableColumns() {
+			a.read(column.Name)
+		}
+	}
+	for _, selected := range query.Columns {
+		column, ok := a.column(selected)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %s in table %s", selected, table.Name)
+		}
+		a.read(column)
+	}
+	if query.Where != nil {
+		a.readExpr(query.Where)
+		a.addCondition(query.Where)
+	}
+	for i, order := range query.Order {
+		a.readExpr(order.Expr)
+		if a.isRedundantNullsOrder(query.Order, i) {
+			continue
+		}
+		column, ok := a.column(order.Expr)
+		if !ok {
+			a.unorderable = true
+			continue
+		}
+		a.order = append(a.order, spansql.KeyPart{Column: column, Desc: order.Desc})
+	}
+	return &a, nil
+}
+
+// addCondition adds a condition in the top-level conjunction of the query condition.
+func (a *analysis) addCondition(e spansql.BoolExpr) {
+	switch e := e.(type) {
+	case spansql.Paren:
+		if inner, ok := e.Expr.(spansql.BoolExpr); ok {
+			a.addCondition(inner)
+		}
+	case spansql.LogicalOp:
+		if e.Op == spansql.And {
+			a.addCondition(e.LHS)
+			a.addCondition(e.RHS)
+		}
+	case spansql.ComparisonOp:
+		column, ok := a.comparedColumn(e.LHS, e.RHS)
+		if !ok {
+			return
+		}
+		switch e.Op {
+		case spansql.Eq:
+			a.equalities = appendUnique(a.equalities, column)
+			a.constants = appendUnique(a.constants, column)
+		case spansql.Lt, spansql.Le, spansql.Gt, spansql.Ge:
+			a.ranges = appendUnique(a.ranges, column)
+		case spansql.Between:
+			if isConstant(e.RHS2) {
+				a.ranges = appendUnique(a.ranges, column)
+			}
+		}
+	case spansql.IsOp:
+		if column, ok := a.column(e.LHS); ok && !e.Neg && e.RHS == spansql.Null {
+			a.equalities = appendUnique(a.equalities, column)
+			a.constants = appendUnique(a.constants, column)
+		}
+	case spansql.InOp:
+		if column, ok := a.column(e.LHS); ok && !e.Neg && !slices.ContainsFunc(e.RHS, not(isConstant)) {
+			a.equalities = appendUnique(a.equalities, column)
+			// Parameters of UNNEST may bind multiple values.
+			if len(e.RHS) == 1 && !e.Unnest {
+				a.constants = appendUnique(a.constants, column)
+			}
+		}
+	case spansql.Func:
+		if e.Name == "STARTS_WITH" && len(e.Args) == 2 {
+			if column, ok := a.column(e.Args[0]); ok && isConstant(e.Args[1]) {
+				a.ranges = appendUnique(a.ranges, column)
+			}
+		}
+	}
+}
+
+// comparedColumn returns the column of a comparison between a column and a constant.
+func (a *analysis) comparedColumn(lhs, rhs spansql.Expr) (spansql.ID, bool) {
+	if column, ok := a.column(lhs); ok && isConstant(rhs) {
+		return column, true
+	}
+	if column, ok := a.column(rhs); ok && isConstant(lhs) {
+		return column, true
+	}
+	return "", false
+}
+
+// column returns the column of the table referenced by an expression, by name or qualified with the table name.
+func (a *analysis) column(e spansql.Expr) (spansql.ID, bool) {
+	var name spansql.ID
+	switch e := e.(type) {
+	case spansql.ID:
+		name = e
+	case spansql.PathExp:
+		if len(e) != 2 || !strings.EqualFold(string(e[0]), string(a.table.Name)) {
+			return "", false
+		}
+		name = e[1]
+	default:
+		return "", false
+	}
+	// Column names are case-insensitive.
+	for _, column := range a.table.Columns {
+		if strings.EqualFold(string(column.Name), string(name)) {
+			return column.Name, true
+		}
+	}
+	return "", false
+}
+
+// isRedundantNullsOrder returns true if the order at index i is on whether a column IS NULL, and does not change the
+// order of the query. That is the case when the column is never NULL or constant, or when the next order is on the
+// column, in the opposite direction, so that NULL values come first in ascending and last in descending order.
+func (a *analysis) isRedundantNullsOrder(orders []spansql.Order, i int) bool {
+	isNull, ok := orders[i].Expr.(spansql.IsOp)
+	if !ok || isNull.Neg || isNull.RHS != spansql.Null {
+		return false
+	}
+	column, ok := a.column(isNull.LHS)
+	if !ok {
+		return false
+	}
+	if definition, ok := a.table.Column(column); ok && definition.NotNull || slices.Contains(a.constants, column) {
+		return true
+	}
+	if i+1 == len(orders) {
+		return false
+	}
+	next, ok := a.column(orders[i+1].Expr)
+	return ok && next == column && orders[i+1].Desc != orders[i].Desc
+}
+
+func (a *analysis) read(column spansql.ID) {
+	a.reads = appendUnique(a.reads, column)
+}
+
+func (a *analysis) readExpr(e spansql.Expr) {
+	visitReferences(e, func(reference spansql.Expr) {
+		if column, ok := a.column(reference); ok {
+			a.read(column)
+		}
+	})
+}
+
+func (a *analysis) orderable() bool {
+	return len(a.order) > 0 && !a.unorderable
+}
+
+// match returns true if an index with the provided key serves the query, and a score of how many key columns are used
+// by the query.
+func (a *analysis) match(key []spansql.KeyPart) (bool, int) {
+	var i int
+	for i < len(key) && slices.Contains(a.equalities, key[i].Column) {
+		i++
+	}
+	if i < len(a.equalities) {
+		return false, 0
+	}
+	score := i
+	if a.orderable() {
+		var j int
+		for _, keyPart := range a.order {
+			// Columns with single-value equality conditions are constant in the result.
+			if slices.Contains(a.constants, keyPart.Column) {
+				continue
+			}
+			for j < len(key) && slices.Contains(a.constants, key[j].Column) {
+				j++
+			}
+			if j >= len(key) || key[j] != keyPart {
+				return false, 0
+			}
+			j++
+			if j > i {
+				score++
+			}
+		}
+		return true, score
+	}
+	if i < len(key) && slices.Contains(a.ranges, key[i].Column) {
+		score++
+	}
+	return score > 0, score
+}
+
+// missingColumns returns the columns read by the query that are not covered by an index.
+func (a *analysis) missingColumns(table *spanddl.Table, index *spanddl.Index) []spansql.ID {
+	var result []spansql.ID
+	key := indexKey(table, index)
+	for _, column := range a.reads {
+		if !slices.ContainsFunc(key, hasColumn(column)) && !slices.Contains(index.Storing, column) {
+			result = append(result, column)
+		}
+	}
+	return result
+}
+
+// suggestion returns a covering index that serves the query, or nil if the query has no conditions or ordering that
+// an index can seek on, or if no index can serve both the conditions and the ordering of the query.
+func (a *analysis) suggestion(table *spanddl.Table) *spansql.CreateIndex {
+	var key []spansql.KeyPart
+	for _, column := range a.constants {
+		key = append(key, spansql.KeyPart{Column: column})
+	}
+	if a.orderable() {
+		for _, keyPart := range a.order {
+			if !slices.ContainsFunc(key, hasColumn(keyPart.Column)) {
+				key = append(key, keyPart)
+			}
+		}
+	}
+	for _, column := range a.equalities {
+		if !slices.ContainsFunc(key, hasColumn(column)) {
+			key = append(key, spansql.KeyPart{Column: column})
+		}
+	}
+	if !a.orderable() && len(a.ranges) > 0 && !slices.ContainsFunc(key, hasColumn(a.ranges[0])) {
+		key = append(key, spansql.KeyPart{Column: a.ranges[0]})
+	}
+	if len(key) == 0 {
+		return nil
+	}
+	if serves, _ := a.match(key); !serves {
+		return nil
+	}
+	var name strings.Builder
+	name.WriteString(string(table.Name))
+	name.WriteString("By")
+	for _, keyPart := range key {
+		name.WriteString(strcase.UpperCamelCase(string(keyPart.Column)))
+		if keyPart.Desc {
+			name.WriteString("Desc")
+		}
+	}
+	var storing []spansql.ID
+	for _, column := range a.reads {
+		if !slices.ContainsFunc(key, hasColumn(column)) && !slices.ContainsFunc(table.PrimaryKey, hasColumn(column)) {
+			storing = append(storing, column)
+		}
+	}
+	return &spansql.CreateIndex{
+		Name:    spansql.ID(name.String()),
+		Table:   table.Name,
+		Columns: key,
+		Storing: storing,
+	}
+}
+
+// indexKey returns the key of an index, which includes the primary key columns of the table.
+func indexKey(table *spanddl.Table, index *spanddl.Index) []spansql.KeyPart {
+	key := slices.Clone(index.Columns)
+	for _, keyPart := range table.PrimaryKey {
+		if !slices.ContainsFunc(key, hasColumn(keyPart.Column)) {
+			key = append(key, keyPart)
+		}
+	}
+	return key
+}
+
+func hasColumn(column spansql.ID) func(spansql.KeyPart) bool {
+	return func(keyPart spansql.KeyPart) bool {
+		return keyPart.Column == column
+	}
+}
+
+func appendUnique(columns []spansql.ID, column spansql.ID) []spansql.ID {
+	if slices.Contains(columns, column) {
+		return columns
+	}
+	return append(columns, column)
+}
+
+func not(f func(spansql.Expr) bool) func(spansql.Expr) bool {
+	return func(e spansql.Expr) bool {
+		return !f(e)
+	}
+}
+
+// isConstant returns true if an expression does not reference any columns.
+func isConstant(e spansql.Expr) bool {
+	constant := true
+	visitReferences(e, func(spansql.Expr) {
+		constant = false
+	})
+	return constant
+}
+
+// visitReferences visits the column references of an expression. Only qualified references are visited in the
+// conditions of subqueries, since unqualified references in subqueries may refer to the columns of other tables.
+func visitReferences(e spansql.Expr, visit func(spansql.Expr)) {
+	switch e := e.(type) {
+	case spansql.ID, spansql.PathExp:
+		visit(e)
+	case spansql.Paren:
+		visitReferences(e.Expr, visit)
+	case spansql.LogicalOp:
+		if e.LHS != nil {
+			visitReferences(e.LHS, visit)
+		}
+		visitReferences(e.RHS, visit)
+	case spansql.ComparisonOp:
+		visitReferences(e.LHS, visit)
+		visitReferences(e.RHS, visit)
+		if e.RHS2 != nil {
+			visitReferences(e.RHS2, visit)
+		}
+	case spansql.IsOp:
+		visitReferences(e.LHS, visit)
+		visitReferences(e.RHS, visit)
+	case spansql.InOp:
+		visitReferences(e.LHS, visit)
+		for _, rhs := range e.RHS {
+			visitReferences(rhs, visit)
+		}
+	case spansql.ArithOp:
+		if e.LHS != nil {
+			visitReferences(e.LHS, visit)
+		}
+		visitReferences(e.RHS, visit)
+	case spansql.Func:
+		for _, arg := range e.Args {
+			visitReferences(arg, visit)
+		}
+	case spansql.DefinitionExpr:
+		visitReferences(e.Value, visit)
+	case spansql.TypedExpr:
+		visitReferences(e.Expr, visit)
+	case spansql.Array:
+		for _, element := range e {
+			visitReferences(element, visit)
+		}
+	case spansql.ExistsOp:
+		for _, from := range e.Subquery.Select.From {
+			if unnest, ok := from.(spansql.SelectFromUnnest); ok {
+				visitReferences(unnest.Expr, visit)
+			}
+		}
+		if e.Subquery.Select.Where != nil {
+			visitReferences(e.Subquery.Select.Where, func(reference spansql.Expr) {
+				if _, ok := reference.(spansql.PathExp); ok {
+					visit(reference)
+				}
+			})
+		}
+	}
+}
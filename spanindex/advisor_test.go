@@ -0,0 +1,283 @@
+package spanindex
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/spanner-aip/spanddl"
+	"gotest.tools/v3/assert"
+)
+
+func TestAdvise(t *testing.T) {
+	t.Parallel()
+	ddl, err := spansql.ParseDDL("", `
+		CREATE TABLE Singers (
+			SingerId INT64 NOT NULL,
+			FirstName STRING(1024),
+			LastName STRING(1024),
+			SingerInfo BYTES(MAX),
+		) PRIMARY KEY (SingerId);
+
+		CREATE TABLE Albums (
+			SingerId INT64 NOT NULL,
+			AlbumId INT64 NOT NULL,
+			AlbumTitle STRING(MAX),
+			ReleaseDate DATE,
+		) PRIMARY KEY (SingerId, AlbumId),
+		INTERLEAVE IN PARENT Singers ON DELETE CASCADE;
+
+		CREATE INDEX SingersByLastName ON Singers(LastName);
+
+		CREATE INDEX SingersByLastNameStoringFirstName ON Singers(LastName) STORING (FirstName);
+
+		CREATE INDEX AlbumsByAlbumTitle ON Albums(AlbumTitle);
+
+		CREATE INDEX AlbumsBySingerIdReleaseDateDesc ON Albums(SingerId, ReleaseDate DESC), INTERLEAVE IN Singers;
+	`)
+	assert.NilError(t, err)
+	var db spanddl.Database
+	assert.NilError(t, db.ApplyDDL(ddl))
+	for _, tt := range []struct {
+		name               string
+		query              string
+		expectedPrimaryKey bool
+		expectedFullScan   bool
+		expectedIndex      spansql.ID
+		expectedCovering   bool
+		expectedMissing    []spansql.ID
+		expectedSuggestion string
+		errorContains      string
+	}{
+		{
+			name:               "scan",
+			query:              `SELECT * FROM Singers`,
+			expectedPrimaryKey: true,
+			expectedFullScan:   true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:               "primary key prefix",
+			query:              `SELECT * FROM Albums WHERE SingerId = @singer_id ORDER BY AlbumId`,
+			expectedPrimaryKey: true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:               "primary key order",
+			query:              `SELECT * FROM Albums ORDER BY SingerId, AlbumId`,
+			expectedPrimaryKey: true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:            "index equality",
+			query:           `SELECT * FROM Singers WHERE LastName = @last_name`,
+			expectedIndex:   "SingersByLastNameStoringFirstName",
+			expectedMissing: []spansql.ID{"SingerInfo"},
+		},
+
+		{
+			name:             "covering index",
+			query:            `SELECT SingerId, FirstName FROM Singers WHERE @last_name = Singers.LastName`,
+			expectedIndex:    "SingersByLastNameStoringFirstName",
+			expectedCovering: true,
+		},
+
+		{
+			name:             "first covering index",
+			query:            `SELECT SingerId FROM Singers WHERE LastName = @last_name`,
+			expectedIndex:    "SingersByLastName",
+			expectedCovering: true,
+		},
+
+		{
+			name:             "index order with primary key tie-breaker",
+			query:            `SELECT SingerId FROM Singers ORDER BY LastName, SingerId`,
+			expectedIndex:    "SingersByLastName",
+			expectedCovering: true,
+		},
+
+		{
+			name:             "index range",
+			query:            `SELECT SingerId FROM Singers WHERE STARTS_WITH(LastName, @prefix) AND FirstName IS NOT NULL`,
+			expectedIndex:    "SingersByLastNameStoringFirstName",
+			expectedCovering: true,
+		},
+
+		{
+			name:            "index equality with ordering",
+			query:           `SELECT * FROM Albums WHERE SingerId = @singer_id ORDER BY ReleaseDate DESC`,
+			expectedIndex:   "AlbumsBySingerIdReleaseDateDesc",
+			expectedMissing: []spansql.ID{"AlbumTitle"},
+		},
+
+		{
+			name:            "equality columns in ordering",
+			query:           `SELECT * FROM Albums WHERE AlbumTitle IN (@a, @b) ORDER BY AlbumTitle, SingerId, AlbumId`,
+			expectedIndex:   "AlbumsByAlbumTitle",
+			expectedMissing: []spansql.ID{"ReleaseDate"},
+		},
+
+		{
+			name:  "multi-value equality with ordering",
+			query: `SELECT * FROM Albums WHERE SingerId IN (@a, @b) ORDER BY ReleaseDate DESC`,
+		},
+
+		{
+			name:            "single-value equality with ordering",
+			query:           `SELECT * FROM Albums WHERE SingerId IN (@singer_id) ORDER BY ReleaseDate DESC`,
+			expectedIndex:   "AlbumsBySingerIdReleaseDateDesc",
+			expectedMissing: []spansql.ID{"AlbumTitle"},
+		},
+
+		{
+			name:            "multi-value equality with ordering by the column",
+			query:           `SELECT * FROM Albums WHERE SingerId IN UNNEST(@ids) ORDER BY SingerId, ReleaseDate DESC`,
+			expectedIndex:   "AlbumsBySingerIdReleaseDateDesc",
+			expectedMissing: []spansql.ID{"AlbumTitle"},
+		},
+
+		{
+			name:             "nulls first ordering",
+			query:            `SELECT SingerId FROM Singers ORDER BY LastName IS NULL DESC, LastName, SingerId`,
+			expectedIndex:    "SingersByLastName",
+			expectedCovering: true,
+		},
+
+		{
+			name:               "nulls last ordering",
+			query:              `SELECT SingerId FROM Singers ORDER BY LastName IS NULL, LastName`,
+			expectedPrimaryKey: true,
+			expectedFullScan:   true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:               "nulls last ordering on non-null column",
+			query:              `SELECT * FROM Albums WHERE SingerId = @singer_id ORDER BY AlbumId IS NULL, AlbumId`,
+			expectedPrimaryKey: true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:            "nulls last ordering on constant column",
+			query:           `SELECT * FROM Albums WHERE AlbumTitle = @title ORDER BY AlbumTitle IS NULL, AlbumTitle`,
+			expectedIndex:   "AlbumsByAlbumTitle",
+			expectedMissing: []spansql.ID{"ReleaseDate"},
+		},
+
+		{
+			name:            "expression ordering",
+			query:           `SELECT * FROM Albums WHERE AlbumTitle = @title ORDER BY LOWER(AlbumTitle)`,
+			expectedIndex:   "AlbumsByAlbumTitle",
+			expectedMissing: []spansql.ID{"ReleaseDate"},
+		},
+
+		{
+			name:  "ordering direction mismatch",
+			query: `SELECT SingerId, FirstName FROM Singers ORDER BY LastName DESC`,
+			expectedSuggestion: "CREATE INDEX SingersByLastNameDesc ON Singers(LastName DESC) " +
+				"STORING (FirstName)",
+		},
+
+		{
+			name:               "equality and ordering",
+			query:              `SELECT * FROM Singers WHERE FirstName = @first_name ORDER BY LastName`,
+			expectedSuggestion: "CREATE INDEX SingersByFirstNameLastName ON Singers(FirstName, LastName) STORING (SingerInfo)",
+		},
+
+		{
+			name:               "range",
+			query:              `SELECT AlbumId FROM Albums WHERE ReleaseDate > @release_date`,
+			expectedSuggestion: "CREATE INDEX AlbumsByReleaseDate ON Albums(ReleaseDate)",
+		},
+
+		{
+			name:               "disjunction",
+			query:              `SELECT * FROM Singers WHERE LastName = @a OR FirstName = @b`,
+			expectedPrimaryKey: true,
+			expectedFullScan:   true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:               "equality disjunction",
+			query:              `SELECT * FROM Singers WHERE FirstName = @a OR FirstName = @b`,
+			expectedPrimaryKey: true,
+			expectedFullScan:   true,
+			expectedCovering:   true,
+		},
+
+		{
+			name:               "substring",
+			query:              `SELECT * FROM Singers WHERE STRPOS(LastName, @substring) > 0`,
+			expectedPrimaryKey: true,
+			expectedFullScan:   true,
+			expectedCovering:   true,
+		},
+
+		{
+			name: "correlated subquery",
+			query: `SELECT SingerId FROM Singers WHERE LastName = @last_name AND EXISTS ` +
+				`(SELECT 1 FROM Albums AS c WHERE c.SingerId = Singers.SingerId AND c.AlbumTitle = @title)`,
+			expectedIndex:    "SingersByLastName",
+			expectedCovering: true,
+		},
+
+		{
+			name:          "unknown table",
+			query:         `SELECT * FROM Labels`,
+			errorContains: "unknown table Labels",
+		},
+
+		{
+			name:          "unknown column",
+			query:         `SELECT Genre FROM Singers`,
+			errorContains: "unknown column Genre in table Singers",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			query, err := ParseQuery(tt.query)
+			assert.NilError(t, err)
+			advice, err := Advise(&db, query)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expectedPrimaryKey, advice.PrimaryKey)
+			assert.Equal(t, tt.expectedFullScan, advice.FullScan)
+			if tt.expectedIndex != "" {
+				assert.Assert(t, advice.Index != nil)
+				assert.Equal(t, tt.expectedIndex, advice.Index.Name)
+			} else {
+				assert.Assert(t, advice.Index == nil)
+			}
+			assert.Equal(t, tt.expectedCovering, advice.Covering)
+			assert.DeepEqual(t, tt.expectedMissing, advice.MissingColumns)
+			assert.Equal(t, tt.expectedPrimaryKey || tt.expectedIndex != "", advice.Served())
+			if tt.expectedSuggestion != "" {
+				assert.Assert(t, advice.Suggestion != nil)
+				assert.Equal(t, tt.expectedSuggestion, advice.Suggestion.SQL())
+			} else {
+				assert.Assert(t, advice.Suggestion == nil)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+	query, err := ParseQuery(`SELECT SingerId, Singers.FirstName FROM Singers WHERE LastName = @p ORDER BY FirstName DESC`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Query{
+		Table:   "Singers",
+		Columns: []spansql.ID{"SingerId", "FirstName"},
+		Where:   spansql.ComparisonOp{Op: spansql.Eq, LHS: spansql.ID("LastName"), RHS: spansql.Param("p")},
+		Order:   []spansql.Order{{Expr: spansql.ID("FirstName"), Desc: true}},
+	}, query)
+	_, err = ParseQuery(`SELECT * FROM Singers JOIN Albums ON Singers.SingerId = Albums.SingerId`)
+	assert.ErrorContains(t, err, "parse query")
+}
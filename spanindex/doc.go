@@ -0,0 +1,2 @@
+// Package spanindex provides primitives for analyzing which Spanner indexes can serve filtered and ordered queries.
+package spanindex
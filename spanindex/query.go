@@ -0,0 +1,50 @@
+package spanindex
+
+import (
+	"fmt"
+
+	"cloud.google.com/go/spanner/spansql"
+)
+
+// ParseQuery parses a GoogleSQL query on a single table to analyze, e.g.
+// `SELECT * FROM Singers WHERE LastName = @last_name ORDER BY FirstName`.
+func ParseQuery(sql string) (Query, error) {
+	parsed, err := spansql.ParseQuery(sql)
+	if err != nil {
+		return Query{}, fmt.Errorf("parse query: %w", err)
+	}
+	if len(parsed.Select.From) != 1 {
+		return Query{}, fmt.Errorf("parse query: query must select from a single table")
+	}
+	from, ok := parsed.Select.From[0].(spansql.SelectFromTable)
+	if !ok {
+		return Query{}, fmt.Errorf("parse query: unsupported FROM clause: %s", parsed.Select.From[0].SQL())
+	}
+	result := Query{
+		Table: from.Table,
+		Where: parsed.Select.Where,
+		Order: parsed.Order,
+	}
+	var star bool
+	for _, selected := range parsed.Select.List {
+		switch selected := selected.(type) {
+		case spansql.ID:
+			result.Columns = append(result.Columns, selected)
+		case spansql.PathExp:
+			if len(selected) != 2 {
+				return Query{}, fmt.Errorf("parse query: unsupported selected expression: %s", selected.SQL())
+			}
+			result.Columns = append(result.Columns, selected[1])
+		default:
+			if selected != spansql.Star {
+				return Query{}, fmt.Errorf("parse query: unsupported selected expression: %s", selected.SQL())
+			}
+			star = true
+		}
+	}
+	if star {
+		// Selecting all columns is the default.
+		result.Columns = nil
+	}
+	return result, nil
+}
@@ -0,0 +1,4 @@
+// Package spanlist provides primitives for implementing AIP standard List methods on Spanner tables.
+//
+// See: https://google.aip.dev/132 (Standard methods: List).
+package spanlist
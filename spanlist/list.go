@@ -0,0 +1,163 @@
+package spanlist
+
+import (
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/aip/ordering"
+	"go.einride.tech/aip/pagination"
+	"go.einride.tech/spanner-aip/spanfiltering"
+	"go.einride.tech/spanner-aip/spanordering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultPageSize is the page size of requests without a page size, unless configured with WithDefaultPageSize.
+	DefaultPageSize = 100
+	// DefaultMaxPageSize is the maximum page size of requests, unless configured with WithMaxPageSize.
+	DefaultMaxPageSize = 1000
+)
+
+// Request is an AIP standard List request with filtering, ordering and pagination.
+type Request interface {
+	filtering.Request
+	ordering.Request
+	pagination.Request
+}
+
+// Option configures ParseRequest.
+type Option func(options *options)
+
+// WithFilterOptions configures the transpilation of the request filter.
+func WithFilterOptions(filterOptions ...spanfiltering.TranspileOption) Option {
+	return func(options *options) {
+		options.filterOptions = append(options.filterOptions, filterOptions...)
+	}
+}
+
+// WithOrderingOptions configures the transpilation of the request ordering, e.g. with nulls orders and collations.
+func WithOrderingOptions(orderingOptions ...spanordering.TranspileOption) Option {
+	return func(options *options) {
+		options.orderingOptions = append(options.orderingOptions, orderingOptions...)
+	}
+}
+
+// WithOrderByColumns maps the fields that the request can be ordered by to columns of the table.
+// Without a mapping, the request can be ordered by all columns of the table, by their names.
+func WithOrderByColumns(columns map[string]spansql.ID) Option {
+	return func(options *options) {
+		options.orderByColumns = columns
+	}
+}
+
+// WithDefaultPageSize sets the page size of requests without a page size.
+func WithDefaultPageSize(pageSize int32) Option {
+	return func(options *options) {
+		options.defaultPageSize = pageSize
+	}
+}
+
+// WithMaxPageSize sets the maximum page size of requests. Larger page sizes are coerced to the maximum.
+func WithMaxPageSize(pageSize int32) Option {
+	return func(options *options) {
+		options.maxPageSize = pageSize
+	}
+}
+
+type options struct {
+	filterOptions   []spanfiltering.TranspileOption
+	orderingOptions []spanordering.TranspileOption
+	orderByColumns  map[string]spansql.ID
+	defaultPageSize int32
+	maxPageSize     int32
+}
+
+// Query is a List query parsed from a request, with fields corresponding to the generated List<Table>RowsQuery.
+// The generated List<Table>RowsQuery.FromList method converts the query to a List query.
+type Query struct {
+	// Where is the transpiled filter of the request.
+	Where spansql.BoolExpr
+	// Order is the validated and transpiled ordering of the request, with the primary key as tie-breaker.
+	Order []spansql.Order
+	// Limit is one more than the page size, to detect whether there is a next page.
+	Limit int32
+	// Offset of the page.
+	Offset int64
+	// Params of the transpiled filter.
+	Params map[string]interface{}
+	// PageSize of the request, after applying the default and maximum page sizes.
+	PageSize int32
+
+	pageToken pagination.PageToken
+}
+
+// ParseRequest parses and validates a List request on a table, e.g. a generated table descriptor, to a query.
+//
+// The filter is parsed with the provided declarations and transpiled, and the ordering is validated against the table
+// columns. An InvalidArgument error is returned for invalid filters, orderings, page sizes and page tokens. Errors in
+// the configuration of the transpilation, such as an unknown table in spanfiltering.WithInterleavedTable or an unknown
+// column in WithOrderByColumns, are returned with an Internal status.
+func ParseRequest(
+	request Request,
+	table spanordering.TableDescriptor,
+	declarations *filtering.Declarations,
+	opts ...Option,
+) (*Query, error) {
+	o := options{defaultPageSize: DefaultPageSize, maxPageSize: DefaultMaxPageSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var result Query
+	switch pageSize := request.GetPageSize(); {
+	case pageSize < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_size: %d (must be non-negative)", pageSize)
+	case pageSize == 0:
+		result.PageSize = o.defaultPageSize
+	case pageSize > o.maxPageSize:
+		result.PageSize = o.maxPageSize
+	default:
+		result.PageSize = pageSize
+	}
+	result.Limit = result.PageSize + 1
+	pageToken, err := pagination.ParsePageToken(request)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token: %v", err)
+	}
+	result.pageToken = pageToken
+	result.Offset = pageToken.Offset
+	filter, err := filtering.ParseFilter(request, declarations)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	result.Where, result.Params, err = spanfiltering.TranspileFilter(filter, o.filterOptions...)
+	if err != nil {
+		// Configuration errors have a status, and other transpilation errors are caused by the filter.
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "invalid filter: %v", err)
+	}
+	orderBy, err := ordering.ParseOrderBy(request)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order_by: %v", err)
+	}
+	schema, err := spanordering.TableSchema(table, o.orderByColumns)
+	if err != nil {
+		return nil, err
+	}
+	if result.Order, err = spanordering.TranspileSchemaOrderBy(orderBy, schema, o.orderingOptions...); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// NextPageToken returns the next_page_token of the response, given the number of rows returned by the query.
+// The response should include at most PageSize rows.
+func (q *Query) NextPageToken(rows int) string {
+	if rows <= int(q.PageSize) {
+		return ""
+	}
+	nextPageToken := q.pageToken
+	nextPageToken.Offset += int64(q.PageSize)
+	return nextPageToken.String()
+}
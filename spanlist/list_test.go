@@ -0,0 +1,258 @@
+package spanlist_test
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/filtering"
+	"go.einride.tech/spanner-aip/internal/examples/freightdb"
+	"go.einride.tech/spanner-aip/spanddl"
+	"go.einride.tech/spanner-aip/spanfiltering"
+	"go.einride.tech/spanner-aip/spanlist"
+	"go.einride.tech/spanner-aip/spanordering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+	declarations, err := filtering.NewDeclarations(
+		filtering.DeclareStandardFunctions(),
+		filtering.DeclareIdent("origin_site_id", filtering.TypeString),
+		filtering.DeclareIdent("pickup_earliest_time", filtering.TypeTimestamp),
+	)
+	assert.NilError(t, err)
+	shipments := freightdb.Descriptor().Shipments()
+	orderByColumns := spanlist.WithOrderByColumns(map[string]spansql.ID{
+		"origin_site_id":       "origin_site_id",
+		"pickup_earliest_time": "pickup_earliest_time",
+	})
+
+	t.Run("filter and ordering", func(t *testing.T) {
+		t.Parallel()
+		query, err := spanlist.ParseRequest(
+			newListRequest(`origin_site_id = "gothenburg"`, "pickup_earliest_time desc", 10, ""),
+			shipments,
+			declarations,
+			orderByColumns,
+			spanlist.WithOrderingOptions(spanordering.WithNullsOrder("pickup_earliest_time", spanordering.NullsFirst)),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, "(origin_site_id = @param_0)", query.Where.SQL())
+		assert.DeepEqual(t, map[string]interface{}{"param_0": "gothenburg"}, query.Params)
+		assert.DeepEqual(t, []spansql.Order{
+			{Expr: spansql.IsOp{LHS: spansql.ID("pickup_earliest_time"), RHS: spansql.Null}, Desc: true},
+			{Expr: spansql.ID("pickup_earliest_time"), Desc: true},
+			{Expr: spansql.ID("shipper_id")},
+			{Expr: spansql.ID("shipment_id")},
+		}, query.Order)
+		assert.Equal(t, int32(10), query.PageSize)
+		assert.Equal(t, int32(11), query.Limit)
+		assert.Equal(t, int64(0), query.Offset)
+		listQuery := freightdb.ListShipmentsRowsQuery{LineItems: true}.FromList(query)
+		assert.DeepEqual(t, freightdb.ListShipmentsRowsQuery{
+			Where:     query.Where,
+			Order:     query.Order,
+			Limit:     query.Limit,
+			Offset:    query.Offset,
+			Params:    query.Params,
+			LineItems: true,
+		}, listQuery)
+	})
+
+	t.Run("filter configuration error", func(t *testing.T) {
+		t.Parallel()
+		lineItemType := filtering.TypeMap(filtering.TypeString, filtering.TypeString)
+		lineItemDeclarations, err := filtering.NewDeclarations(
+			filtering.DeclareStandardFunctions(),
+			filtering.DeclareIdent("line_items", filtering.TypeList(lineItemType)),
+			filtering.DeclareIdent("line_items.title", filtering.TypeString),
+		)
+		assert.NilError(t, err)
+		_, err = spanlist.ParseRequest(
+			newListRequest(`line_items.title = "pallet"`, "", 10, ""),
+			shipments,
+			lineItemDeclarations,
+			spanlist.WithFilterOptions(
+				spanfiltering.WithInterleavedTable("line_items", &spanddl.Database{}, "shipments", "line_items"),
+			),
+		)
+		assert.ErrorContains(t, err, "unknown table shipments")
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("ordering configuration error", func(t *testing.T) {
+		t.Parallel()
+		_, err := spanlist.ParseRequest(
+			newListRequest("", "origin", 10, ""),
+			shipments,
+			declarations,
+			spanlist.WithOrderByColumns(map[string]spansql.ID{"origin": "origin_site"}),
+		)
+		assert.ErrorContains(t, err, "field origin mapped to unknown column origin_site")
+		assert.Equal(t, codes.Internal, status.Code(err))
+		_, err = spanlist.ParseRequest(
+			newListRequest("", "create_time", 10, ""),
+			shipments,
+			declarations,
+			spanlist.WithOrderingOptions(spanordering.WithCollation("create_time", "und:ci")),
+		)
+		assert.ErrorContains(t, err, "collation on field create_time of type TIMESTAMP")
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		t.Parallel()
+		request := newListRequest(`origin_site_id = "gothenburg"`, "", 10, "")
+		query, err := spanlist.ParseRequest(request, shipments, declarations)
+		assert.NilError(t, err)
+		assert.Equal(t, "", query.NextPageToken(10))
+		nextPageToken := query.NextPageToken(11)
+		assert.Assert(t, nextPageToken != "")
+		nextRequest := newListRequest(`origin_site_id = "gothenburg"`, "", 20, nextPageToken)
+		nextQuery, err := spanlist.ParseRequest(nextRequest, shipments, declarations)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(10), nextQuery.Offset)
+		assert.Equal(t, int32(21), nextQuery.Limit)
+		_, err = spanlist.ParseRequest(
+			newListRequest(`origin_site_id = "stockholm"`, "", 10, nextPageToken), shipments, declarations,
+		)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.ErrorContains(t, err, "invalid page_token")
+	})
+
+	t.Run("page sizes", func(t *testing.T) {
+		t.Parallel()
+		query, err := spanlist.ParseRequest(
+			newListRequest("", "", 0, ""), shipments, declarations, spanlist.WithDefaultPageSize(25),
+		)
+		assert.NilError(t, err)
+		assert.Equal(t, int32(25), query.PageSize)
+		assert.Equal(t, spansql.True, query.Where)
+		assert.DeepEqual(t, []spansql.Order{{Expr: spansql.ID("shipper_id")}, {Expr: spansql.ID("shipment_id")}}, query.Order)
+		query, err = spanlist.ParseRequest(newListRequest("", "", 5000, ""), shipments, declarations)
+		assert.NilError(t, err)
+		assert.Equal(t, int32(spanlist.DefaultMaxPageSize), query.PageSize)
+		nextPageToken := query.NextPageToken(spanlist.DefaultMaxPageSize + 1)
+		nextQuery, err := spanlist.ParseRequest(newListRequest("", "", 5000, nextPageToken), shipments, declarations)
+		assert.NilError(t, err)
+		assert.Equal(t, int64(spanlist.DefaultMaxPageSize), nextQuery.Offset)
+	})
+
+	for _, tt := range []struct {
+		name          string
+		request       spanlist.Request
+		errorContains string
+	}{
+		{
+			name:          "negative page size",
+			request:       newListRequest("", "", -1, ""),
+			errorContains: "invalid page_size: -1",
+		},
+		{
+			name:          "malformed page token",
+			request:       newListRequest("", "", 10, "foo"),
+			errorContains: "invalid page_token",
+		},
+		{
+			name:          "undeclared filter field",
+			request:       newListRequest(`destination_site_id = "gothenburg"`, "", 10, ""),
+			errorContains: "invalid filter",
+		},
+		{
+			name:          "unsupported filter wildcard",
+			request:       newListRequest(`origin_site_id = "*goth*burg"`, "", 10, ""),
+			errorContains: "wildcard only supported in leading or trailing positions",
+		},
+		{
+			name:          "malformed order_by",
+			request:       newListRequest("", "origin_site_id sideways", 10, ""),
+			errorContains: "invalid order_by",
+		},
+		{
+			name:          "unmapped order_by field",
+			request:       newListRequest("", "create_time", 10, ""),
+			errorContains: "unsupported field in order_by: create_time",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := spanlist.ParseRequest(tt.request, shipments, declarations, orderByColumns)
+			assert.ErrorContains(t, err, tt.errorContains)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
+
+// listRequest is a dynamic List request message with filter, order_by, page_size and page_token fields.
+type listRequest struct {
+	*dynamicpb.Message
+}
+
+var listRequestDescriptor = func() protoreflect.MessageDescriptor {
+	field := func(
+		name string,
+		number int32,
+		fieldType descriptorpb.FieldDescriptorProto_Type,
+	) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     fieldType.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("spanlist_test.proto"),
+		Package: proto.String("spanlist.test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("ListRowsRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("filter", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("order_by", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+					field("page_size", 3, descriptorpb.FieldDescriptorProto_TYPE_INT32),
+					field("page_token", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				},
+			},
+		},
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+	return file.Messages().ByName("ListRowsRequest")
+}()
+
+func newListRequest(filter, orderBy string, pageSize int32, pageToken string) *listRequest {
+	message := dynamicpb.NewMessage(listRequestDescriptor)
+	fields := listRequestDescriptor.Fields()
+	message.Set(fields.ByName("filter"), protoreflect.ValueOfString(filter))
+	message.Set(fields.ByName("order_by"), protoreflect.ValueOfString(orderBy))
+	message.Set(fields.ByName("page_size"), protoreflect.ValueOfInt32(pageSize))
+	message.Set(fields.ByName("page_token"), protoreflect.ValueOfString(pageToken))
+	return &listRequest{Message: message}
+}
+
+func (r *listRequest) GetFilter() string {
+	return r.Get(listRequestDescriptor.Fields().ByName("filter")).String()
+}
+
+func (r *listRequest) GetOrderBy() string {
+	return r.Get(listRequestDescriptor.Fields().ByName("order_by")).String()
+}
+
+func (r *listRequest) GetPageSize() int32 {
+	return int32(r.Get(listRequestDescriptor.Fields().ByName("page_size")).Int())
+}
+
+func (r *listRequest) GetPageToken() string {
+	return r.Get(listRequestDescriptor.Fields().ByName("page_token")).String()
+}
@@ -0,0 +1,14 @@
+package spanordering
+
+import (
+	"go.einride.tech/aip/ordering"
+	"go.einride.tech/spanner-aip/internal/postgresql"
+)
+
+// TranspileOrderByPostgreSQL transpiles a valid ordering.OrderBy expression to a PostgreSQL-dialect ORDER BY list,
+// without the ORDER BY keywords, for databases using the Spanner PostgreSQL interface.
+//
+// Relevance queries of WithScore and WithScoreNgrams must be positional parameters, e.g. spansql.Param("p1") for $1.
+func TranspileOrderByPostgreSQL(orderBy ordering.OrderBy, options ...TranspileOption) (string, error) {
+	return postgresql.NewPrinter(nil).Orders(TranspileOrderBy(orderBy, options...))
+}
@@ -0,0 +1,94 @@
+package spanordering
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/ordering"
+	"gotest.tools/v3/assert"
+)
+
+func TestTranspileOrderByPostgreSQL(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name          string
+		orderBy       ordering.OrderBy
+		options       []TranspileOption
+		expected      string
+		errorContains string
+	}{
+		{
+			name:     "empty",
+			orderBy:  ordering.OrderBy{},
+			expected: "",
+		},
+
+		{
+			name: "multiple fields",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+					{Path: "createTime", Desc: true},
+				},
+			},
+			expected: `display_name, "createTime" DESC`,
+		},
+
+		{
+			name: "JSON column",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "config.max_weight", Desc: true},
+				},
+			},
+			options: []TranspileOption{
+				WithJSONColumn("config", map[string]spansql.TypeBase{"max_weight": spansql.Float64}),
+			},
+			expected: `CAST(config->>'max_weight' AS double precision) DESC`,
+		},
+
+		{
+			name: "score",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "title"},
+				},
+			},
+			options:  []TranspileOption{WithScore("title_tokens", spansql.Param("p1"))},
+			expected: `spanner.score(title_tokens, $1) DESC, title`,
+		},
+
+		{
+			name: "nulls last",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+				},
+			},
+			options:  []TranspileOption{WithNullsOrder("display_name", NullsLast)},
+			expected: `display_name IS NULL, display_name`,
+		},
+
+		{
+			name: "collation",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+				},
+			},
+			options:       []TranspileOption{WithCollation("display_name", "und:ci")},
+			errorContains: "unsupported collation in PostgreSQL dialect",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := TranspileOrderByPostgreSQL(tt.orderBy, tt.options...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package spanordering
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/ordering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TableDescriptor describes the columns and primary key of a table, as implemented by generated table descriptors.
+type TableDescriptor interface {
+	TableID() spansql.ID
+	ColumnIDs() []spansql.ID
+	ColumnTypes() []spansql.Type
+	PrimaryKey() []spansql.KeyPart
+}
+
+// Field is a field that can be referenced in an ordering.
+type Field struct {
+	// Path of the field in the ordering, e.g. "display_name".
+	Path string
+	// Column that the field is stored in.
+	Column spansql.ID
+	// Type of the column.
+	Type spansql.Type
+}
+
+// Schema describes the fields that can be referenced in an ordering, and the primary key of the ordered table.
+type Schema struct {
+	// Fields that can be referenced in an ordering.
+	Fields []Field
+	// PrimaryKey of the ordered table, appended to orderings as a tie-breaker.
+	PrimaryKey []spansql.KeyPart
+}
+
+// TableSchema returns the schema of a table, with fields mapped to columns by the provided field to column mapping.
+// When the mapping is empty, all columns of the table can be referenced by their names.
+//
+// Mappings to unknown columns are configuration errors, and are returned with an Internal status.
+func TableSchema(table TableDescriptor, columns map[string]spansql.ID) (Schema, error) {
+	columnIDs, columnTypes := table.ColumnIDs(), table.ColumnTypes()
+	if len(columnIDs) != len(columnTypes) {
+		return Schema{}, status.Errorf(codes.Internal, "table %s: mismatched column IDs and types", table.TableID())
+	}
+	schema := Schema{PrimaryKey: table.PrimaryKey()}
+	if len(columns) == 0 {
+		for i, columnID := range columnIDs {
+			schema.Fields = append(schema.Fields, Field{Path: string(columnID), Column: columnID, Type: columnTypes[i]})
+		}
+		return schema, nil
+	}
+	for _, path := range slices.Sorted(maps.Keys(columns)) {
+		i := slices.Index(columnIDs, columns[path])
+		if i == -1 {
+			return Schema{}, status.Errorf(
+				codes.Internal, "table %s: field %s mapped to unknown column %s", table.TableID(), path, columns[path],
+			)
+		}
+		schema.Fields = append(schema.Fields, Field{Path: path, Column: columnIDs[i], Type: columnTypes[i]})
+	}
+	return schema, nil
+}
+
+// TranspileSchemaOrderBy validates an ordering.OrderBy expression against a schema, and transpiles it to a
+// spansql.Order expression on the mapped columns.
+//
+// Unknown fields, fields that can not be ordered by (ARRAY, JSON and BYTES columns) and duplicate fields are rejected
+// with an InvalidArgument error. Fields nested in JSON columns configured with WithJSONColumn can be ordered by.
+// Collations configured with WithCollation on fields that are not strings are rejected with an Internal error.
+//
+// Primary key columns that are not already ordered by are appended to the result, so that the ordering is total, as
+// required for stable pagination.
+func TranspileSchemaOrderBy(
+	orderBy ordering.OrderBy,
+	schema Schema,
+	options ...TranspileOption,
+) ([]spansql.Order, error) {
+	var opts transpileOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	result := make([]spansql.Order, 0, len(opts.scores)+len(orderBy.Fields)+len(schema.PrimaryKey))
+	result = append(result, opts.scores...)
+	orderedColumns := make(map[spansql.ID]struct{}, len(orderBy.Fields))
+	orderedPaths := make(map[string]struct{}, len(orderBy.Fields))
+	for _, field := range orderBy.Fields {
+		if _, ok := orderedPaths[field.Path]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate field in order_by: %s", field.Path)
+		}
+		orderedPaths[field.Path] = struct{}{}
+		if schemaField, ok := schema.field(field.Path); ok {
+			if !isSortable(schemaField.Type) {
+				return nil, status.Errorf(
+					codes.InvalidArgument, "unsortable field in order_by: %s (%s)", field.Path, schemaField.Type.SQL(),
+				)
+			}
+			if _, ok := orderedColumns[schemaField.Column]; ok {
+				return nil, status.Errorf(codes.InvalidArgument, "duplicate field in order_by: %s", field.Path)
+			}
+			if _, ok := opts.collations[field.Path]; ok && schemaField.Type.Base != spansql.String {
+				return nil, status.Errorf(
+					codes.Internal, "collation on field %s of type %s", field.Path, schemaField.Type.SQL(),
+				)
+			}
+			orderedColumns[schemaField.Column] = struct{}{}
+			result = append(result, opts.fieldOrders(field.Path, schemaField.Column, field.Desc)...)
+			continue
+		}
+		if root, path, ok := strings.Cut(field.Path, "."); ok {
+			if schemaField, ok := schema.field(root); ok && schemaField.Type.Base == spansql.JSON {
+				if fieldTypes, ok := opts.jsonColumns[string(schemaField.Column)]; ok {
+					if _, ok := opts.collations[field.Path]; ok {
+						// Nested fields without a type are ordered as STRING.
+						if base, ok := fieldTypes[path]; ok && base != spansql.String {
+							return nil, status.Errorf(
+								codes.Internal,
+								"collation on field %s of type %s",
+								field.Path,
+								spansql.Type{Base: base}.SQL(),
+							)
+						}
+					}
+					expr := jsonValue(string(schemaField.Column), strings.Split(path, "."), fieldTypes)
+					result = append(result, opts.fieldOrders(field.Path, expr, field.Desc)...)
+					continue
+				}
+			}
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported field in order_by: %s", field.Path)
+	}
+	for _, keyPart := range schema.PrimaryKey {
+		if _, ok := orderedColumns[keyPart.Column]; ok {
+			continue
+		}
+		result = append(result, spansql.Order{Expr: keyPart.Column, Desc: keyPart.Desc})
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func (s Schema) field(path string) (Field, bool) {
+	for _, field := range s.Fields {
+		if field.Path == path {
+			return field, true
+		}
+	}
+	return Field{}, false
+}
+
+// isSortable returns true if values of the type can be used in ORDER BY.
+func isSortable(t spansql.Type) bool {
+	if t.Array {
+		return false
+	}
+	switch t.Base {
+	case spansql.JSON, spansql.Bytes, spansql.Tokenlist, spansql.Proto:
+		return false
+	}
+	return true
+}
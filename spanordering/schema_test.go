@@ -0,0 +1,286 @@
+package spanordering_test
+
+import (
+	"testing"
+
+	"cloud.google.com/go/spanner/spansql"
+	"go.einride.tech/aip/ordering"
+	"go.einride.tech/spanner-aip/internal/examples/musicdb"
+	"go.einride.tech/spanner-aip/spanordering"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/v3/assert"
+)
+
+func TestTableSchema(t *testing.T) {
+	t.Parallel()
+	t.Run("columns", func(t *testing.T) {
+		t.Parallel()
+		schema, err := spanordering.TableSchema(musicdb.Descriptor().Albums(), nil)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, spanordering.Schema{
+			Fields: []spanordering.Field{
+				{Path: "SingerId", Column: "SingerId", Type: spansql.Type{Base: spansql.Int64}},
+				{Path: "AlbumId", Column: "AlbumId", Type: spansql.Type{Base: spansql.Int64}},
+				{Path: "AlbumTitle", Column: "AlbumTitle", Type: spansql.Type{Base: spansql.String, Len: spansql.MaxLen}},
+			},
+			PrimaryKey: []spansql.KeyPart{{Column: "SingerId"}, {Column: "AlbumId"}},
+		}, schema)
+	})
+
+	t.Run("mapping", func(t *testing.T) {
+		t.Parallel()
+		schema, err := spanordering.TableSchema(musicdb.Descriptor().Albums(), map[string]spansql.ID{
+			"title":     "AlbumTitle",
+			"album_id":  "AlbumId",
+			"singer_id": "SingerId",
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, []spanordering.Field{
+			{Path: "album_id", Column: "AlbumId", Type: spansql.Type{Base: spansql.Int64}},
+			{Path: "singer_id", Column: "SingerId", Type: spansql.Type{Base: spansql.Int64}},
+			{Path: "title", Column: "AlbumTitle", Type: spansql.Type{Base: spansql.String, Len: spansql.MaxLen}},
+		}, schema.Fields)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		t.Parallel()
+		_, err := spanordering.TableSchema(musicdb.Descriptor().Albums(), map[string]spansql.ID{"year": "ReleaseYear"})
+		assert.ErrorContains(t, err, "field year mapped to unknown column ReleaseYear")
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestTranspileSchemaOrderBy(t *testing.T) {
+	t.Parallel()
+	singers, err := spanordering.TableSchema(musicdb.Descriptor().Singers(), map[string]spansql.ID{
+		"singer_id":   "SingerId",
+		"first_name":  "FirstName",
+		"last_name":   "LastName",
+		"singer_info": "SingerInfo",
+	})
+	assert.NilError(t, err)
+	shipments := spanordering.Schema{
+		Fields: []spanordering.Field{
+			{Path: "display_name", Column: "display_name", Type: spansql.Type{Base: spansql.String}},
+			{Path: "tags", Column: "tags", Type: spansql.Type{Array: true, Base: spansql.String}},
+			{Path: "config", Column: "config", Type: spansql.Type{Base: spansql.JSON}},
+		},
+		PrimaryKey: []spansql.KeyPart{{Column: "shipper_id"}, {Column: "shipment_id", Desc: true}},
+	}
+	for _, tt := range []struct {
+		name          string
+		orderBy       ordering.OrderBy
+		schema        spanordering.Schema
+		options       []spanordering.TranspileOption
+		expected      []spansql.Order
+		errorContains string
+	}{
+		{
+			name:     "empty",
+			schema:   singers,
+			expected: []spansql.Order{{Expr: spansql.ID("SingerId")}},
+		},
+
+		{
+			name:    "mapped fields",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "last_name"}, {Path: "first_name", Desc: true}}},
+			schema:  singers,
+			expected: []spansql.Order{
+				{Expr: spansql.ID("LastName")},
+				{Expr: spansql.ID("FirstName"), Desc: true},
+				{Expr: spansql.ID("SingerId")},
+			},
+		},
+
+		{
+			name:     "ordered primary key",
+			orderBy:  ordering.OrderBy{Fields: []ordering.Field{{Path: "singer_id", Desc: true}}},
+			schema:   singers,
+			expected: []spansql.Order{{Expr: spansql.ID("SingerId"), Desc: true}},
+		},
+
+		{
+			name:    "descending primary key",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "display_name"}}},
+			schema:  shipments,
+			expected: []spansql.Order{
+				{Expr: spansql.ID("display_name")},
+				{Expr: spansql.ID("shipper_id")},
+				{Expr: spansql.ID("shipment_id"), Desc: true},
+			},
+		},
+
+		{
+			name:    "JSON column field",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "config.max_weight", Desc: true}}},
+			schema:  shipments,
+			options: []spanordering.TranspileOption{
+				spanordering.WithJSONColumn("config", map[string]spansql.TypeBase{"max_weight": spansql.Float64}),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{
+						Name: "CAST",
+						Args: []spansql.Expr{
+							spansql.TypedExpr{
+								Type: spansql.Type{Base: spansql.Float64},
+								Expr: spansql.Func{
+									Name: "JSON_VALUE",
+									Args: []spansql.Expr{spansql.ID("config"), spansql.StringLiteral("$.max_weight")},
+								},
+							},
+						},
+					},
+					Desc: true,
+				},
+				{Expr: spansql.ID("shipper_id")},
+				{Expr: spansql.ID("shipment_id"), Desc: true},
+			},
+		},
+
+		{
+			name:    "score",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "last_name"}}},
+			schema:  singers,
+			options: []spanordering.TranspileOption{spanordering.WithScore("Name_Tokens", spansql.Param("query"))},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{Name: "SCORE", Args: []spansql.Expr{spansql.ID("Name_Tokens"), spansql.Param("query")}},
+					Desc: true,
+				},
+				{Expr: spansql.ID("LastName")},
+				{Expr: spansql.ID("SingerId")},
+			},
+		},
+
+		{
+			name:    "nulls last and collation",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "last_name"}}},
+			schema:  singers,
+			options: []spanordering.TranspileOption{
+				spanordering.WithDefaultNullsOrder(spanordering.NullsLast),
+				spanordering.WithCollation("last_name", "und:ci"),
+			},
+			expected: []spansql.Order{
+				{Expr: spansql.IsOp{LHS: spansql.ID("LastName"), RHS: spansql.Null}},
+				{
+					Expr: spansql.Func{
+						Name: "COLLATE",
+						Args: []spansql.Expr{spansql.ID("LastName"), spansql.StringLiteral("und:ci")},
+					},
+				},
+				{Expr: spansql.ID("SingerId")},
+			},
+		},
+
+		{
+			name:    "JSON column field with collation",
+			orderBy: ordering.OrderBy{Fields: []ordering.Field{{Path: "config.carrier"}}},
+			schema:  shipments,
+			options: []spanordering.TranspileOption{
+				spanordering.WithJSONColumn("config", map[string]spansql.TypeBase{"max_weight": spansql.Float64}),
+				spanordering.WithCollation("config.carrier", "und:ci"),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{
+						Name: "COLLATE",
+						Args: []spansql.Expr{
+							spansql.Func{
+								Name: "JSON_VALUE",
+								Args: []spansql.Expr{spansql.ID("config"), spansql.StringLiteral("$.carrier")},
+							},
+							spansql.StringLiteral("und:ci"),
+						},
+					},
+				},
+				{Expr: spansql.ID("shipper_id")},
+				{Expr: spansql.ID("shipment_id"), Desc: true},
+			},
+		},
+
+		{
+			name:          "unknown field",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "LastName"}}},
+			schema:        singers,
+			errorContains: "unsupported field in order_by: LastName",
+		},
+
+		{
+			name:          "unknown JSON column field",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "config.max_weight"}}},
+			schema:        shipments,
+			errorContains: "unsupported field in order_by: config.max_weight",
+		},
+
+		{
+			name:          "bytes",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "singer_info"}}},
+			schema:        singers,
+			errorContains: "unsortable field in order_by: singer_info (BYTES(MAX))",
+		},
+
+		{
+			name:          "array",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "tags"}}},
+			schema:        shipments,
+			errorContains: "unsortable field in order_by: tags (ARRAY<STRING>)",
+		},
+
+		{
+			name:          "JSON",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "config"}}},
+			schema:        shipments,
+			errorContains: "unsortable field in order_by: config (JSON)",
+		},
+
+		{
+			name:          "duplicate",
+			orderBy:       ordering.OrderBy{Fields: []ordering.Field{{Path: "last_name"}, {Path: "last_name", Desc: true}}},
+			schema:        singers,
+			errorContains: "duplicate field in order_by: last_name",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := spanordering.TranspileSchemaOrderBy(tt.orderBy, tt.schema, tt.options...)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				assert.Equal(t, codes.InvalidArgument, status.Code(err))
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestTranspileSchemaOrderBy_collationOnNonString(t *testing.T) {
+	t.Parallel()
+	schema, err := spanordering.TableSchema(musicdb.Descriptor().Singers(), nil)
+	assert.NilError(t, err)
+	_, err = spanordering.TranspileSchemaOrderBy(
+		ordering.OrderBy{Fields: []ordering.Field{{Path: "SingerId"}}},
+		schema,
+		spanordering.WithCollation("SingerId", "und:ci"),
+	)
+	assert.ErrorContains(t, err, "collation on field SingerId of type INT64")
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestTranspileSchemaOrderBy_collationOnNonStringJSONField(t *testing.T) {
+	t.Parallel()
+	schema := spanordering.Schema{
+		Fields:     []spanordering.Field{{Path: "config", Column: "config", Type: spansql.Type{Base: spansql.JSON}}},
+		PrimaryKey: []spansql.KeyPart{{Column: "shipment_id"}},
+	}
+	_, err := spanordering.TranspileSchemaOrderBy(
+		ordering.OrderBy{Fields: []ordering.Field{{Path: "config.max_weight"}}},
+		schema,
+		spanordering.WithJSONColumn("config", map[string]spansql.TypeBase{"max_weight": spansql.Float64}),
+		spanordering.WithCollation("config.max_weight", "und:ci"),
+	)
+	assert.ErrorContains(t, err, "collation on field config.max_weight of type FLOAT64")
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
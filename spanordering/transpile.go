@@ -7,23 +7,167 @@ import (
 	"go.einride.tech/aip/ordering"
 )
 
+// TranspileOption configures TranspileOrderBy.
+type TranspileOption func(options *transpileOptions)
+
+// WithJSONColumn transpiles fields nested in the provided JSON column to typed JSON extraction.
+// For example, ordering by config.max_weight on a JSON column config becomes
+// `CAST(JSON_VALUE(config, '$.max_weight') AS FLOAT64)`.
+//
+// The types of nested fields are keyed by their path within the column, e.g. "max_weight". Nested fields without a type
+// are ordered as STRING.
+func WithJSONColumn(column string, fieldTypes map[string]spansql.TypeBase) TranspileOption {
+	return func(options *transpileOptions) {
+		if options.jsonColumns == nil {
+			options.jsonColumns = make(map[string]map[string]spansql.TypeBase)
+		}
+		options.jsonColumns[column] = fieldTypes
+	}
+}
+
+// WithScore orders by descending SCORE relevance of the query on the TOKENLIST column, before the ordered fields.
+// The query is typically a parameter, bound to the same value as in a search filter.
+func WithScore(column spansql.ID, query spansql.Expr) TranspileOption {
+	return func(options *transpileOptions) {
+		options.scores = append(options.scores, score("SCORE", column, query))
+	}
+}
+
+// WithScoreNgrams orders by descending SCORE_NGRAMS relevance of the query on the TOKENLIST column, before the ordered
+// fields. The query is typically a parameter, bound to the same value as in a searchNgrams filter.
+func WithScoreNgrams(column spansql.ID, query spansql.Expr) TranspileOption {
+	return func(options *transpileOptions) {
+		options.scores = append(options.scores, score("SCORE_NGRAMS", column, query))
+	}
+}
+
+// NullsOrder specifies whether NULL values are ordered before or after non-NULL values.
+type NullsOrder int
+
+const (
+	// NullsDefault orders NULL values as the smallest values, i.e. first in ascending and last in descending order.
+	NullsDefault NullsOrder = iota
+	// NullsFirst orders NULL values before non-NULL values.
+	NullsFirst
+	// NullsLast orders NULL values after non-NULL values.
+	NullsLast
+)
+
+// WithNullsOrder orders NULL values of the field first or last, regardless of the direction of the field.
+// For example, ordering by display_name with NullsLast becomes `display_name IS NULL, display_name`.
+//
+// The nulls order is emitted as a leading `IS NULL` order on the field instead of NULLS FIRST or NULLS LAST, which
+// spansql orders can't express. The leading order is only added when the nulls order differs from the default.
+func WithNullsOrder(path string, nulls NullsOrder) TranspileOption {
+	return func(options *transpileOptions) {
+		if options.nullsOrders == nil {
+			options.nullsOrders = make(map[string]NullsOrder)
+		}
+		options.nullsOrders[path] = nulls
+	}
+}
+
+// WithDefaultNullsOrder orders NULL values first or last for fields without a nulls order from WithNullsOrder.
+func WithDefaultNullsOrder(nulls NullsOrder) TranspileOption {
+	return func(options *transpileOptions) {
+		options.defaultNullsOrder = nulls
+	}
+}
+
+// WithCollation orders the string field by the collation, e.g. "und:ci" for case-insensitive ordering.
+// For example, ordering by display_name with "und:ci" becomes `COLLATE(display_name, "und:ci")`.
+//
+// The collation is passed to the COLLATE function unchanged.
+//
+// Collations are not supported in the PostgreSQL dialect.
+func WithCollation(path string, collation string) TranspileOption {
+	return func(options *transpileOptions) {
+		if options.collations == nil {
+			options.collations = make(map[string]string)
+		}
+		options.collations[path] = collation
+	}
+}
+
+func score(function string, column spansql.ID, query spansql.Expr) spansql.Order {
+	return spansql.Order{Expr: spansql.Func{Name: function, Args: []spansql.Expr{column, query}}, Desc: true}
+}
+
+type transpileOptions struct {
+	jsonColumns       map[string]map[string]spansql.TypeBase
+	scores            []spansql.Order
+	nullsOrders       map[string]NullsOrder
+	defaultNullsOrder NullsOrder
+	collations        map[string]string
+}
+
+// fieldOrders returns the orders of a field with the expression expr, with the collation and nulls order of the field.
+func (o *transpileOptions) fieldOrders(path string, expr spansql.Expr, desc bool) []spansql.Order {
+	order := spansql.Order{Expr: expr, Desc: desc}
+	if collation, ok := o.collations[path]; ok {
+		order.Expr = collate(expr, collation)
+	}
+	nulls, ok := o.nullsOrders[path]
+	if !ok {
+		nulls = o.defaultNullsOrder
+	}
+	// NULL values are the smallest values, so only NULLS FIRST in descending order and NULLS LAST in ascending order
+	// need a leading order on whether the value is NULL.
+	if (nulls == NullsFirst && desc) || (nulls == NullsLast && !desc) {
+		return []spansql.Order{{Expr: spansql.IsOp{LHS: expr, RHS: spansql.Null}, Desc: nulls == NullsFirst}, order}
+	}
+	return []spansql.Order{order}
+}
+
+// collate returns the expression with the collation, e.g. `COLLATE(display_name, "und:ci")`.
+func collate(expr spansql.Expr, collation string) spansql.Expr {
+	return spansql.Func{Name: "COLLATE", Args: []spansql.Expr{expr, spansql.StringLiteral(collation)}}
+}
+
 // TranspileOrderBy transpiles a valid ordering.OrderBy expression to a spansql.Order expression.
-func TranspileOrderBy(orderBy ordering.OrderBy) []spansql.Order {
-	if len(orderBy.Fields) == 0 {
+func TranspileOrderBy(orderBy ordering.OrderBy, options ...TranspileOption) []spansql.Order {
+	var opts transpileOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	if len(orderBy.Fields) == 0 && len(opts.scores) == 0 {
 		return nil
 	}
-	result := make([]spansql.Order, 0, len(orderBy.Fields))
+	result := make([]spansql.Order, 0, len(opts.scores)+len(orderBy.Fields))
+	result = append(result, opts.scores...)
 	for _, field := range orderBy.Fields {
-		subFields := strings.Split(field.Path, ".")
-		if len(subFields) == 1 {
-			result = append(result, spansql.Order{Expr: spansql.ID(subFields[0]), Desc: field.Desc})
-			continue
-		}
-		pathExp := make(spansql.PathExp, 0, len(subFields))
-		for _, subField := range subFields {
-			pathExp = append(pathExp, spansql.ID(subField))
-		}
-		result = append(result, spansql.Order{Expr: pathExp, Desc: field.Desc})
+		result = append(result, opts.fieldOrders(field.Path, fieldExpr(field.Path, opts.jsonColumns), field.Desc)...)
 	}
 	return result
 }
+
+func fieldExpr(path string, jsonColumns map[string]map[string]spansql.TypeBase) spansql.Expr {
+	subFields := strings.Split(path, ".")
+	if len(subFields) == 1 {
+		return spansql.ID(subFields[0])
+	}
+	if fieldTypes, ok := jsonColumns[subFields[0]]; ok {
+		return jsonValue(subFields[0], subFields[1:], fieldTypes)
+	}
+	pathExp := make(spansql.PathExp, 0, len(subFields))
+	for _, subField := range subFields {
+		pathExp = append(pathExp, spansql.ID(subField))
+	}
+	return pathExp
+}
+
+func jsonValue(column string, path []string, fieldTypes map[string]spansql.TypeBase) spansql.Expr {
+	jsonPath := strings.Join(path, ".")
+	value := spansql.Func{
+		Name: "JSON_VALUE",
+		Args: []spansql.Expr{spansql.ID(column), spansql.StringLiteral("$." + jsonPath)},
+	}
+	base, ok := fieldTypes[jsonPath]
+	if !ok || base == spansql.String {
+		return value
+	}
+	return spansql.Func{
+		Name: "CAST",
+		Args: []spansql.Expr{spansql.TypedExpr{Type: spansql.Type{Base: base}, Expr: value}},
+	}
+}
@@ -13,6 +13,7 @@ func TestTranspileOrderBy(t *testing.T) {
 	for _, tt := range []struct {
 		name     string
 		orderBy  ordering.OrderBy
+		options  []TranspileOption
 		expected []spansql.Order
 	}{
 		{
@@ -70,10 +71,200 @@ func TestTranspileOrderBy(t *testing.T) {
 				{Expr: spansql.PathExp{spansql.ID("bar"), spansql.ID("baz")}, Desc: true},
 			},
 		},
+
+		{
+			name: "JSON column",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "config.max_weight", Desc: true},
+					{Path: "config.name"},
+				},
+			},
+			options: []TranspileOption{
+				WithJSONColumn("config", map[string]spansql.TypeBase{"max_weight": spansql.Float64}),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{
+						Name: "CAST",
+						Args: []spansql.Expr{
+							spansql.TypedExpr{
+								Type: spansql.Type{Base: spansql.Float64},
+								Expr: spansql.Func{
+									Name: "JSON_VALUE",
+									Args: []spansql.Expr{spansql.ID("config"), spansql.StringLiteral("$.max_weight")},
+								},
+							},
+						},
+					},
+					Desc: true,
+				},
+				{
+					Expr: spansql.Func{
+						Name: "JSON_VALUE",
+						Args: []spansql.Expr{spansql.ID("config"), spansql.StringLiteral("$.name")},
+					},
+				},
+			},
+		},
+
+		{
+			name: "score",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "foo"},
+				},
+			},
+			options: []TranspileOption{
+				WithScore("foo_tokens", spansql.Param("query")),
+				WithScoreNgrams("bar_tokens", spansql.StringLiteral("bar")),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{Name: "SCORE", Args: []spansql.Expr{spansql.ID("foo_tokens"), spansql.Param("query")}},
+					Desc: true,
+				},
+				{
+					Expr: spansql.Func{
+						Name: "SCORE_NGRAMS",
+						Args: []spansql.Expr{spansql.ID("bar_tokens"), spansql.StringLiteral("bar")},
+					},
+					Desc: true,
+				},
+				{Expr: spansql.ID("foo")},
+			},
+		},
+
+		{
+			name:    "score only",
+			orderBy: ordering.OrderBy{},
+			options: []TranspileOption{
+				WithScore("foo_tokens", spansql.Param("query")),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{Name: "SCORE", Args: []spansql.Expr{spansql.ID("foo_tokens"), spansql.Param("query")}},
+					Desc: true,
+				},
+			},
+		},
+
+		{
+			name: "nulls last",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+				},
+			},
+			options: []TranspileOption{WithNullsOrder("display_name", NullsLast)},
+			expected: []spansql.Order{
+				{Expr: spansql.IsOp{LHS: spansql.ID("display_name"), RHS: spansql.Null}},
+				{Expr: spansql.ID("display_name")},
+			},
+		},
+
+		{
+			name: "nulls first, desc",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name", Desc: true},
+				},
+			},
+			options: []TranspileOption{WithNullsOrder("display_name", NullsFirst)},
+			expected: []spansql.Order{
+				{Expr: spansql.IsOp{LHS: spansql.ID("display_name"), RHS: spansql.Null}, Desc: true},
+				{Expr: spansql.ID("display_name"), Desc: true},
+			},
+		},
+
+		{
+			name: "default nulls order",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+					{Path: "create_time", Desc: true},
+					{Path: "title"},
+				},
+			},
+			options: []TranspileOption{
+				WithDefaultNullsOrder(NullsLast),
+				WithNullsOrder("title", NullsFirst),
+			},
+			expected: []spansql.Order{
+				{Expr: spansql.IsOp{LHS: spansql.ID("display_name"), RHS: spansql.Null}},
+				{Expr: spansql.ID("display_name")},
+				{Expr: spansql.ID("create_time"), Desc: true},
+				{Expr: spansql.ID("title")},
+			},
+		},
+
+		{
+			name: "collation",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name"},
+				},
+			},
+			options: []TranspileOption{
+				WithCollation("display_name", "und:ci"),
+				WithNullsOrder("display_name", NullsLast),
+			},
+			expected: []spansql.Order{
+				{Expr: spansql.IsOp{LHS: spansql.ID("display_name"), RHS: spansql.Null}},
+				{
+					Expr: spansql.Func{
+						Name: "COLLATE",
+						Args: []spansql.Expr{spansql.ID("display_name"), spansql.StringLiteral("und:ci")},
+					},
+				},
+			},
+		},
+
+		{
+			name: "collation with language tag",
+			orderBy: ordering.OrderBy{
+				Fields: []ordering.Field{
+					{Path: "display_name", Desc: true},
+				},
+			},
+			options: []TranspileOption{
+				WithCollation("display_name", "sv-SE:ci"),
+			},
+			expected: []spansql.Order{
+				{
+					Expr: spansql.Func{
+						Name: "COLLATE",
+						Args: []spansql.Expr{spansql.ID("display_name"), spansql.StringLiteral("sv-SE:ci")},
+					},
+					Desc: true,
+				},
+			},
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			assert.DeepEqual(t, tt.expected, TranspileOrderBy(tt.orderBy))
+			assert.DeepEqual(t, tt.expected, TranspileOrderBy(tt.orderBy, tt.options...))
 		})
 	}
 }
+
+func TestTranspileOrderBy_collationSQL(t *testing.T) {
+	t.Parallel()
+	orders := TranspileOrderBy(
+		ordering.OrderBy{Fields: []ordering.Field{{Path: "display_name", Desc: true}}},
+		WithCollation("display_name", "und:ci"),
+		WithNullsOrder("display_name", NullsFirst),
+	)
+	query := spansql.Query{
+		Select: spansql.Select{
+			List: []spansql.Expr{spansql.Star},
+			From: []spansql.SelectFrom{spansql.SelectFromTable{Table: "Shippers"}},
+		},
+		Order: orders,
+	}
+	assert.Equal(
+		t,
+		`SELECT * FROM Shippers ORDER BY display_name IS NULL DESC, COLLATE(display_name, "und:ci") DESC`,
+		query.SQL(),
+	)
+}
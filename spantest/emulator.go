@@ -123,6 +123,11 @@ func (fx *EmulatorFixture) Context() context.Context {
 	return fx.ctx
 }
 
+// EmulatorHost returns the host of the Spanner emulator.
+func (fx *EmulatorFixture) EmulatorHost() string {
+	return fx.emulatorHost
+}
+
 // NewDatabase creates a new database with a random ID based on the passed options.
 func (fx *EmulatorFixture) NewDatabase(t testing.TB, options ...DatabaseCreationOption) *spanner.Client {
 	t.Helper()